@@ -0,0 +1,186 @@
+package dialect
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shellyln/go-loose-json-parser/internal/grammar"
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Builder of the JSON dialect.
+// The dialect accepts RFC 8259, the built-in extensions, and the pieces that are added to the builder.
+type Builder struct {
+	spec grammar.Spec
+	err  error
+}
+
+// Start building the dialect that has the built-in extensions f.
+// (e.g. `jsonlp.Features_JSON5`, `jsonlp.Features_All &^ jsonlp.Feature_DateTime`)
+func NewBuilder(f jsonlp.Features) *Builder {
+	return &Builder{spec: grammar.Spec{Features: uint32(f)}}
+}
+
+// Turn on the built-in extensions f.
+func (b *Builder) Enable(f jsonlp.Features) *Builder {
+	b.spec.Features |= uint32(f)
+	return b
+}
+
+// Turn off the built-in extensions f.
+func (b *Builder) Disable(f jsonlp.Features) *Builder {
+	b.spec.Features &^= uint32(f)
+	return b
+}
+
+// Add the value alternative that is tried before the built-in primitive values.
+// fn should push one AST that has the Go value.
+func (b *Builder) Value(fn ParserFn) *Builder {
+	if fn == nil {
+		b.setError("Value: parser is nil")
+		return b
+	}
+	b.spec.Values = append(b.spec.Values, fn)
+	return b
+}
+
+// Add the key form that is tried after the built-in keys.
+// fn should push one AST that has the string value.
+func (b *Builder) Key(fn ParserFn) *Builder {
+	if fn == nil {
+		b.setError("Key: parser is nil")
+		return b
+	}
+	b.spec.Keys = append(b.spec.Keys, fn)
+	return b
+}
+
+// Add the key-value separators in addition to `:`. (e.g. `:=`)
+func (b *Builder) Separator(s ...string) *Builder {
+	if b.checkTokens("Separator", s...) {
+		b.spec.Separators = append(b.spec.Separators, s...)
+	}
+	return b
+}
+
+// Add the delimiters of the array elements and the object members in addition to `,`. (e.g. `;`)
+func (b *Builder) Delimiter(s ...string) *Builder {
+	if b.checkTokens("Delimiter", s...) {
+		b.spec.Delimiters = append(b.spec.Delimiters, s...)
+	}
+	return b
+}
+
+// Add the line comment that starts with prefix. (e.g. `--`)
+func (b *Builder) LineComment(prefix string) *Builder {
+	if b.checkTokens("LineComment", prefix) {
+		b.spec.LineComments = append(b.spec.LineComments, prefix)
+	}
+	return b
+}
+
+// Add the block comment that is enclosed by open and close. (e.g. `{-`, `-}`)
+func (b *Builder) BlockComment(open, close string) *Builder {
+	if b.checkTokens("BlockComment", open, close) {
+		b.spec.BlockComments = append(b.spec.BlockComments, grammar.BlockComment{Open: open, Close: close})
+	}
+	return b
+}
+
+// Add the strings that are enclosed by quote.
+// If multiLine is true, the strings can have the line breaks.
+// The strings are also accepted as the keys.
+func (b *Builder) Quote(quote string, multiLine bool) *Builder {
+	if b.checkTokens("Quote", quote) {
+		b.spec.Quotes = append(b.spec.Quotes, grammar.Quote{Quote: quote, MultiLine: multiLine})
+	}
+	return b
+}
+
+func (b *Builder) setError(msg string) {
+	if b.err == nil {
+		b.err = errors.New(msg)
+	}
+}
+
+func (b *Builder) checkTokens(name string, s ...string) bool {
+	for _, x := range s {
+		if x == "" {
+			b.setError(name + ": empty token")
+			return false
+		}
+	}
+	return true
+}
+
+// Build the parser of the dialect.
+// It returns the first error of the builder methods.
+// The builder can be reused after that.
+func (b *Builder) Build() (*Parser, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	spec := b.spec
+	spec.Values = append([]ParserFn(nil), spec.Values...)
+	spec.Keys = append([]ParserFn(nil), spec.Keys...)
+	spec.Separators = append([]string(nil), spec.Separators...)
+	spec.Delimiters = append([]string(nil), spec.Delimiters...)
+	spec.LineComments = append([]string(nil), spec.LineComments...)
+	spec.BlockComments = append([]grammar.BlockComment(nil), spec.BlockComments...)
+	spec.Quotes = append([]grammar.Quote(nil), spec.Quotes...)
+	return &Parser{grammar: grammar.New[*jsonlp.ParseOptions](spec)}, nil
+}
+
+// Parser of the dialect.
+// It is safe for concurrent use.
+type Parser struct {
+	grammar grammar.Grammar[*jsonlp.ParseOptions]
+}
+
+// src: JSON of the dialect
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+// Dialect, Features and DisableFastPath are ignored.
+//
+// parsed:
+// Same as jsonlp.ParseJSONWithOptions
+// Errors are *jsonlp.SyntaxError (or jsonlp.SyntaxErrors if opts.ErrorRecovery is set) as same as jsonlp.ParseJSONWithOptions.
+func (p *Parser) Parse(s string, opts *jsonlp.ParseOptions) (interface{}, error) {
+	return p.grammar.Parse(s, opts)
+}
+
+// src: JSON of the dialect
+//
+// opts:
+// Same as Parse.
+//
+// If ctx is done while parsing, the SyntaxError with jsonlp.ErrorCode_Canceled is returned.
+func (p *Parser) ParseContext(ctx context.Context, s string, opts *jsonlp.ParseOptions) (interface{}, error) {
+	return p.grammar.ParseContext(ctx, s, opts)
+}
+
+// Parser of the strings of the extensions f.
+// It can be used only in the parsers that are added to the builder.
+func String(f jsonlp.Features) ParserFn {
+	return grammar.String(uint32(f))
+}
+
+// Parser of the numbers of the extensions f.
+// It can be used only in the parsers that are added to the builder.
+func Number(f jsonlp.Features) ParserFn {
+	return grammar.Number(uint32(f))
+}
+
+// Parser of the primitive values of the extensions f.
+// It can be used only in the parsers that are added to the builder.
+func Primitive(f jsonlp.Features) ParserFn {
+	return grammar.Primitive(uint32(f))
+}
+
+// Parser of the whitespaces and the comments of the extensions f. It pushes nothing.
+// It can be used only in the parsers that are added to the builder.
+func Space(f jsonlp.Features) ParserFn {
+	return grammar.Space(uint32(f))
+}
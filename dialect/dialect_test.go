@@ -0,0 +1,275 @@
+package dialect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shellyln/go-loose-json-parser/dialect"
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+	. "github.com/shellyln/takenoco/base"
+	. "github.com/shellyln/takenoco/string"
+)
+
+// `@include "path"` directive
+var includeDirective = Trans(
+	FlatGroup(
+		Trans(Seq("@include"), Erase),
+		dialect.Space(jsonlp.Features_JSONC),
+		dialect.String(jsonlp.Features_StrictJSON),
+	),
+	func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+		return AstSlice{{
+			Type:  AstType_Any,
+			Value: map[string]interface{}{"@include": asts[0].Value},
+		}}, nil
+	},
+)
+
+func TestBuilder1(t *testing.T) {
+	p, err := dialect.NewBuilder(jsonlp.Features_JSONC).
+		Separator(":=").
+		Delimiter(";").
+		LineComment("--").
+		BlockComment("{-", "-}").
+		Quote("%", true).
+		Value(includeDirective).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		s        string
+		want     interface{}
+		wantCode jsonlp.ErrorCode
+	}{{
+		name: "b1-1a",
+		s:    `{"a": 1, "b": [true, null]}`,
+		want: map[string]interface{}{"a": float64(1), "b": []interface{}{true, nil}},
+	}, {
+		name: "b1-1b",
+		s:    "{\"a\" := 1; \"b\": [1; 2, 3] -- comment\n; %c%: %x\ny% {- comment -}}",
+		want: map[string]interface{}{
+			"a": float64(1),
+			"b": []interface{}{float64(1), float64(2), float64(3)},
+			"c": "x\ny",
+		},
+	}, {
+		name: "b1-1c",
+		s:    `{"a": @include "a.json", /* comment */ "b": 1} // comment`,
+		want: map[string]interface{}{
+			"a": map[string]interface{}{"@include": "a.json"},
+			"b": float64(1),
+		},
+	}, {
+		name:     "b1-2a",
+		s:        `[1, 2,]`,
+		wantCode: jsonlp.ErrorCode_ExtensionNotAllowed,
+	}, {
+		name:     "b1-2b",
+		s:        `{"a" = 1}`,
+		wantCode: jsonlp.ErrorCode_ExtensionNotAllowed,
+	}, {
+		name:     "b1-2c",
+		s:        `{- comment`,
+		wantCode: jsonlp.ErrorCode_UnterminatedComment,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Parse(tt.s, nil)
+			if tt.wantCode != 0 {
+				var se *jsonlp.SyntaxError
+				if !errors.As(err, &se) {
+					t.Errorf("Parse: error = %v, want SyntaxError", err)
+					return
+				}
+				if se.Code != tt.wantCode {
+					t.Errorf("Parse: code = %v, want %v", se.Code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Parse: error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse: v = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder2(t *testing.T) {
+	// The options are applied as same as jsonlp.ParseJSONWithOptions.
+	p, err := dialect.NewBuilder(jsonlp.Features_All).Disable(jsonlp.Feature_DateTime).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := p.Parse(`{a: 1, b: 2}`, &jsonlp.ParseOptions{NumberMode: jsonlp.Number_Integer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"a": int64(1), "b": int64(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse: v = %v, want %v", got, want)
+	}
+
+	_, err = p.Parse(`[1, 2, 3]`, &jsonlp.ParseOptions{MaxArrayLength: 2})
+	var se *jsonlp.SyntaxError
+	if !errors.As(err, &se) || se.Code != jsonlp.ErrorCode_ArrayLengthLimitExceeded {
+		t.Errorf("Parse: error = %v, want ErrorCode_ArrayLengthLimitExceeded", err)
+	}
+
+	_, err = p.Parse("[2020-12-31, ]\n[", &jsonlp.ParseOptions{ErrorRecovery: true})
+	var ses jsonlp.SyntaxErrors
+	if !errors.As(err, &ses) || len(ses) == 0 {
+		t.Errorf("Parse: error = %v, want SyntaxErrors", err)
+	}
+}
+
+func TestBuilder3(t *testing.T) {
+	if _, err := dialect.NewBuilder(0).Delimiter("").Build(); err == nil {
+		t.Errorf("Build: want error")
+	}
+	if _, err := dialect.NewBuilder(0).Value(nil).Build(); err == nil {
+		t.Errorf("Build: want error")
+	}
+
+	// The parsers that are built before are not changed.
+	b := dialect.NewBuilder(jsonlp.Features_StrictJSON)
+	p1, _ := b.Build()
+	p2, _ := b.Delimiter(";").Build()
+	if _, err := p1.Parse(`[1; 2]`, nil); err == nil {
+		t.Errorf("Parse: want error")
+	}
+	if _, err := p2.Parse(`[1; 2]`, nil); err != nil {
+		t.Errorf("Parse: error = %v", err)
+	}
+}
+
+func TestLiteral1(t *testing.T) {
+	now := time.Date(2020, 12, 31, 18, 20, 30, 0, time.UTC)
+	env := map[string]string{"HOME": "/home/user"}
+	constant := func(v interface{}) func() (interface{}, error) {
+		return func() (interface{}, error) { return v, nil }
+	}
+
+	p, err := dialect.NewBuilder(jsonlp.Features_All).
+		Keyword("yes", constant(true)).
+		Keyword("no", constant(false)).
+		Keyword("on", constant(true)).
+		Keyword("off", constant(false)).
+		Keyword("nil", constant(nil)).
+		Keyword("@now", func() (interface{}, error) { return now, nil }).
+		Literal(
+			FlatGroup(Seq("$"), OneOrMoreTimes(CharClassFn(func(c rune) bool {
+				return c == '_' || 'A' <= c && c <= 'Z'
+			}))),
+			func(s string) (interface{}, error) {
+				if v, ok := env[s[1:]]; ok {
+					return v, nil
+				}
+				return nil, errors.New("Undefined variable: " + s)
+			},
+		).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		s       string
+		want    interface{}
+		wantErr bool
+	}{{
+		name: "l1-1a",
+		s:    `[yes, no, on, off, nil, @now, $HOME]`,
+		want: []interface{}{true, false, true, false, nil, now, "/home/user"},
+	}, {
+		name: "l1-1b",
+		s:    `{yes: yes, "no": "no"}`,
+		want: map[string]interface{}{"yes": true, "no": "no"},
+	}, {
+		name: "l1-1c",
+		// Built-in values are not changed.
+		s:    `[true, null, 1, "yes"]`,
+		want: []interface{}{true, nil, float64(1), "yes"},
+	}, {
+		name:    "l1-2a",
+		s:       `[nil2]`,
+		wantErr: true,
+	}, {
+		name:    "l1-2b",
+		s:       `[yes_no]`,
+		wantErr: true,
+	}, {
+		name:    "l1-2c",
+		s:       `[$USER]`,
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Parse(tt.s, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse: v = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Parse: error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse: v = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLiteral2(t *testing.T) {
+	// The literals are scoped to the parser.
+	p1, _ := dialect.NewBuilder(jsonlp.Features_All).Keyword("yes", func() (interface{}, error) {
+		return true, nil
+	}).Build()
+	p2, _ := dialect.NewBuilder(jsonlp.Features_All).Build()
+
+	if got, err := p1.Parse(`yes`, nil); err != nil || got != true {
+		t.Errorf("Parse: v, error = %v, %v, want true", got, err)
+	}
+	if got, err := p2.Parse(`yes`, nil); err == nil {
+		t.Errorf("Parse: v = %v, want error", got)
+	}
+	if got, err := jsonlp.ParseJSONWithOptions(`yes`, nil); err == nil {
+		t.Errorf("ParseJSONWithOptions: v = %v, want error", got)
+	}
+}
+
+func TestLiteral3(t *testing.T) {
+	// The error of the callback is wrapped in the SyntaxError.
+	errUndefined := errors.New("Undefined variable")
+	p, _ := dialect.NewBuilder(jsonlp.Features_All).Keyword("$USER", func() (interface{}, error) {
+		return nil, errUndefined
+	}).Build()
+
+	_, err := p.Parse("[1,\n  $USER]", nil)
+	var se *jsonlp.SyntaxError
+	if !errors.As(err, &se) {
+		t.Fatalf("Parse: error = %v, want SyntaxError", err)
+	}
+	if se.Code != jsonlp.ErrorCode_InvalidLiteral || se.Line != 2 || se.Col != 3 {
+		t.Errorf("Parse: error = %v (%v), want InvalidLiteral at 2:3", se.Code, se)
+	}
+	if se.Message != "Undefined variable" {
+		t.Errorf("Parse: message = %q", se.Message)
+	}
+	if !errors.Is(err, errUndefined) {
+		t.Errorf("Parse: error = %v, want to wrap the callback error", err)
+	}
+}
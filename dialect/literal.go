@@ -0,0 +1,95 @@
+package dialect
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shellyln/go-loose-json-parser/internal/grammar"
+	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
+	. "github.com/shellyln/takenoco/base"
+	"github.com/shellyln/takenoco/extra"
+)
+
+// Add the keyword that is parsed as the value of fn. (e.g. `yes`, `off`, `nil`, `@now`)
+// The keyword is tried alongside the built-in primitive values, and is case-sensitive.
+// fn is called each time the keyword is parsed.
+func (b *Builder) Keyword(word string, fn func() (interface{}, error)) *Builder {
+	if !b.checkTokens("Keyword", word) {
+		return b
+	}
+	if fn == nil {
+		b.setError("Keyword: callback is nil")
+		return b
+	}
+	return b.Literal(keyword(word), func(string) (interface{}, error) {
+		return fn()
+	})
+}
+
+// Add the literal that the grammar fn matches. (e.g. `$ENV_NAME`)
+// The literal is tried alongside the built-in primitive values.
+// conv is called with the source text of the literal, and returns the value.
+// If conv returns the error, parsing fails with the SyntaxError (ErrorCode_InvalidLiteral) that wraps it.
+func (b *Builder) Literal(fn ParserFn, conv func(s string) (interface{}, error)) *Builder {
+	if fn == nil || conv == nil {
+		b.setError("Literal: parser or callback is nil")
+		return b
+	}
+	b.spec.Values = append(b.spec.Values, literal(fn, conv))
+	return b
+}
+
+// Parser that matches the word.
+func keyword(word string) ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		if !strings.HasPrefix(ctx.Str[ctx.Position:], word) {
+			ctx.Length = 0
+			ctx.MatchStatus = MatchStatus_Unmatched
+			return ctx, nil
+		}
+		ctx.Position += len(word)
+		ctx.Length = len(word)
+		ctx.MatchStatus = MatchStatus_Matched
+		return ctx, nil
+	}
+}
+
+// If the literal ends with the word character, it should be followed by the word boundary.
+// (e.g. `nil` does not match `nil2`)
+func literal(fn ParserFn, conv func(s string) (interface{}, error)) ParserFn {
+	boundary := extra.UnicodeWordBoundary()
+	return func(ctx ParserContext) (ParserContext, error) {
+		out, err := fn(ctx)
+		if err != nil || out.MatchStatus != MatchStatus_Matched {
+			return out, err
+		}
+		s := ctx.Str[ctx.Position:out.Position]
+
+		if c, _ := utf8.DecodeLastRuneInString(s); isWordRune(c) {
+			b, err := boundary(out)
+			if err != nil || b.MatchStatus != MatchStatus_Matched {
+				ctx.MatchStatus = MatchStatus_Unmatched
+				return ctx, err
+			}
+		}
+
+		v, err := conv(s)
+		if err != nil {
+			// Error at the start of the literal
+			ctx.MatchStatus = MatchStatus_Error
+			return ctx, grammar.LiteralError(err)
+		}
+		n := len(ctx.AstStack)
+		out.AstStack = append(out.AstStack[:n:n], Ast{
+			ClassName: class.Literal,
+			Type:      AstType_Any,
+			Value:     v,
+		})
+		return out, nil
+	}
+}
+
+func isWordRune(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
@@ -0,0 +1,23 @@
+// Conversions of the math/big values that the jsonlp and marshal packages share.
+package bignum
+
+import (
+	"math/big"
+)
+
+// Convert *big.Int, *big.Float, *big.Rat to the nearest float64.
+// If v is not one of them, it returns 0.
+func ToFloat64(v interface{}) float64 {
+	switch x := v.(type) {
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(x).Float64()
+		return f
+	case *big.Float:
+		f, _ := x.Float64()
+		return f
+	case *big.Rat:
+		f, _ := x.Float64()
+		return f
+	}
+	return 0
+}
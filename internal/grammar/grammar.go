@@ -0,0 +1,83 @@
+// Pieces of the JSON grammar that the dialect package builds.
+// The jsonlp package implements the functions of this package in its init.
+package grammar
+
+import (
+	"context"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Pieces of the JSON grammar.
+// The grammar accepts RFC 8259, the extensions of Features, and the other pieces.
+type Spec struct {
+	Features uint32 // Built-in extensions (jsonlp.Features)
+
+	// Parsers of the values that are tried before the built-in primitive values.
+	// Each parser should push one AST that has the Go value.
+	Values []ParserFn
+
+	// Parsers of the object keys that are tried after the built-in keys.
+	// Each parser should push one AST that has the string value.
+	Keys []ParserFn
+
+	Separators    []string       // Key-value separators in addition to `:` (e.g. `:=`)
+	Delimiters    []string       // Delimiters of the array elements and the object members in addition to `,` (e.g. `;`)
+	LineComments  []string       // Prefixes of the line comments in addition to the built-in comments (e.g. `--`)
+	BlockComments []BlockComment // Block comments in addition to the built-in comments (e.g. `{-`, `-}`)
+	Quotes        []Quote        // Quotes of the strings in addition to the built-in strings (e.g. `«`)
+}
+
+// Block comment of Spec.
+type BlockComment struct {
+	Open  string
+	Close string
+}
+
+// Quote of the strings of Spec.
+// The string has the escape sequences of the extensions.
+type Quote struct {
+	Quote     string // Opening and closing quote
+	MultiLine bool   // If true, the string can have the line breaks
+}
+
+// JSON grammar that is built from Spec.
+// O is *jsonlp.ParseOptions. (This package cannot import jsonlp)
+// It is safe for concurrent use.
+type Grammar[O any] interface {
+	Parse(s string, opts O) (interface{}, error)
+	ParseContext(ctx context.Context, s string, opts O) (interface{}, error)
+}
+
+// Builder of the grammar that SetNew sets.
+var newGrammar interface{}
+
+// Set the builder of the JSON grammar.
+func SetNew[O any](fn func(spec Spec) Grammar[O]) {
+	newGrammar = fn
+}
+
+// Build the JSON grammar from the pieces.
+// O should be the same as the type argument of SetNew.
+func New[O any](spec Spec) Grammar[O] {
+	return newGrammar.(func(spec Spec) Grammar[O])(spec)
+}
+
+var (
+	// Parser of the strings of the extensions f. It pushes the AST of the string value.
+	String func(f uint32) ParserFn
+
+	// Parser of the numbers of the extensions f. It pushes the AST of the number value.
+	Number func(f uint32) ParserFn
+
+	// Parser of the primitive values (strings, numbers, booleans, null, dates) of the extensions f.
+	// It pushes the AST of the value.
+	Primitive func(f uint32) ParserFn
+
+	// Parser of the whitespaces and the comments of the extensions f. It pushes nothing.
+	Space func(f uint32) ParserFn
+
+	// Wrap the error of the literal callback.
+	// It is converted to the SyntaxError with ErrorCode_InvalidLiteral that unwraps err.
+	LiteralError func(err error) error
+)
@@ -8,6 +8,10 @@ const (
 	Int               = "Int"
 	Uint              = "Uint"
 	Float             = "Float"
+	Number            = "Number"
+	BigInt            = "BigInt"
+	BigFloat          = "BigFloat"
+	BigRat            = "BigRat"
 	Complex           = "Complex"
 	Bool              = "Bool"
 	String            = "String"
@@ -18,4 +22,12 @@ const (
 	NaN               = "NaN"
 	Inf               = "Inf"
 	DateTimeStr       = "DateTimeStr"
+	Date              = "Date"
+	Time              = "Time"
+	DateTime          = "DateTime"
+	LocalDate         = "LocalDate"
+	LocalTime         = "LocalTime"
+	LocalDateTime     = "LocalDateTime"
+	CstNode           = "CstNode"
+	Literal           = "Literal"
 )
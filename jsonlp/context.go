@@ -0,0 +1,64 @@
+package jsonlp
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// The context is checked at every cancelCheckInterval values.
+const cancelCheckInterval = 256
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Check the cancellation of the context at regular intervals.
+func (state *parseState) checkCancel() error {
+	if state.ctx == nil {
+		return nil
+	}
+	state.ticks++
+	if state.ticks%cancelCheckInterval != 0 {
+		return nil
+	}
+	return state.ctx.Err()
+}
+
+// Check the cancellation of the context before parsing the value.
+func cancelable(fn ParserFn) ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		if err := ctx.Tag.(parseOptions).state.checkCancel(); err != nil {
+			ctx.MatchStatus = MatchStatus_Error
+			return ctx, err
+		}
+		return fn(ctx)
+	}
+}
+
+// src: Loose JSON
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+//
+// If ctx is done while parsing, the SyntaxError with ErrorCode_Canceled is returned.
+// It wraps ctx.Err() and has the position where parsing is stopped.
+func ParseJSONContext(ctx context.Context, s string, opts *ParseOptions) (interface{}, error) {
+	o := newParseOptions(opts, false)
+	o.state.ctx = ctx
+	return parseJSON(s, o)
+}
+
+// src: Loose TOML
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+//
+// If ctx is done while parsing, the SyntaxError with ErrorCode_Canceled is returned.
+// It wraps ctx.Err() and has the position where parsing is stopped.
+func ParseTOMLContext(ctx context.Context, s string, opts *ParseOptions) (interface{}, error) {
+	o := newParseOptions(opts, true)
+	o.state.ctx = ctx
+	return parse(tomlParserOf(o), s, o)
+}
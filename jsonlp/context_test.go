@@ -0,0 +1,67 @@
+package jsonlp_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+// Context that is canceled after Err() is called n times.
+type countdownContext struct {
+	context.Context
+	n int
+}
+
+func (c *countdownContext) Err() error {
+	c.n--
+	if c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestParseContext1(t *testing.T) {
+	src := "[" + strings.Repeat("1,\n", 10000) + "]"
+
+	got, err := jsonlp.ParseJSONContext(context.Background(), src, nil)
+	if err != nil || len(got.([]interface{})) != 10000 {
+		t.Errorf("ParseJSONContext() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = jsonlp.ParseJSONContext(ctx, src, nil)
+	var se *jsonlp.SyntaxError
+	if !errors.Is(err, context.Canceled) || !errors.As(err, &se) || se.Code != jsonlp.ErrorCode_Canceled || se.Offset != 0 {
+		t.Errorf("ParseJSONContext() error = %v, want canceled at the start", err)
+	}
+
+	for _, recovery := range []bool{false, true} {
+		ctx := &countdownContext{Context: context.Background(), n: 3}
+		_, err = jsonlp.ParseJSONContext(ctx, src, &jsonlp.ParseOptions{ErrorRecovery: recovery})
+		if !errors.Is(err, context.Canceled) || !errors.As(err, &se) || se.Code != jsonlp.ErrorCode_Canceled {
+			t.Errorf("ParseJSONContext() error = %v, want canceled", err)
+			continue
+		}
+		if se.Line <= 1 || len(src) <= se.Offset {
+			t.Errorf("ParseJSONContext() error position = %v, want the middle of the source", se.Position)
+		}
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = jsonlp.ParseTOMLContext(ctx, "a = 1\n", nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &se) || se.Code != jsonlp.ErrorCode_Canceled {
+		t.Errorf("ParseTOMLContext() error = %v, want deadline exceeded", err)
+	}
+
+	tomlSrc := strings.Repeat("[[a]]\nb = 1\n", 2000)
+	_, err = jsonlp.ParseTOMLContext(&countdownContext{Context: context.Background(), n: 2}, tomlSrc, nil)
+	if !errors.Is(err, context.Canceled) || !errors.As(err, &se) || se.Line <= 1 {
+		t.Errorf("ParseTOMLContext() error = %v, want canceled", err)
+	}
+}
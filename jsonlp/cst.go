@@ -0,0 +1,390 @@
+package jsonlp
+
+import (
+	"strings"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
+	. "github.com/shellyln/takenoco/base"
+	strparser "github.com/shellyln/takenoco/string"
+)
+
+// Kind of the CST node.
+type NodeKind int
+
+const (
+	Node_Document      NodeKind = iota
+	Node_Object                 // `{ ... }` (JSON object and TOML inline table)
+	Node_Array                  // `[ ... ]`
+	Node_Member                 // Key-value pair
+	Node_Key                    // Key or dotted key
+	Node_Table                  // TOML `[table]` section (header and key-value pairs)
+	Node_ArrayOfTables          // TOML `[[array-of-tables]]` section (header and key-value pairs)
+	Node_Header                 // TOML table header
+	Node_Value                  // Literal of the primitive value or the part of the key
+	Node_Comment                // Comment without the line break
+	Node_Whitespace             // Whitespaces and line breaks
+	Node_Punct                  // `{`, `}`, `[`, `]`, `[[`, `]]`, `,`, `:`, `=`, `=>`, `.`
+)
+
+// Convert NodeKind to a string.
+func (k NodeKind) String() string {
+	switch k {
+	case Node_Document:
+		return "Document"
+	case Node_Object:
+		return "Object"
+	case Node_Array:
+		return "Array"
+	case Node_Member:
+		return "Member"
+	case Node_Key:
+		return "Key"
+	case Node_Table:
+		return "Table"
+	case Node_ArrayOfTables:
+		return "ArrayOfTables"
+	case Node_Header:
+		return "Header"
+	case Node_Value:
+		return "Value"
+	case Node_Comment:
+		return "Comment"
+	case Node_Whitespace:
+		return "Whitespace"
+	case Node_Punct:
+		return "Punct"
+	default:
+		return "Unknown"
+	}
+}
+
+// Node of the concrete syntax tree (CST).
+//
+// Leaf nodes (Node_Value, Node_Comment, Node_Whitespace, Node_Punct) have the source text.
+// The text of the other nodes is the concatenation of the children.
+type Node struct {
+	Kind  NodeKind
+	Start int    // Byte offset of the start of the node (inclusive)
+	End   int    // Byte offset of the end of the node (exclusive)
+	Text  string // Source text of the leaf node
+
+	// Value of the literal if Kind is Node_Value.
+	// Name of the key ([]string) if Kind is Node_Key.
+	Value interface{}
+
+	Children []*Node
+}
+
+// Source text of the node.
+// The text of the document node is the same as the source.
+func (n *Node) String() string {
+	var sb strings.Builder
+	n.writeTo(&sb)
+	return sb.String()
+}
+
+func (n *Node) writeTo(sb *strings.Builder) {
+	if n.Children == nil {
+		sb.WriteString(n.Text)
+		return
+	}
+	for _, c := range n.Children {
+		c.writeTo(sb)
+	}
+}
+
+func cstAst(node *Node) Ast {
+	return Ast{
+		ClassName: class.CstNode,
+		Type:      AstType_Any,
+		Value:     node,
+	}
+}
+
+// Replace the resulting ASTs by the leaf node that has the matched source text.
+func cstLeaf(kind NodeKind, fn ParserFn) ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		out, err := fn(ctx)
+		if err != nil || out.MatchStatus != MatchStatus_Matched {
+			return out, err
+		}
+		node := &Node{
+			Kind:  kind,
+			Start: ctx.Position,
+			End:   out.Position,
+			Text:  ctx.Str[ctx.Position:out.Position],
+		}
+		n := len(ctx.AstStack)
+		if kind == Node_Value && len(out.AstStack) > n {
+			node.Value = out.AstStack[len(out.AstStack)-1].Value
+		}
+		out.AstStack = append(out.AstStack[:n:n], cstAst(node))
+		return out, nil
+	}
+}
+
+// Replace the resulting nodes by the node that has them as the children.
+func cstNode(kind NodeKind, fn ParserFn) ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		out, err := fn(ctx)
+		if err != nil || out.MatchStatus != MatchStatus_Matched {
+			return out, err
+		}
+		node := &Node{
+			Kind:     kind,
+			Start:    ctx.Position,
+			End:      out.Position,
+			Children: make([]*Node, 0),
+		}
+		n := len(ctx.AstStack)
+		for _, ast := range out.AstStack[n:] {
+			if c, ok := ast.Value.(*Node); ok {
+				node.Children = append(node.Children, c)
+			}
+		}
+		if kind == Node_Key {
+			node.Value = keyNameOf(node)
+		}
+		out.AstStack = append(out.AstStack[:n:n], cstAst(node))
+		return out, nil
+	}
+}
+
+func keyNameOf(node *Node) []string {
+	ret := make([]string, 0, 1)
+	for _, c := range node.Children {
+		if c.Kind == Node_Value {
+			if s, ok := c.Value.(string); ok {
+				ret = append(ret, s)
+			}
+		}
+	}
+	return ret
+}
+
+func cstPunct(s string) ParserFn {
+	return cstLeaf(Node_Punct, strparser.Seq(s))
+}
+
+func cstLinebreak() ParserFn {
+	return First(
+		cstLeaf(Node_Whitespace, strparser.CharClass("\r\n", "\r", "\n")),
+		LookAhead(strparser.End()),
+	)
+}
+
+// Whitespaces and comments
+func cstSp0() ParserFn {
+	return ZeroOrMoreTimes(First(
+		cstLeaf(Node_Whitespace, OneOrMoreTimes(whitespace(Features_All))),
+		cstLeaf(Node_Comment, commentLookAheadLb(Features_All)),
+	))
+}
+
+// Whitespaces and comments
+func cstSp0NoLb() ParserFn {
+	return ZeroOrMoreTimes(First(
+		cstLeaf(Node_Whitespace, OneOrMoreTimes(strparser.WhitespaceNoLineBreak())),
+		cstLeaf(Node_Comment, commentLookAheadLb(Features_All)),
+	))
+}
+
+func cstKey(allowLb bool) ParserFn {
+	part := cstLeaf(Node_Value, First(stringValue(Features_All), escapedIdentifier()))
+	sp := If(allowLb, cstSp0(), cstSp0NoLb())
+	return cstNode(Node_Key, First(
+		FlatGroup(
+			part,
+			OneOrMoreTimes(sp, cstPunct("."), sp, part),
+		),
+		part,
+	))
+}
+
+func cstValue() ParserFn {
+	return First(
+		cstLeaf(Node_Value, primitiveValue(Features_All)),
+		Indirect(cstArray),
+		Indirect(cstObject),
+	)
+}
+
+func cstArray() ParserFn {
+	return cstNode(Node_Array, FlatGroup(
+		cstPunct("["),
+		cstSp0(),
+		ZeroOrOnce(
+			FlatGroup(cstValue(), cstSp0()),
+			ZeroOrMoreTimes(
+				cstPunct(","),
+				cstSp0(),
+				First(
+					cstValue(),
+					LookAhead(strparser.Seq("]")),
+				),
+				cstSp0(),
+			),
+		),
+		ZeroOrOnce(cstPunct(","), cstSp0()),
+		cstPunct("]"),
+	))
+}
+
+func cstMember() ParserFn {
+	return cstNode(Node_Member, FlatGroup(
+		cstKey(true),
+		cstSp0(),
+		cstLeaf(Node_Punct, First(strparser.CharClass(":"), strparser.CharClass("=>"), strparser.CharClass("="))),
+		cstSp0(),
+		cstValue(),
+	))
+}
+
+func cstObject() ParserFn {
+	return cstNode(Node_Object, FlatGroup(
+		cstPunct("{"),
+		cstSp0(),
+		ZeroOrOnce(
+			cstMember(),
+			cstSp0(),
+			ZeroOrMoreTimes(
+				cstPunct(","),
+				cstSp0(),
+				First(
+					FlatGroup(cstMember(), cstSp0()),
+					LookAhead(strparser.Seq("}")),
+				),
+			),
+			ZeroOrOnce(cstPunct(","), cstSp0()),
+		),
+		cstPunct("}"),
+	))
+}
+
+func cstJSONDocument() ParserFn {
+	return cstNode(Node_Document, FlatGroup(
+		Start(),
+		cstSp0(),
+		cstValue(),
+		cstSp0(),
+		strparser.End(),
+	))
+}
+
+func cstTomlMember() ParserFn {
+	member := func(value ParserFn) ParserFn {
+		return cstNode(Node_Member, FlatGroup(
+			cstKey(false),
+			cstSp0NoLb(),
+			cstPunct("="),
+			cstSp0NoLb(),
+			value,
+		))
+	}
+	return First(
+		FlatGroup(
+			member(cstLeaf(Node_Value, primitiveValue(Features_All))),
+			cstSp0NoLb(),
+			cstLinebreak(),
+		),
+		member(First(
+			Indirect(cstArray),
+			Indirect(cstObject),
+		)),
+	)
+}
+
+func cstTomlSection(kind NodeKind, open, close string) ParserFn {
+	return cstNode(kind, FlatGroup(
+		cstNode(Node_Header, FlatGroup(
+			cstPunct(open),
+			cstSp0NoLb(),
+			cstKey(false),
+			cstSp0NoLb(),
+			cstPunct(close),
+		)),
+		cstSp0NoLb(),
+		cstLinebreak(),
+		cstSp0(),
+		ZeroOrMoreTimes(
+			cstTomlMember(),
+			cstSp0(),
+		),
+	))
+}
+
+func cstTOMLDocument() ParserFn {
+	return cstNode(Node_Document, FlatGroup(
+		Start(),
+		cstSp0(),
+		ZeroOrMoreTimes(
+			First(
+				cstTomlMember(),
+				cstTomlSection(Node_ArrayOfTables, "[[", "]]"),
+				cstTomlSection(Node_Table, "[", "]"),
+			),
+			cstSp0(),
+		),
+		strparser.End(),
+	))
+}
+
+var (
+	jsonCstParser ParserFn
+	tomlCstParser ParserFn
+)
+
+func init() {
+	jsonCstParser = cstJSONDocument()
+	tomlCstParser = cstTOMLDocument()
+}
+
+// Validate the document by the value parser, and then build the CST.
+func parseCST(valueParser, cstParser ParserFn, s string, opts parseOptions) (*Node, error) {
+	opts.recovery = false
+	if _, err := parse(valueParser, s, opts); err != nil {
+		return nil, err
+	}
+	return buildCST(cstParser, s, opts)
+}
+
+// Build the CST of the document that is already validated.
+func buildCST(cstParser ParserFn, s string, opts parseOptions) (*Node, error) {
+	ctx := *strparser.NewStringParserContext(s)
+	ctx.Tag = opts
+
+	out, err := cstParser(ctx)
+	if err != nil {
+		return nil, newSyntaxError(s, out.SourcePosition, err)
+	}
+	if out.MatchStatus != MatchStatus_Matched || len(out.AstStack) == 0 {
+		return nil, newSyntaxError(s, out.SourcePosition, nil)
+	}
+	return out.AstStack[len(out.AstStack)-1].Value.(*Node), nil
+}
+
+// src: Loose JSON
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+// ErrorRecovery is ignored.
+//
+// Returns the concrete syntax tree (CST) that keeps comments, whitespaces and the source text of the literals.
+// `String()` of the returned node is the same as the source.
+func ParseJSONCST(s string, opts *ParseOptions) (*Node, error) {
+	o := newParseOptions(opts, false)
+	return parseCST(jsonGrammarOf(o).document, jsonCstParser, s, o)
+}
+
+// src: Loose TOML
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+// ErrorRecovery is ignored.
+//
+// Returns the concrete syntax tree (CST) that keeps comments, whitespaces and the source text of the literals.
+// `String()` of the returned node is the same as the source.
+func ParseTOMLCST(s string, opts *ParseOptions) (*Node, error) {
+	o := newParseOptions(opts, true)
+	return parseCST(tomlParserOf(o), tomlCstParser, s, o)
+}
@@ -0,0 +1,127 @@
+package jsonlp_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+// Check that the spans of the children are contiguous and cover the parent.
+func checkSpans(t *testing.T, s string, n *jsonlp.Node) {
+	t.Helper()
+	if n.Children == nil {
+		if s[n.Start:n.End] != n.Text {
+			t.Errorf("%v: Text = %q, want %q", n.Kind, n.Text, s[n.Start:n.End])
+		}
+		return
+	}
+	pos := n.Start
+	for _, c := range n.Children {
+		if c.Start != pos {
+			t.Errorf("%v: Start = %v, want %v", c.Kind, c.Start, pos)
+		}
+		checkSpans(t, s, c)
+		pos = c.End
+	}
+	if pos != n.End {
+		t.Errorf("%v: End = %v, want %v", n.Kind, n.End, pos)
+	}
+}
+
+// Collect the nodes of the kind in the document order.
+func collectNodes(n *jsonlp.Node, kind jsonlp.NodeKind) []*jsonlp.Node {
+	var ret []*jsonlp.Node
+	if n.Kind == kind {
+		ret = append(ret, n)
+	}
+	for _, c := range n.Children {
+		ret = append(ret, collectNodes(c, kind)...)
+	}
+	return ret
+}
+
+func TestCST1(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		isTOML   bool
+		keys     [][]string
+		comments []string
+		wantErr  bool
+	}{{
+		name:     "cst1-1a",
+		s:        "// head\n{\r\n  a: 1, /* c1 */ 'b' => [1, ],\n  \"c\" . d = {e: 0x1_0,},  # c2\n  f: `x\ny`\n}\n",
+		keys:     [][]string{{"a"}, {"b"}, {"c", "d"}, {"e"}, {"f"}},
+		comments: []string{"// head", "/* c1 */", "# c2"},
+	}, {
+		name:     "cst1-1b",
+		s:        "  [1, 2.50, NaN, -Infinity, 1+2i, 2020-01-02 03:04:05Z, null, true,]  ",
+		comments: nil,
+	}, {
+		name:     "cst1-1c",
+		s:        `"str"`,
+		comments: nil,
+	}, {
+		name:    "cst1-1d",
+		s:       "{a: 1,, b: 2}",
+		wantErr: true,
+	}, {
+		name:     "cst1-2a",
+		s:        "# head\n\na = 1 # c1\n\"b\".c = '''\nx\n'''\n\n[d . e] # c2\nf = [\n  1, # c3\n  2,\n]\ng = { h = 1 }\n\n[[i]]\nj = 1979-05-27T07:32:00Z\r\n[[i]]\n",
+		isTOML:   true,
+		keys:     [][]string{{"a"}, {"b", "c"}, {"d", "e"}, {"f"}, {"g"}, {"h"}, {"i"}, {"j"}, {"i"}},
+		comments: []string{"# head", "# c1", "# c2", "# c3"},
+	}, {
+		name:   "cst1-2b",
+		s:      "a = 1",
+		isTOML: true,
+		keys:   [][]string{{"a"}},
+	}, {
+		name:    "cst1-2c",
+		s:       "a = 1 b = 2",
+		isTOML:  true,
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *jsonlp.Node
+			var err error
+			if tt.isTOML {
+				got, err = jsonlp.ParseTOMLCST(tt.s, nil)
+			} else {
+				got, err = jsonlp.ParseJSONCST(tt.s, nil)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCST() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.String() != tt.s {
+				t.Errorf("String() = %q, want %q", got.String(), tt.s)
+			}
+			if got.Kind != jsonlp.Node_Document || got.Start != 0 || got.End != len(tt.s) {
+				t.Errorf("Document = %v [%v, %v)", got.Kind, got.Start, got.End)
+			}
+			checkSpans(t, tt.s, got)
+
+			var keys [][]string
+			for _, n := range collectNodes(got, jsonlp.Node_Key) {
+				keys = append(keys, n.Value.([]string))
+			}
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("keys = %v, want %v", keys, tt.keys)
+			}
+			var comments []string
+			for _, n := range collectNodes(got, jsonlp.Node_Comment) {
+				comments = append(comments, n.Text)
+			}
+			if !reflect.DeepEqual(comments, tt.comments) {
+				t.Errorf("comments = %v, want %v", comments, tt.comments)
+			}
+		})
+	}
+}
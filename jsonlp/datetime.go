@@ -1,9 +1,11 @@
 package jsonlp
 
 import (
+	"time"
+
 	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
 	. "github.com/shellyln/takenoco/base"
-	. "github.com/shellyln/takenoco/string"
+	strparser "github.com/shellyln/takenoco/string"
 )
 
 // Customized `extra. DateTimeStr()`
@@ -11,46 +13,49 @@ import (
 // Parse the ISO 8601 datetime string.
 // (yyyy-MM-ddThh:mmZ , ... , yyyy-MM-ddThh:mm:ss.fffffffffZ)
 // (yyyy-MM-ddThh:mm+00:00 , ... , yyyy-MM-ddThh:mm:ss.fffffffff+00:00)
+// (yyyy-MM-ddThh:mm , ... , yyyy-MM-ddThh:mm:ss.fffffffff)
+//
+// The result is normalized to `yyyy-MM-ddThh:mm:ss.fffffffff` followed by the offset `+hh:mm` if any.
 func dateTimeStr() ParserFn {
 	return Trans(
 		FlatGroup(
-			ZeroOrOnce(Seq("-")),
-			Repeat(Times{Min: 4, Max: -1}, Number()),
-			Seq("-"),
-			CharRange(RuneRange{Start: '0', End: '1'}),
-			CharRange(RuneRange{Start: '0', End: '9'}),
-			Seq("-"),
-			CharRange(RuneRange{Start: '0', End: '3'}),
-			CharRange(RuneRange{Start: '0', End: '9'}),
+			ZeroOrOnce(strparser.Seq("-")),
+			Repeat(Times{Min: 4, Max: -1}, strparser.Number()),
+			strparser.Seq("-"),
+			strparser.CharRange(RuneRange{Start: '0', End: '1'}),
+			strparser.CharRange(RuneRange{Start: '0', End: '9'}),
+			strparser.Seq("-"),
+			strparser.CharRange(RuneRange{Start: '0', End: '3'}),
+			strparser.CharRange(RuneRange{Start: '0', End: '9'}),
 			First(
-				Seq("T"),
+				strparser.Seq("T"),
 				// TOML allows Datetime format with date and time delimited by space (RFC 3339 section 5.6)
 				FlatGroup(
-					erase(Seq(" ")),
+					erase(strparser.Seq(" ")),
 					Zero(Ast{
 						Type:  AstType_String,
 						Value: "T",
 					}),
 				),
 			),
-			CharRange(RuneRange{Start: '0', End: '2'}),
-			CharRange(RuneRange{Start: '0', End: '9'}),
-			Seq(":"),
-			CharRange(RuneRange{Start: '0', End: '5'}),
-			CharRange(RuneRange{Start: '0', End: '9'}),
+			strparser.CharRange(RuneRange{Start: '0', End: '2'}),
+			strparser.CharRange(RuneRange{Start: '0', End: '9'}),
+			strparser.Seq(":"),
+			strparser.CharRange(RuneRange{Start: '0', End: '5'}),
+			strparser.CharRange(RuneRange{Start: '0', End: '9'}),
 			First(
 				FlatGroup(
-					Seq(":"),
-					CharRange(RuneRange{Start: '0', End: '6'}),
-					CharRange(RuneRange{Start: '0', End: '9'}),
+					strparser.Seq(":"),
+					strparser.CharRange(RuneRange{Start: '0', End: '6'}),
+					strparser.CharRange(RuneRange{Start: '0', End: '9'}),
 					First(
 						FlatGroup(
-							Seq("."),
+							strparser.Seq("."),
 							Trans(
 								Repeat(Times{Min: 1, Max: 9}, // 3: milli, 6: micro, 9: nano
-									CharRange(RuneRange{Start: '0', End: '9'}),
+									strparser.CharRange(RuneRange{Start: '0', End: '9'}),
 								),
-								Concat,
+								strparser.Concat,
 								func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
 									return AstSlice{{
 										Type:  AstType_String,
@@ -72,31 +77,150 @@ func dateTimeStr() ParserFn {
 			),
 			First(
 				FlatGroup(
-					erase(Seq("Z")),
+					erase(strparser.Seq("Z")),
 					Zero(Ast{
 						Type:  AstType_String,
 						Value: "+00:00",
 					}),
 				),
 				FlatGroup(
-					CharClass("+", "-"),
+					strparser.CharClass("+", "-"),
 					Repeat(Times{Min: 2, Max: 2},
-						CharRange(RuneRange{Start: '0', End: '9'}),
+						strparser.CharRange(RuneRange{Start: '0', End: '9'}),
 					),
-					Seq(":"),
-					CharRange(RuneRange{Start: '0', End: '5'}),
-					CharRange(RuneRange{Start: '0', End: '9'}),
-				),
-				FlatGroup(
-					// TOML allows Datetime format without timezone
-					Zero(Ast{
-						Type:  AstType_String,
-						Value: "+00:00",
-					}),
+					strparser.Seq(":"),
+					strparser.CharRange(RuneRange{Start: '0', End: '5'}),
+					strparser.CharRange(RuneRange{Start: '0', End: '9'}),
 				),
+				// TOML allows Datetime format without timezone (local datetime)
+				Zero(Ast{
+					Type:  AstType_String,
+					Value: "",
+				}),
 			),
 		),
-		Concat,
+		strparser.Concat,
 		ChangeClassName(class.DateTimeStr),
 	)
 }
+
+// Whether the normalized datetime string of dateTimeStr() has the offset.
+func hasOffset(s string) bool {
+	return len(s) >= 6 && (s[len(s)-6] == '+' || s[len(s)-6] == '-') && s[len(s)-3] == ':'
+}
+
+const (
+	localDateLayout     = "2006-01-02"
+	localTimeLayout     = "15:04:05.999999999"
+	localDateTimeLayout = "2006-01-02T15:04:05.999999999"
+)
+
+// TOML Local Date that is returned if ParseOptions.LocalDateTime is set.
+// (e.g. `1979-05-27`)
+type LocalDate struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// TOML Local Time that is returned if ParseOptions.LocalDateTime is set.
+// (e.g. `07:32:00.999999`)
+type LocalTime struct {
+	Hour       int
+	Minute     int
+	Second     int
+	Nanosecond int
+}
+
+// TOML Local Date-Time that is returned if ParseOptions.LocalDateTime is set.
+// (e.g. `1979-05-27T07:32:00`)
+type LocalDateTime struct {
+	Date LocalDate
+	Time LocalTime
+}
+
+// Date part of t in the location of t.
+func LocalDateOf(t time.Time) LocalDate {
+	y, m, d := t.Date()
+	return LocalDate{Year: y, Month: m, Day: d}
+}
+
+// Time part of t in the location of t.
+func LocalTimeOf(t time.Time) LocalTime {
+	return LocalTime{Hour: t.Hour(), Minute: t.Minute(), Second: t.Second(), Nanosecond: t.Nanosecond()}
+}
+
+// Date and time of t in the location of t. The offset is dropped.
+func LocalDateTimeOf(t time.Time) LocalDateTime {
+	return LocalDateTime{Date: LocalDateOf(t), Time: LocalTimeOf(t)}
+}
+
+// Parse `yyyy-MM-dd`.
+func ParseLocalDate(s string) (LocalDate, error) {
+	t, err := time.Parse(localDateLayout, s)
+	if err != nil {
+		return LocalDate{}, err
+	}
+	return LocalDateOf(t), nil
+}
+
+// Parse `hh:mm:ss`, `hh:mm:ss.fffffffff` or `hh:mm`.
+func ParseLocalTime(s string) (LocalTime, error) {
+	t, err := time.Parse(localTimeLayout, s)
+	if err != nil {
+		var err2 error
+		if t, err2 = time.Parse("15:04", s); err2 != nil {
+			return LocalTime{}, err
+		}
+	}
+	return LocalTimeOf(t), nil
+}
+
+// Parse `yyyy-MM-ddThh:mm:ss.fffffffff`. The date and the time can also be delimited by the space.
+// The seconds can be omitted.
+func ParseLocalDateTime(s string) (LocalDateTime, error) {
+	if len(s) > 10 && s[10] == ' ' {
+		s = s[:10] + "T" + s[11:]
+	}
+	t, err := time.Parse(localDateTimeLayout, s)
+	if err != nil {
+		var err2 error
+		if t, err2 = time.Parse("2006-01-02T15:04", s); err2 != nil {
+			return LocalDateTime{}, err
+		}
+	}
+	return LocalDateTimeOf(t), nil
+}
+
+// Midnight of the date in loc.
+func (d LocalDate) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// Date and time of the day.
+func (d LocalDate) At(t LocalTime) LocalDateTime {
+	return LocalDateTime{Date: d, Time: t}
+}
+
+// TOML format. (`yyyy-MM-dd`)
+func (d LocalDate) String() string {
+	return d.In(time.UTC).Format(localDateLayout)
+}
+
+// TOML format. (`hh:mm:ss`, `hh:mm:ss.fffffffff`)
+// The trailing zeros of the fraction are removed.
+func (t LocalTime) String() string {
+	return time.Date(1970, 1, 1, t.Hour, t.Minute, t.Second, t.Nanosecond, time.UTC).Format(localTimeLayout)
+}
+
+// Time in loc.
+func (dt LocalDateTime) In(loc *time.Location) time.Time {
+	d, t := dt.Date, dt.Time
+	return time.Date(d.Year, d.Month, d.Day, t.Hour, t.Minute, t.Second, t.Nanosecond, loc)
+}
+
+// TOML format. (`yyyy-MM-ddThh:mm:ss`, `yyyy-MM-ddThh:mm:ss.fffffffff`)
+// The trailing zeros of the fraction are removed.
+func (dt LocalDateTime) String() string {
+	return dt.In(time.UTC).Format(localDateTimeLayout)
+}
@@ -0,0 +1,165 @@
+package jsonlp_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func TestLocalDateTime1(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		isTOML bool
+		local  bool
+		want   interface{}
+		toml   string
+	}{{
+		name:   "ldt1-1a",
+		s:      "a = 1979-05-27",
+		isTOML: true,
+		local:  true,
+		want:   jsonlp.LocalDate{Year: 1979, Month: time.May, Day: 27},
+		toml:   "a = 1979-05-27\n",
+	}, {
+		name:   "ldt1-1b",
+		s:      "a = 07:32:00.999",
+		isTOML: true,
+		local:  true,
+		want:   jsonlp.LocalTime{Hour: 7, Minute: 32, Second: 0, Nanosecond: 999000000},
+		toml:   "a = 07:32:00.999\n",
+	}, {
+		name:   "ldt1-1c",
+		s:      "a = 1979-05-27T07:32:00",
+		isTOML: true,
+		local:  true,
+		want: jsonlp.LocalDateTime{
+			Date: jsonlp.LocalDate{Year: 1979, Month: time.May, Day: 27},
+			Time: jsonlp.LocalTime{Hour: 7, Minute: 32},
+		},
+		toml: "a = 1979-05-27T07:32:00\n",
+	}, {
+		name:   "ldt1-1d",
+		s:      "a = 1979-05-27 07:32",
+		isTOML: true,
+		local:  true,
+		want: jsonlp.LocalDateTime{
+			Date: jsonlp.LocalDate{Year: 1979, Month: time.May, Day: 27},
+			Time: jsonlp.LocalTime{Hour: 7, Minute: 32},
+		},
+		toml: "a = 1979-05-27T07:32:00\n",
+	}, {
+		name:   "ldt1-1e",
+		s:      "a = 1979-05-27T07:32:00-07:00",
+		isTOML: true,
+		local:  true,
+		want:   time.Date(1979, 5, 27, 14, 32, 0, 0, time.UTC),
+		toml:   "a = 1979-05-27T14:32:00Z\n",
+	}, {
+		name:   "ldt1-1f",
+		s:      "a = 1979-05-27T07:32:00Z",
+		isTOML: true,
+		local:  true,
+		want:   time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		toml:   "a = 1979-05-27T07:32:00Z\n",
+	}, {
+		name:   "ldt1-2a",
+		s:      "a = 1979-05-27T07:32:00",
+		isTOML: true,
+		want:   time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		toml:   "a = 1979-05-27T07:32:00Z\n",
+	}, {
+		name:   "ldt1-2b",
+		s:      "a = 07:32:00",
+		isTOML: true,
+		want:   time.Date(1970, 1, 1, 7, 32, 0, 0, time.UTC),
+		toml:   "a = 1970-01-01T07:32:00Z\n",
+	}, {
+		name:  "ldt1-3a",
+		s:     "{a: 1979-05-27T07:32:00.5}",
+		local: true,
+		want: jsonlp.LocalDateTime{
+			Date: jsonlp.LocalDate{Year: 1979, Month: time.May, Day: 27},
+			Time: jsonlp.LocalTime{Hour: 7, Minute: 32, Nanosecond: 500000000},
+		},
+		toml: "a = 1979-05-27T07:32:00.5\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &jsonlp.ParseOptions{LocalDateTime: tt.local}
+			var got interface{}
+			var err error
+			if tt.isTOML {
+				got, err = jsonlp.ParseTOMLWithOptions(tt.s, opts)
+			} else {
+				got, err = jsonlp.ParseJSONWithOptions(tt.s, opts)
+			}
+			if err != nil {
+				t.Errorf("Parse: error = %v", err)
+				return
+			}
+			v := got.(map[string]interface{})["a"]
+			if x, ok := v.(time.Time); ok {
+				if !x.Equal(tt.want.(time.Time)) {
+					t.Errorf("Parse: v = %v, want %v", v, tt.want)
+				}
+			} else if !reflect.DeepEqual(v, tt.want) {
+				t.Errorf("Parse: v = %#v, want %#v", v, tt.want)
+			}
+
+			s, err := jsonlp.FormatTOML(got, nil)
+			if err != nil {
+				t.Errorf("FormatTOML: error = %v", err)
+				return
+			}
+			if s != tt.toml {
+				t.Errorf("FormatTOML: s = %q, want %q", s, tt.toml)
+			}
+		})
+	}
+}
+
+func TestLocalDateTime2(t *testing.T) {
+	tm := time.Date(2020, 12, 31, 18, 20, 30, 1000000, time.FixedZone("", 9*60*60))
+
+	dt := jsonlp.LocalDateTimeOf(tm)
+	if s := dt.String(); s != "2020-12-31T18:20:30.001" {
+		t.Errorf("LocalDateTime.String() = %v", s)
+	}
+	if s := dt.Date.String(); s != "2020-12-31" {
+		t.Errorf("LocalDate.String() = %v", s)
+	}
+	if s := dt.Time.String(); s != "18:20:30.001" {
+		t.Errorf("LocalTime.String() = %v", s)
+	}
+	if x := dt.In(tm.Location()); !x.Equal(tm) {
+		t.Errorf("LocalDateTime.In() = %v, want %v", x, tm)
+	}
+	if x := dt.Date.In(time.UTC); !x.Equal(time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("LocalDate.In() = %v", x)
+	}
+	if x := jsonlp.LocalDateOf(tm).At(jsonlp.LocalTimeOf(tm)); x != dt {
+		t.Errorf("LocalDate.At() = %v, want %v", x, dt)
+	}
+
+	if x, err := jsonlp.ParseLocalDateTime("2020-12-31 18:20:30.001"); err != nil || x != dt {
+		t.Errorf("ParseLocalDateTime() = %v, %v", x, err)
+	}
+	if x, err := jsonlp.ParseLocalDate("2020-12-31"); err != nil || x != dt.Date {
+		t.Errorf("ParseLocalDate() = %v, %v", x, err)
+	}
+	if x, err := jsonlp.ParseLocalTime("18:20:30.001"); err != nil || x != dt.Time {
+		t.Errorf("ParseLocalTime() = %v, %v", x, err)
+	}
+	if _, err := jsonlp.ParseLocalDate("2021-02-30"); err == nil {
+		t.Errorf("ParseLocalDate() should fail")
+	}
+
+	s, err := jsonlp.FormatJSON(map[string]interface{}{"a": dt}, nil)
+	if err != nil || s != `{"a":"2020-12-31T18:20:30.001"}` {
+		t.Errorf("FormatJSON() = %v, %v", s, err)
+	}
+}
@@ -0,0 +1,229 @@
+package jsonlp
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	. "github.com/shellyln/takenoco/base"
+	strparser "github.com/shellyln/takenoco/string"
+)
+
+const decoderMinReadSize = 4096
+
+// Decoder reads loose JSON values one by one from the stream.
+// Values can be delimited by line breaks (JSON Lines, NDJSON) or whitespaces (concatenated JSON).
+type Decoder struct {
+	r       io.Reader
+	opts    parseOptions
+	g       *jsonGrammar
+	lenient bool
+
+	buf        string // Unread part of the stream
+	eof        bool
+	bomChecked bool
+	err        error // Sticky error
+	line       int   // Line number of the start of buf (1-based)
+	col        int   // Column of the start of buf (0-based)
+	offset     int   // Offset of the start of buf
+
+	recLine int // Line number of the last record
+}
+
+// Create a new Decoder that reads from r.
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+// ErrorRecovery and MaxInputSize are ignored.
+func NewDecoder(r io.Reader, opts *ParseOptions) *Decoder {
+	o := newParseOptions(opts, false)
+	o.recovery = false
+	return &Decoder{
+		r:    r,
+		opts: o,
+		g:    jsonGrammarOf(o),
+		line: 1,
+	}
+}
+
+// If lenient is true, Next() skips the malformed record to the next line after returning the error.
+// Otherwise, Next() returns the same error after the first error.
+func (d *Decoder) SetLenient(lenient bool) {
+	d.lenient = lenient
+}
+
+// Line number (1-based) of the start of the record that is returned by the last Next().
+func (d *Decoder) Line() int {
+	return d.recLine
+}
+
+// Read the next value.
+// It returns io.EOF if there are no more values.
+// If the record is malformed, it returns *SyntaxError.
+func (d *Decoder) Next() (interface{}, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	for {
+		// Skip whitespaces and comments
+		ctx := d.newContext()
+		out, err := d.g.sp(ctx)
+		if err != nil || out.MatchStatus != MatchStatus_Matched {
+			if !d.eof {
+				if err := d.fill(); err != nil {
+					return nil, d.fail(err)
+				}
+				continue
+			}
+			return nil, d.fail(d.newSyntaxError(out.SourcePosition, err))
+		}
+		if out.Position == len(d.buf) && !d.eof {
+			if err := d.fill(); err != nil {
+				return nil, d.fail(err)
+			}
+			continue
+		}
+		d.consume(out.Position)
+		if len(d.buf) == 0 && d.eof {
+			return nil, io.EOF
+		}
+
+		// Read the value
+		ctx = d.newContext()
+		out, err = d.g.value(ctx)
+		if err != nil || out.MatchStatus != MatchStatus_Matched {
+			if !d.eof && d.maybeIncomplete(out.Position, err) {
+				if err := d.fill(); err != nil {
+					return nil, d.fail(err)
+				}
+				continue
+			}
+			d.recLine = d.line
+			se := d.newSyntaxError(out.SourcePosition, err)
+			if d.lenient {
+				d.skipLine(out.Position)
+				return nil, se
+			}
+			return nil, d.fail(se)
+		}
+		if !d.eof && !isCompleteValue(d.buf[:out.Position], len(d.buf)) {
+			if err := d.fill(); err != nil {
+				return nil, d.fail(err)
+			}
+			continue
+		}
+
+		d.recLine = d.line
+		d.consume(out.Position)
+		if len(out.AstStack) == 0 {
+			return nil, nil
+		}
+		return out.AstStack[0].Value, nil
+	}
+}
+
+func (d *Decoder) newContext() ParserContext {
+	opts := d.opts
+	opts.state = &parseState{} // The limits are applied to each record
+	ctx := *strparser.NewStringParserContext(d.buf)
+	ctx.Tag = opts
+	return ctx
+}
+
+func (d *Decoder) fail(err error) error {
+	d.err = err
+	return err
+}
+
+// Read more data and append it to the buffer.
+func (d *Decoder) fill() error {
+	size := len(d.buf)
+	if size < decoderMinReadSize {
+		size = decoderMinReadSize
+	}
+	p := make([]byte, size)
+	n, err := io.ReadAtLeast(d.r, p, 1)
+	d.buf += string(p[:n])
+	if !d.bomChecked && (len(d.buf) >= 3 || err != nil) {
+		d.bomChecked = true
+		if strings.HasPrefix(d.buf, "\xEF\xBB\xBF") {
+			// Remove the UTF-8 BOM
+			d.buf = d.buf[3:]
+			d.offset += 3
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		d.eof = true
+		return nil
+	}
+	return err
+}
+
+// Remove the first n bytes of the buffer and update the position.
+func (d *Decoder) consume(n int) {
+	s := d.buf[:n]
+	if lines := strings.Count(s, "\n"); lines != 0 {
+		d.line += lines
+		d.col = n - strings.LastIndex(s, "\n") - 1
+	} else {
+		d.col += n
+	}
+	d.offset += n
+	d.buf = d.buf[n:]
+}
+
+// Skip to the next line after the position.
+func (d *Decoder) skipLine(pos int) {
+	if pos > len(d.buf) {
+		pos = len(d.buf)
+	}
+	if i := strings.IndexByte(d.buf[pos:], '\n'); i >= 0 {
+		d.consume(pos + i + 1)
+	} else {
+		d.consume(len(d.buf))
+	}
+}
+
+// Returns true if the error may be caused by the end of the buffer.
+func (d *Decoder) maybeIncomplete(pos int, err error) bool {
+	var pe *parseError
+	if errors.As(err, &pe) {
+		switch pe.code {
+		case ErrorCode_UnterminatedString, ErrorCode_UnterminatedComment:
+			return true
+		}
+	}
+	if pos > len(d.buf) {
+		return true
+	}
+	return strings.IndexByte(d.buf[pos:], '\n') < 0
+}
+
+// Returns true if the value is terminated by the closing bracket or quote,
+// or it is followed by the other characters in the buffer.
+func isCompleteValue(s string, bufLen int) bool {
+	if len(s) < bufLen {
+		return true
+	}
+	s = strings.TrimRight(s, " \t\r\n")
+	if s == "" {
+		return false
+	}
+	switch s[len(s)-1] {
+	case '}', ']', '"', '\'', '`':
+		return true
+	}
+	return false
+}
+
+// Make the SyntaxError with the position in the stream.
+func (d *Decoder) newSyntaxError(pos SourcePosition, err error) *SyntaxError {
+	se := newSyntaxError(d.buf, pos, err)
+	if se.Line == 1 {
+		se.Col += d.col
+	}
+	se.Line += d.line - 1
+	se.Offset += d.offset
+	return se
+}
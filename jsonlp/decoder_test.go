@@ -0,0 +1,130 @@
+package jsonlp_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+type decoderRecord struct {
+	line  int
+	value interface{}
+	err   bool
+}
+
+func runDecoder(t *testing.T, name string, r io.Reader, lenient bool, want []decoderRecord) {
+	dec := jsonlp.NewDecoder(r, nil)
+	dec.SetLenient(lenient)
+
+	got := make([]decoderRecord, 0, len(want))
+	for i := 0; i < len(want)+1; i++ {
+		v, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		var se *jsonlp.SyntaxError
+		if err != nil && !errors.As(err, &se) {
+			t.Errorf("%v: Next() error = %v, want *SyntaxError", name, err)
+			return
+		}
+		got = append(got, decoderRecord{line: dec.Line(), value: v, err: err != nil})
+		if err != nil && !lenient {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%v: got = %v, want %v", name, got, want)
+	}
+}
+
+func TestDecoder1(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		lenient bool
+		want    []decoderRecord
+	}{{
+		name: "d1-1a",
+		s:    "{\"a\": 1}\n{\"a\": 2}\n[3, 4]\n\"5\"\n6\ntrue\nnull\n",
+		want: []decoderRecord{
+			{line: 1, value: map[string]interface{}{"a": float64(1)}},
+			{line: 2, value: map[string]interface{}{"a": float64(2)}},
+			{line: 3, value: []interface{}{float64(3), float64(4)}},
+			{line: 4, value: "5"},
+			{line: 5, value: float64(6)},
+			{line: 6, value: true},
+			{line: 7, value: nil},
+		},
+	}, {
+		name: "d1-1b",
+		s:    "{a: 1,} // comment\n\n/* comment */ {b: [2,],}{c: 3} 4 5",
+		want: []decoderRecord{
+			{line: 1, value: map[string]interface{}{"a": float64(1)}},
+			{line: 3, value: map[string]interface{}{"b": []interface{}{float64(2)}}},
+			{line: 3, value: map[string]interface{}{"c": float64(3)}},
+			{line: 3, value: float64(4)},
+			{line: 3, value: float64(5)},
+		},
+	}, {
+		name: "d1-1c",
+		s:    "{\n  a: 1,\n  b: 2\n}\n{\n  c: 3\n}",
+		want: []decoderRecord{
+			{line: 1, value: map[string]interface{}{"a": float64(1), "b": float64(2)}},
+			{line: 5, value: map[string]interface{}{"c": float64(3)}},
+		},
+	}, {
+		name: "d1-1d",
+		s:    "\xEF\xBB\xBF1\n  \n",
+		want: []decoderRecord{
+			{line: 1, value: float64(1)},
+		},
+	}, {
+		name: "d1-2a",
+		s:    "{a: 1}\n{a: }\n{a: 3}\n",
+		want: []decoderRecord{
+			{line: 1, value: map[string]interface{}{"a": float64(1)}},
+			{line: 2, err: true},
+		},
+	}, {
+		name:    "d1-2b",
+		s:       "{a: 1}\n{a: }\n{a: 3}\n[1, 2\n",
+		lenient: true,
+		want: []decoderRecord{
+			{line: 1, value: map[string]interface{}{"a": float64(1)}},
+			{line: 2, err: true},
+			{line: 3, value: map[string]interface{}{"a": float64(3)}},
+			{line: 4, err: true},
+		},
+	}}
+	for _, tt := range tests {
+		runDecoder(t, tt.name, strings.NewReader(tt.s), tt.lenient, tt.want)
+		runDecoder(t, tt.name+"(OneByteReader)", iotest.OneByteReader(strings.NewReader(tt.s)), tt.lenient, tt.want)
+	}
+}
+
+func TestDecoder2(t *testing.T) {
+	dec := jsonlp.NewDecoder(strings.NewReader("1\n2\n{a: ,}\n"), nil)
+	for i := 0; i < 2; i++ {
+		if _, err := dec.Next(); err != nil {
+			t.Errorf("Next() error = %v", err)
+			return
+		}
+	}
+	_, err := dec.Next()
+	var se *jsonlp.SyntaxError
+	if !errors.As(err, &se) {
+		t.Errorf("Next() error = %v, want *SyntaxError", err)
+		return
+	}
+	if se.Line != 3 || se.Col != 5 || se.Offset != 8 {
+		t.Errorf("Line, Col, Offset = %v, %v, %v, want 3, 5, 8", se.Line, se.Col, se.Offset)
+	}
+	if _, err2 := dec.Next(); err2 != err {
+		t.Errorf("Next() error = %v, want the same error", err2)
+	}
+}
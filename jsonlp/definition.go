@@ -0,0 +1,149 @@
+package jsonlp
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
+	. "github.com/shellyln/takenoco/base"
+)
+
+type definitionKind int
+
+const (
+	definition_Value definitionKind = iota
+	definition_InlineTable
+	definition_DottedTable
+	definition_ImplicitTable
+	definition_Table
+	definition_ArrayOfTables
+)
+
+type definition struct {
+	kind definitionKind
+	pos  SourcePosition
+}
+
+// Definitions of the keys in a table. It is used for strictness checks.
+// Each node of the table tree has the definitions of its own keys.
+type definitions map[string]definition
+
+func isHeader(valueClass string) bool {
+	return valueClass == class.TomlTable || valueClass == class.TomlArrayOfTable
+}
+
+func newDefinitionError(code ErrorCode, msg string, name []string, pos SourcePosition, first *definition) error {
+	err := &parseError{
+		code: code,
+		msg:  msg + ": " + strings.Join(name, "."),
+		pos:  &pos,
+	}
+	if first != nil {
+		err.first = &first.pos
+	}
+	return err
+}
+
+// Check the intermediate table of the dotted key and register it if it is created.
+func (defs definitions) traverse(name []string, key string, keyPos SourcePosition, valueClass string) error {
+	if d, ok := defs[key]; ok {
+		switch d.kind {
+		case definition_Value:
+			return newDefinitionError(ErrorCode_DuplicateKey, "Duplicate key", name, keyPos, &d)
+		case definition_InlineTable:
+			return newDefinitionError(ErrorCode_InlineTableExtension, "Inline table cannot be extended", name, keyPos, &d)
+		}
+		return nil
+	}
+	if isHeader(valueClass) {
+		defs[key] = definition{kind: definition_ImplicitTable, pos: keyPos}
+	} else {
+		defs[key] = definition{kind: definition_DottedTable, pos: keyPos}
+	}
+	return nil
+}
+
+// Check the last key of the (dotted) key and register it.
+// table is the table that the key is assigned to.
+func (defs definitions) define(name []string, key string, keyPos SourcePosition, valueClass string, table object) error {
+	d, defined := defs[key]
+	_, exists := table.get(key)
+
+	var first *definition
+	if defined {
+		first = &d
+	}
+
+	switch valueClass {
+	case class.TomlArrayOfTable:
+		if defined && d.kind == definition_ArrayOfTables {
+			return nil
+		}
+		if defined || exists {
+			return newDefinitionError(ErrorCode_ArrayOfTablesConflict, "Array of tables conflicts with the existing key", name, keyPos, first)
+		}
+		defs[key] = definition{kind: definition_ArrayOfTables, pos: keyPos}
+
+	case class.TomlTable:
+		if defined {
+			switch d.kind {
+			case definition_ImplicitTable:
+				// `[x.y.z] ... [x]` is valid
+			case definition_Value:
+				return newDefinitionError(ErrorCode_DuplicateKey, "Duplicate key", name, keyPos, first)
+			case definition_ArrayOfTables:
+				return newDefinitionError(ErrorCode_ArrayOfTablesConflict, "Table conflicts with the array of tables", name, keyPos, first)
+			default:
+				return newDefinitionError(ErrorCode_TableRedefinition, "Table is already defined", name, keyPos, first)
+			}
+		} else if exists {
+			return newDefinitionError(ErrorCode_TableRedefinition, "Table is already defined", name, keyPos, nil)
+		}
+		defs[key] = definition{kind: definition_Table, pos: keyPos}
+
+	default:
+		if defined || exists {
+			return newDefinitionError(ErrorCode_DuplicateKey, "Duplicate key", name, keyPos, first)
+		}
+		if valueClass == class.Object {
+			defs[key] = definition{kind: definition_InlineTable, pos: keyPos}
+		} else {
+			defs[key] = definition{kind: definition_Value, pos: keyPos}
+		}
+	}
+	return nil
+}
+
+// Check the keys of the table that is merged into the implicitly created table.
+// defs is the definitions of the keys of the table (to).
+// fromDefs is the definitions of the keys of from, and the keys are checked in the order of the definitions.
+// The keys that are not in fromDefs are checked in the lexical order after them.
+func (defs definitions) checkMerge(name []string, keyPos SourcePosition, from, to object, fromDefs definitions) error {
+	keys := append([]string(nil), from.keyList()...)
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := math.MaxInt, math.MaxInt
+		if d, ok := fromDefs[keys[i]]; ok {
+			pi = d.pos.Position
+		}
+		if d, ok := fromDefs[keys[j]]; ok {
+			pj = d.pos.Position
+		}
+		if pi != pj {
+			return pi < pj
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, xKey := range keys {
+		if _, ok := to.get(xKey); ok {
+			subName := append(name[:len(name):len(name)], xKey)
+			var first *definition
+			if d, ok := defs[xKey]; ok {
+				first = &d
+			}
+			return newDefinitionError(ErrorCode_DuplicateKey, "Duplicate key", subName, keyPos, first)
+		}
+	}
+	return nil
+}
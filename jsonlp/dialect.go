@@ -0,0 +1,297 @@
+package jsonlp
+
+import (
+	"strings"
+	"unicode"
+
+	. "github.com/shellyln/takenoco/base"
+	"github.com/shellyln/takenoco/extra"
+	strparser "github.com/shellyln/takenoco/string"
+)
+
+// Dialect of the JSON parsers.
+type DialectType int
+
+const (
+	Dialect_Loose      DialectType = iota // All extensions of this parser
+	Dialect_StrictJSON                    // RFC 8259
+	Dialect_JSON5                         // JSON5 (https://spec.json5.org/)
+	Dialect_JSONC                         // JSON with `//` and `/* */` comments
+)
+
+// Convert DialectType to a string.
+func (d DialectType) String() string {
+	switch d {
+	case Dialect_Loose:
+		return "Loose"
+	case Dialect_StrictJSON:
+		return "StrictJSON"
+	case Dialect_JSON5:
+		return "JSON5"
+	case Dialect_JSONC:
+		return "JSONC"
+	default:
+		return "Unknown"
+	}
+}
+
+// Set of the extensions to RFC 8259 that the JSON grammar accepts.
+// Combine the flags by `|`, and remove them by `&^`. (e.g. `Features_All &^ Feature_Undefined`)
+// The sets that are made from the Features_* constants are not 0 even if they have no extensions,
+// so 0 means that the set is not specified.
+type Features uint32
+
+const (
+	Feature_LineComment        Features = 1 << iota // `// comment`
+	Feature_BlockComment                            // `/* comment */`
+	Feature_HashComment                             // `# comment`
+	Feature_ExtendedWhitespace                      // Whitespaces other than space, tab, CR and LF (e.g. VT, FF, NBSP)
+	Feature_TrailingComma                           // `[1,]`, `{a:1,}`
+	Feature_SingleQuote                             // `'string'`
+	Feature_BackQuote                               // `` `string` ``
+	Feature_MultiLineString                         // `"""string"""`, `'''string'''`
+	Feature_JSON5Escape                             // `\'`, `\v`, `\0`, `\xHH`, line continuation and the other characters
+	Feature_ExtendedEscape                          // `\u{HHHHHH}`, `\OOO`, `\N` and the other uppercase letters
+	Feature_ControlCharacter                        // Unescaped control characters in the strings
+	Feature_UnquotedKey                             // `{key: 1}`, `{\u006bey: 1}` (ECMAScript IdentifierName)
+	Feature_BareKey                                 // `{bare-key: 1}`, `{1key: 1}` (TOML style bare keys)
+	Feature_DottedKey                               // `{a.b.c: 1}`
+	Feature_EqualSeparator                          // `{a = 1}`
+	Feature_ArrowSeparator                          // `{a => 1}`
+	Feature_Undefined                               // `undefined`, `None`
+	Feature_CaseInsensitive                         // `TRUE`, `False`, `NULL`
+	Feature_JSON5Number                             // `0xff`, `.5`, `5.`, `+1`, `Infinity`, `NaN`, `-0xff` (without Feature_ExtendedNumber)
+	Feature_ExtendedNumber                          // `0b11`, `0o77`, `0x1p-2`, `1_000`, `01`, `inf`, `nan`
+	Feature_NumberSuffix                            // `1s64`, `1u64`, `1n`
+	Feature_Complex                                 // `1+2i`
+	Feature_DateTime                                // `2020-12-31`, `18:20:30`, `2020-12-31T18:20:30Z`
+
+	Features_All Features = 1<<iota - 1 | features_Explicit // Extensions of Dialect_Loose
+)
+
+// Bit that is set in the all sets of the extensions to distinguish Features_StrictJSON from 0.
+const features_Explicit Features = 1 << 31
+
+// Extensions of the dialects
+const (
+	Features_StrictJSON Features = features_Explicit
+	Features_JSONC      Features = features_Explicit | Feature_LineComment | Feature_BlockComment
+	Features_JSON5      Features = features_Explicit | Feature_LineComment | Feature_BlockComment |
+		Feature_ExtendedWhitespace | Feature_TrailingComma | Feature_SingleQuote |
+		Feature_JSON5Escape | Feature_ControlCharacter | Feature_UnquotedKey | Feature_JSON5Number
+)
+
+var featureNames = map[Features]string{
+	Feature_LineComment:        "line comment `//`",
+	Feature_BlockComment:       "block comment `/* */`",
+	Feature_HashComment:        "hash comment `#`",
+	Feature_ExtendedWhitespace: "whitespace character",
+	Feature_TrailingComma:      "trailing comma",
+	Feature_SingleQuote:        "single quoted string",
+	Feature_BackQuote:          "back quoted string",
+	Feature_MultiLineString:    "multi-line string",
+	Feature_JSON5Escape:        "escape sequence",
+	Feature_ExtendedEscape:     "extended escape sequence",
+	Feature_ControlCharacter:   "unescaped control character",
+	Feature_UnquotedKey:        "unquoted key",
+	Feature_BareKey:            "bare key",
+	Feature_DottedKey:          "dotted key",
+	Feature_EqualSeparator:     "key-value separator `=`",
+	Feature_ArrowSeparator:     "key-value separator `=>`",
+	Feature_Undefined:          "`undefined` and `None`",
+	Feature_CaseInsensitive:    "case-insensitive literal",
+	Feature_JSON5Number:        "JSON5 number",
+	Feature_ExtendedNumber:     "extended number",
+	Feature_NumberSuffix:       "number suffix",
+	Feature_Complex:            "complex number",
+	Feature_DateTime:           "date and time literal",
+}
+
+// Extensions that the dialect accepts.
+func (d DialectType) Features() Features {
+	switch d {
+	case Dialect_StrictJSON:
+		return Features_StrictJSON
+	case Dialect_JSON5:
+		return Features_JSON5
+	case Dialect_JSONC:
+		return Features_JSONC
+	default:
+		return Features_All
+	}
+}
+
+// Valid extensions of f with features_Explicit.
+func (f Features) normalize() Features {
+	return f&Features_All | features_Explicit
+}
+
+func (f Features) has(x Features) bool {
+	return f&x == x
+}
+
+// Name of the dialect that accepts the extensions f.
+func (f Features) dialect() DialectType {
+	for _, d := range []DialectType{Dialect_StrictJSON, Dialect_JSON5, Dialect_JSONC, Dialect_Loose} {
+		if d.Features() == f {
+			return d
+		}
+	}
+	return -1
+}
+
+func extensionErrorMessage(f, x Features) string {
+	d := f.dialect()
+	if d < 0 {
+		// Custom set of the extensions
+		return "Extension " + featureNames[x] + " is not allowed"
+	}
+	return "Extension " + featureNames[x] + " is not allowed in dialect " + d.String()
+}
+
+// Zero-width assertion (always error) that the extension x is not allowed.
+func extensionError(f, x Features) ParserFn {
+	return syntaxError(ErrorCode_ExtensionNotAllowed, extensionErrorMessage(f, x))
+}
+
+// If f has the extension x, it returns fn.
+// Otherwise, it returns the parser that fails with ErrorCode_ExtensionNotAllowed if trigger matches.
+// If trigger is nil, fn is used as the trigger.
+func extension(f, x Features, fn, trigger ParserFn) ParserFn {
+	if f.has(x) {
+		return fn
+	}
+	if trigger == nil {
+		trigger = fn
+	}
+	return FlatGroup(
+		LookAhead(trigger),
+		extensionError(f, x),
+	)
+}
+
+// Check the extensions that the source literal of fn requires.
+// If f does not have some of them, it fails with ErrorCode_ExtensionNotAllowed at the start of the literal.
+func checkExtensions(f Features, fn ParserFn, required func(lit string) Features) ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		out, err := fn(ctx)
+		if err != nil || out.MatchStatus != MatchStatus_Matched {
+			return out, err
+		}
+		missing := required(ctx.Str[ctx.Position:out.Position]) &^ f
+		if missing == 0 {
+			return out, nil
+		}
+		x := missing & -missing
+		out.MatchStatus = MatchStatus_Error
+		return out, &parseError{
+			code: ErrorCode_ExtensionNotAllowed,
+			msg:  extensionErrorMessage(f, x),
+			pos:  &SourcePosition{Position: ctx.Position},
+		}
+	}
+}
+
+// Whitespaces other than space, tab, CR and LF that JSON5 accepts.
+// (NEL is also accepted as Whitespace does.)
+func isExtendedWhitespaceRune(c rune) bool {
+	switch c {
+	case '\v', '\f', 0x85, 0xa0, 0x2028, 0x2029, 0xfeff:
+		return true
+	}
+	return unicode.Is(unicode.Zs, c) && c != ' '
+}
+
+func isControlRune(c rune) bool {
+	return c < 0x20
+}
+
+// ID_Start + '$' + '_'
+func isIdentifierStartRune(c rune) bool {
+	if c == '$' || c == '_' {
+		return true
+	}
+	return isIdentifierRune(c) && c != '-' &&
+		!unicode.Is(unicode.Mn, c) &&
+		!unicode.Is(unicode.Mc, c) &&
+		!unicode.Is(unicode.Nd, c) &&
+		!unicode.Is(unicode.Pc, c) &&
+		!unicode.Is(unicode.Other_ID_Continue, c) &&
+		c != 0x0200c && c != 0x0200d
+}
+
+// Extensions that the identifier requires.
+func identifierFeaturesOf(lit string) Features {
+	for i, c := range unescapeIdentifier(lit) {
+		if c == '-' || (i == 0 && !isIdentifierStartRune(c)) {
+			return Feature_BareKey
+		}
+	}
+	return 0
+}
+
+// Extensions that the numeric literal requires.
+func numberFeaturesOf(lit string) Features {
+	var ret Features
+	if strings.HasSuffix(lit, "i") {
+		return Feature_Complex
+	}
+	if strings.Contains(lit, "_") {
+		ret |= Feature_ExtendedNumber
+	}
+
+	s := lit
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		if s[0] == '+' {
+			ret |= Feature_JSON5Number
+		}
+		s = s[1:]
+	}
+
+	switch lower := strings.ToLower(s); {
+	case s == "Infinity" || s == "NaN":
+		return ret | Feature_JSON5Number
+	case lower == "infinity" || lower == "inf" || lower == "nan":
+		return ret | Feature_ExtendedNumber
+	case strings.HasPrefix(lower, "0b") || strings.HasPrefix(lower, "0o"):
+		ret |= Feature_ExtendedNumber
+	case strings.HasPrefix(lower, "0x"):
+		ret |= Feature_JSON5Number
+		if strings.ContainsAny(lower, ".p") {
+			ret |= Feature_ExtendedNumber
+		}
+	default:
+		if 1 < len(s) && s[0] == '0' && '0' <= s[1] && s[1] <= '9' {
+			ret |= Feature_ExtendedNumber
+		}
+		if dot := strings.IndexByte(s, '.'); 0 <= dot {
+			if dot == 0 || len(s) == dot+1 || s[dot+1] < '0' || '9' < s[dot+1] {
+				ret |= Feature_JSON5Number
+			}
+		}
+	}
+
+	if strings.HasSuffix(lit, "n") {
+		ret |= Feature_NumberSuffix
+	} else if 3 < len(lit) {
+		switch strings.ToLower(lit[len(lit)-3:]) {
+		case "s64", "u64":
+			ret |= Feature_NumberSuffix
+		}
+	}
+	return ret
+}
+
+// Keyword that is case-insensitive if f has Feature_CaseInsensitive. (e.g. `true`, `TRUE`)
+func keyword(f Features, s string) ParserFn {
+	if f.has(Feature_CaseInsensitive) {
+		return strparser.SeqI(s)
+	}
+	return First(
+		strparser.Seq(s),
+		extension(f, Feature_CaseInsensitive, nil, FlatGroup(
+			strparser.SeqI(s),
+			extra.UnicodeWordBoundary(),
+		)),
+	)
+}
@@ -0,0 +1,450 @@
+package jsonlp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func TestDialectJSONTestSuite(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "JSONTestSuite", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no test files")
+	}
+	// RFC 8259 allows the limit of the nesting depth. (e.g. n_structure_100000_opening_arrays.json)
+	opts := &jsonlp.ParseOptions{Dialect: jsonlp.Dialect_StrictJSON, MaxDepth: 512}
+
+	for _, file := range files {
+		file := file
+		name := filepath.Base(file)
+		if !strings.HasPrefix(name, "y_") && !strings.HasPrefix(name, "n_") {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := jsonlp.ParseJSONWithOptions(string(src), opts)
+			if strings.HasPrefix(name, "n_") {
+				if err == nil {
+					t.Errorf("ParseJSONWithOptions: v = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("ParseJSONWithOptions: error = %v", err)
+				return
+			}
+			var want interface{}
+			if err := json.Unmarshal(src, &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseJSONWithOptions: v = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// testdata/json5 is the json5-tests corpus (https://github.com/json5/json5-tests)
+// that is copied from github.com/titanous/json5 v1.0.0.
+// *.json and *.json5 must be accepted. *.js (ES5 only) and *.txt must be rejected.
+func TestDialectJSON5(t *testing.T) {
+	opts := &jsonlp.ParseOptions{Dialect: jsonlp.Dialect_JSON5}
+	n := 0
+	err := filepath.WalkDir(filepath.Join("testdata", "json5"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		var valid bool
+		switch filepath.Ext(path) {
+		case ".json", ".json5":
+			valid = true
+		case ".js", ".txt":
+			valid = false
+		default:
+			return nil
+		}
+		n++
+		name, _ := filepath.Rel(filepath.Join("testdata", "json5"), path)
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := jsonlp.ParseJSONWithOptions(string(src), opts)
+			if valid && err != nil {
+				t.Errorf("ParseJSONWithOptions: error = %v", err)
+			}
+			if !valid && err == nil {
+				t.Errorf("ParseJSONWithOptions: v = %v, want error", got)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n == 0 {
+		t.Fatal("no test files")
+	}
+}
+
+func TestDialect1(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		dialect jsonlp.DialectType
+		want    interface{}
+		wantErr string
+		wantCol int
+	}{{
+		name:    "d1-1a",
+		s:       `{"a": [1, 2.5, true, null, "A"]}`,
+		dialect: jsonlp.Dialect_StrictJSON,
+		want:    map[string]interface{}{"a": []interface{}{float64(1), 2.5, true, nil, "A"}},
+	}, {
+		name:    "d1-1b",
+		s:       `[1, 2,]`,
+		dialect: jsonlp.Dialect_StrictJSON,
+		wantErr: "Extension trailing comma is not allowed in dialect StrictJSON",
+		wantCol: 7,
+	}, {
+		name:    "d1-1c",
+		s:       `{"a" => 1}`,
+		dialect: jsonlp.Dialect_StrictJSON,
+		wantErr: "Extension key-value separator `=>` is not allowed in dialect StrictJSON",
+		wantCol: 6,
+	}, {
+		name:    "d1-1d",
+		s:       "[\"a\tb\"]",
+		dialect: jsonlp.Dialect_StrictJSON,
+		wantErr: "Extension unescaped control character is not allowed in dialect StrictJSON",
+		wantCol: 4,
+	}, {
+		name:    "d1-2a",
+		s:       "// comment\n{\"a\": /* comment */ 1}",
+		dialect: jsonlp.Dialect_JSONC,
+		want:    map[string]interface{}{"a": float64(1)},
+	}, {
+		name:    "d1-2b",
+		s:       "# comment\n{\"a\": 1}",
+		dialect: jsonlp.Dialect_JSONC,
+		wantErr: "Extension hash comment `#` is not allowed in dialect JSONC",
+		wantCol: 1,
+	}, {
+		name:    "d1-3a",
+		s:       "{a: 'x', b: 0x10, c: .5, d: +Infinity,}",
+		dialect: jsonlp.Dialect_JSON5,
+		want: map[string]interface{}{
+			"a": "x", "b": float64(16), "c": 0.5, "d": math.Inf(1),
+		},
+	}, {
+		name:    "d1-3b",
+		s:       "{a: 2020-12-31}",
+		dialect: jsonlp.Dialect_JSON5,
+		wantErr: "Extension date and time literal is not allowed in dialect JSON5",
+		wantCol: 5,
+	}, {
+		name:    "d1-3c",
+		s:       `{a: """x"""}`,
+		dialect: jsonlp.Dialect_JSON5,
+		wantErr: "Extension multi-line string is not allowed in dialect JSON5",
+		wantCol: 5,
+	}, {
+		name:    "d1-3d",
+		s:       `{\u0061: 5., b\u0063: -0x1A, d: +0x1A}`,
+		dialect: jsonlp.Dialect_JSON5,
+		want:    map[string]interface{}{"a": float64(5), "bc": float64(-26), "d": float64(26)},
+	}, {
+		name:    "d1-3e",
+		s:       `[5.]`,
+		dialect: jsonlp.Dialect_StrictJSON,
+		wantErr: "Extension JSON5 number is not allowed in dialect StrictJSON",
+		wantCol: 2,
+	}, {
+		name:    "d1-3f",
+		s:       `[-0x1A]`,
+		dialect: jsonlp.Dialect_StrictJSON,
+		wantErr: "Extension JSON5 number is not allowed in dialect StrictJSON",
+		wantCol: 2,
+	}, {
+		name:    "d1-3g",
+		s:       `{\u0061: 1}`,
+		dialect: jsonlp.Dialect_StrictJSON,
+		wantErr: "Extension unquoted key is not allowed in dialect StrictJSON",
+		wantCol: 2,
+	}, {
+		name:    "d1-3h",
+		s:       `{\u0031: 1}`,
+		dialect: jsonlp.Dialect_JSON5,
+		wantErr: "Extension bare key is not allowed in dialect JSON5",
+		wantCol: 2,
+	}, {
+		name:    "d1-4a",
+		s:       "# comment\n{a => 2020-12-31, b = `x`,}",
+		dialect: jsonlp.Dialect_Loose,
+		want: map[string]interface{}{
+			"a": time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC), "b": "x",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonlp.ParseJSONWithOptions(tt.s, &jsonlp.ParseOptions{Dialect: tt.dialect})
+			if tt.wantErr != "" {
+				var se *jsonlp.SyntaxError
+				if !errors.As(err, &se) {
+					t.Errorf("ParseJSONWithOptions: error = %v, want SyntaxError", err)
+					return
+				}
+				if se.Code != jsonlp.ErrorCode_ExtensionNotAllowed {
+					t.Errorf("ParseJSONWithOptions: code = %v, want %v", se.Code, jsonlp.ErrorCode_ExtensionNotAllowed)
+				}
+				if se.Message != tt.wantErr || se.Col != tt.wantCol {
+					t.Errorf("ParseJSONWithOptions: msg, col = %q, %v, want %q, %v", se.Message, se.Col, tt.wantErr, tt.wantCol)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("ParseJSONWithOptions: error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseJSONWithOptions: v = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeatures1(t *testing.T) {
+	all := jsonlp.Features_All
+	tests := []struct {
+		name     string
+		s        string
+		features jsonlp.Features
+		want     interface{}
+		wantErr  string
+	}{{
+		name:     "f1-1a",
+		s:        "// comment\n[null, None]",
+		features: all &^ jsonlp.Feature_Undefined,
+		wantErr:  "Extension `undefined` and `None` is not allowed",
+	}, {
+		name:     "f1-1b",
+		s:        "# comment\n[null]",
+		features: all &^ jsonlp.Feature_Undefined,
+		want:     []interface{}{nil},
+	}, {
+		name:     "f1-2a",
+		s:        `{"a" = 1}`,
+		features: all &^ jsonlp.Feature_ArrowSeparator,
+		want:     map[string]interface{}{"a": float64(1)},
+	}, {
+		name:     "f1-2b",
+		s:        `{"a" => 1}`,
+		features: all &^ jsonlp.Feature_ArrowSeparator,
+		wantErr:  "Extension key-value separator `=>` is not allowed",
+	}, {
+		name:     "f1-3a",
+		s:        `["2020-12-31"]`,
+		features: all &^ jsonlp.Feature_DateTime,
+		want:     []interface{}{"2020-12-31"},
+	}, {
+		name:     "f1-3b",
+		s:        `[2020-12-31]`,
+		features: all &^ jsonlp.Feature_DateTime,
+		wantErr:  "Extension date and time literal is not allowed",
+	}, {
+		name:     "f1-4a",
+		s:        `[1+2i]`,
+		features: all &^ jsonlp.Feature_Complex,
+		wantErr:  "Extension complex number is not allowed",
+	}, {
+		name:     "f1-4b",
+		s:        `[1u64]`,
+		features: all &^ jsonlp.Feature_NumberSuffix,
+		wantErr:  "Extension number suffix is not allowed",
+	}, {
+		name:     "f1-5a",
+		s:        "# comment\n{a: 1,}",
+		features: jsonlp.Features_JSON5 | jsonlp.Feature_HashComment,
+		want:     map[string]interface{}{"a": float64(1)},
+	}, {
+		name:     "f1-6a",
+		s:        `{"a": [1, 2]}`,
+		features: jsonlp.Features_StrictJSON,
+		want:     map[string]interface{}{"a": []interface{}{float64(1), float64(2)}},
+	}, {
+		name:     "f1-6b",
+		s:        "// comment\n{\"a\": 1}",
+		features: jsonlp.Features_StrictJSON,
+		wantErr:  "Extension line comment `//` is not allowed in dialect StrictJSON",
+	}, {
+		name:     "f1-6c",
+		s:        `{a: 1}`,
+		features: jsonlp.Features_StrictJSON,
+		wantErr:  "Extension unquoted key is not allowed in dialect StrictJSON",
+	}, {
+		name:     "f1-6d",
+		s:        `{"a": 1 /* comment */}`,
+		features: jsonlp.Features_JSONC &^ (jsonlp.Feature_LineComment | jsonlp.Feature_BlockComment),
+		wantErr:  "Extension block comment `/* */` is not allowed in dialect StrictJSON",
+	}, {
+		name:     "f1-6e",
+		s:        `{"a": 1, "b": 2,}`,
+		features: jsonlp.Feature_TrailingComma,
+		want:     map[string]interface{}{"a": float64(1), "b": float64(2)},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Features overrides Dialect.
+			for _, d := range []jsonlp.DialectType{jsonlp.Dialect_Loose, jsonlp.Dialect_StrictJSON, jsonlp.Dialect_Loose} {
+				opts := &jsonlp.ParseOptions{Dialect: d, Features: tt.features}
+				got, err := jsonlp.ParseJSONWithOptions(tt.s, opts)
+				if tt.wantErr != "" {
+					var se *jsonlp.SyntaxError
+					if !errors.As(err, &se) {
+						t.Errorf("ParseJSONWithOptions: error = %v, want SyntaxError", err)
+						return
+					}
+					if se.Code != jsonlp.ErrorCode_ExtensionNotAllowed || se.Message != tt.wantErr {
+						t.Errorf("ParseJSONWithOptions: code, msg = %v, %q, want %v, %q", se.Code, se.Message, jsonlp.ErrorCode_ExtensionNotAllowed, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("ParseJSONWithOptions: error = %v", err)
+					return
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ParseJSONWithOptions: v = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTomlFeatures1(t *testing.T) {
+	toml10 := jsonlp.Features_StrictJSON // Only the extensions that TOML 1.0 also has
+	tests := []struct {
+		name     string
+		s        string
+		features jsonlp.Features
+		want     interface{}
+		wantErr  string
+	}{{
+		name:     "tf1-1a",
+		s:        "a = 1+2i\nc = undefined",
+		features: jsonlp.Features_All,
+		want:     map[string]interface{}{"a": complex(1, 2), "c": nil},
+	}, {
+		name:     "tf1-1b",
+		s:        "a = 1+2i",
+		features: jsonlp.Features_All &^ jsonlp.Feature_Complex,
+		wantErr:  "Extension complex number is not allowed",
+	}, {
+		name:     "tf1-1c",
+		s:        "c = undefined",
+		features: jsonlp.Features_All &^ jsonlp.Feature_Undefined,
+		wantErr:  "Extension `undefined` and `None` is not allowed",
+	}, {
+		name:     "tf1-2a",
+		s:        "# comment\n[a]\nb = 0xff # comment\nc = [1, 2,]\nd = {e.f = 'x'}\ng = 2020-12-31",
+		features: toml10,
+		want: map[string]interface{}{"a": map[string]interface{}{
+			"b": float64(255),
+			"c": []interface{}{float64(1), float64(2)},
+			"d": map[string]interface{}{"e": map[string]interface{}{"f": "x"}},
+			"g": time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC),
+		}},
+	}, {
+		name:     "tf1-2b",
+		s:        "a = 1 // comment",
+		features: toml10,
+		wantErr:  "Extension line comment `//` is not allowed",
+	}, {
+		name:     "tf1-2c",
+		s:        "a = [1, /* comment */ 2]",
+		features: toml10,
+		wantErr:  "Extension block comment `/* */` is not allowed",
+	}, {
+		name:     "tf1-2d",
+		s:        "a = `x`",
+		features: toml10,
+		wantErr:  "Extension back quoted string is not allowed",
+	}, {
+		name:     "tf1-2e",
+		s:        "a = 1u64",
+		features: toml10,
+		wantErr:  "Extension number suffix is not allowed",
+	}, {
+		name:     "tf1-2f",
+		s:        "a = TRUE",
+		features: toml10,
+		wantErr:  "Extension case-insensitive literal is not allowed",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Dialect is ignored.
+			for _, d := range []jsonlp.DialectType{jsonlp.Dialect_Loose, jsonlp.Dialect_StrictJSON, jsonlp.Dialect_Loose} {
+				opts := &jsonlp.ParseOptions{Dialect: d, Features: tt.features}
+				got, err := jsonlp.ParseTOMLWithOptions(tt.s, opts)
+				if tt.wantErr != "" {
+					var se *jsonlp.SyntaxError
+					if !errors.As(err, &se) {
+						t.Errorf("ParseTOMLWithOptions: error = %v, want SyntaxError", err)
+						return
+					}
+					if se.Code != jsonlp.ErrorCode_ExtensionNotAllowed || se.Message != tt.wantErr {
+						t.Errorf("ParseTOMLWithOptions: code, msg = %v, %q, want %v, %q", se.Code, se.Message, jsonlp.ErrorCode_ExtensionNotAllowed, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("ParseTOMLWithOptions: error = %v", err)
+					return
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ParseTOMLWithOptions: v = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTomlFeatures2(t *testing.T) {
+	// The Unicode escape sequences are only accepted in the unquoted keys of JSON.
+	for _, s := range []string{
+		`\u0061 = 1`,
+		`b.\u0061 = 1`,
+		"[\\u0061]\nb = 1",
+		`b = {\u0061 = 1}`,
+	} {
+		for _, f := range []jsonlp.Features{jsonlp.Features_All, jsonlp.Features_StrictJSON} {
+			if got, err := jsonlp.ParseTOMLWithOptions(s, &jsonlp.ParseOptions{Features: f}); err == nil {
+				t.Errorf("ParseTOMLWithOptions(%q, %v) = %v, want error", s, f, got)
+			}
+		}
+	}
+
+	got, err := jsonlp.ParseJSONWithOptions(`{\u0061: 1}`, &jsonlp.ParseOptions{Features: jsonlp.Features_All})
+	if err != nil || !reflect.DeepEqual(got, map[string]interface{}{"a": float64(1)}) {
+		t.Errorf("ParseJSONWithOptions() = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,812 @@
+package jsonlp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	. "github.com/shellyln/takenoco/base"
+	strparser "github.com/shellyln/takenoco/string"
+)
+
+// Error that is returned if the path of the edit is not found in the document.
+var ErrPathNotFound = errors.New("Path not found")
+
+// Document that can be edited keeping the comments and the layout.
+//
+// The edit methods rewrite only the span of the source that is touched,
+// and the result is parsed again to keep the CST up to date.
+// If the result is not well-formed, the document is not changed.
+type Document struct {
+	src    string
+	root   *Node
+	isTOML bool
+	opts   *ParseOptions
+}
+
+// src: Loose JSON
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+func ParseJSONDocument(s string, opts *ParseOptions) (*Document, error) {
+	root, err := ParseJSONCST(s, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{src: s, root: root, isTOML: false, opts: opts}, nil
+}
+
+// src: Loose TOML
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+func ParseTOMLDocument(s string, opts *ParseOptions) (*Document, error) {
+	root, err := ParseTOMLCST(s, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{src: s, root: root, isTOML: true, opts: opts}, nil
+}
+
+// Source text of the document.
+func (d *Document) String() string {
+	return d.src
+}
+
+// CST of the document.
+func (d *Document) Root() *Node {
+	return d.root
+}
+
+// Parse the document and return the value.
+func (d *Document) Value() (interface{}, error) {
+	if d.isTOML {
+		return ParseTOMLWithOptions(d.src, d.opts)
+	}
+	return ParseJSONWithOptions(d.src, d.opts)
+}
+
+var docPathParser ParserFn
+
+func init() {
+	docPathParser = FlatGroup(
+		Start(),
+		sp0NoLb(Features_All),
+		objectKey(grammarSpec_All, false),
+		sp0NoLb(Features_All),
+		strparser.End(),
+	)
+}
+
+// Parse the path by the key grammar. (e.g. `a.b."c.d"`)
+// The empty path is the root.
+func parseDocPath(path string) ([]string, error) {
+	if strings.TrimSpace(path) == "" {
+		return []string{}, nil
+	}
+	ctx := *strparser.NewStringParserContext(path)
+	ctx.Tag = newParseOptions(nil, false)
+
+	out, err := docPathParser(ctx)
+	if err != nil {
+		return nil, newSyntaxError(path, out.SourcePosition, err)
+	}
+	if out.MatchStatus != MatchStatus_Matched {
+		return nil, newSyntaxError(path, out.SourcePosition, nil)
+	}
+	switch v := out.AstStack[0].Value.(type) {
+	case []string:
+		return v, nil
+	case string:
+		return []string{v}, nil
+	}
+	return nil, fmt.Errorf("Invalid path: %v", path)
+}
+
+// Location of the path in the document.
+type docLocation struct {
+	container *Node    // Object node, or TOML Document, Table, ArrayOfTables node that has the members
+	member    *Node    // Matched member. nil if it is not found
+	keyLen    int      // Number of the key parts of the matched member
+	section   *Node    // Matched TOML section if the path is the header of the section
+	rest      []string // Key parts from the container to the target
+}
+
+// Children of the node excluding whitespaces and comments.
+func significantChildren(n *Node) []*Node {
+	ret := make([]*Node, 0, len(n.Children))
+	for _, c := range n.Children {
+		if c.Kind != Node_Whitespace && c.Kind != Node_Comment {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+func childIndex(parent, child *Node) int {
+	for i, c := range parent.Children {
+		if c == child {
+			return i
+		}
+	}
+	return -1
+}
+
+func memberKey(m *Node) []string {
+	for _, c := range m.Children {
+		if c.Kind == Node_Key {
+			return c.Value.([]string)
+		}
+	}
+	return nil
+}
+
+func memberValue(m *Node) *Node {
+	return m.Children[len(m.Children)-1]
+}
+
+func sectionKey(s *Node) []string {
+	for _, c := range s.Children {
+		if c.Kind == Node_Header {
+			for _, h := range c.Children {
+				if h.Kind == Node_Key {
+					return h.Value.([]string)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func hasKeyPrefix(key, prefix []string) bool {
+	if len(key) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if key[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Find the last member whose key is the prefix of the path.
+func findMember(container *Node, path []string) *Node {
+	var ret *Node
+	for _, c := range container.Children {
+		if c.Kind == Node_Member && hasKeyPrefix(path, memberKey(c)) {
+			ret = c
+		}
+	}
+	return ret
+}
+
+// Root value of the JSON document.
+func (d *Document) jsonRootValue() *Node {
+	for _, c := range significantChildren(d.root) {
+		return c
+	}
+	return nil
+}
+
+func (d *Document) sections() []*Node {
+	ret := make([]*Node, 0)
+	for _, c := range d.root.Children {
+		if c.Kind == Node_Table || c.Kind == Node_ArrayOfTables {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+func (d *Document) locate(path []string) (docLocation, error) {
+	loc := docLocation{rest: path}
+	if d.isTOML {
+		// The last one of the longest headers that is the prefix of the path.
+		// (Sub keys of the array of tables belong to the last element)
+		loc.container = d.root
+		headerLen := 0
+		for _, s := range d.sections() {
+			key := sectionKey(s)
+			if hasKeyPrefix(path, key) && len(key) >= headerLen {
+				loc.container = s
+				headerLen = len(key)
+			}
+		}
+		if headerLen != 0 && headerLen == len(path) {
+			loc.section = loc.container
+			loc.rest = []string{}
+			return loc, nil
+		}
+		loc.rest = path[headerLen:]
+	} else {
+		loc.container = d.jsonRootValue()
+		if len(path) != 0 && loc.container.Kind != Node_Object {
+			return loc, fmt.Errorf("Not an object: (root)")
+		}
+	}
+
+	for len(loc.rest) != 0 {
+		m := findMember(loc.container, loc.rest)
+		if m == nil {
+			return loc, nil
+		}
+		n := len(memberKey(m))
+		if n == len(loc.rest) {
+			loc.member = m
+			loc.keyLen = n
+			return loc, nil
+		}
+		v := memberValue(m)
+		if v.Kind != Node_Object {
+			return loc, fmt.Errorf("Not a table: %v", strings.Join(path[:len(path)-len(loc.rest)+n], "."))
+		}
+		loc.container = v
+		loc.rest = loc.rest[n:]
+	}
+	return loc, nil
+}
+
+type textEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// Apply the edits to the source and parse it again.
+func (d *Document) apply(edits ...textEdit) error {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	s := d.src
+	for _, e := range edits {
+		s = s[:e.start] + e.text + s[e.end:]
+	}
+
+	var root *Node
+	var err error
+	if d.isTOML {
+		root, err = ParseTOMLCST(s, d.opts)
+	} else {
+		root, err = ParseJSONCST(s, d.opts)
+	}
+	if err != nil {
+		return err
+	}
+	d.src = s
+	d.root = root
+	return nil
+}
+
+// Line break of the document.
+func (d *Document) linebreak() string {
+	if strings.Contains(d.src, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// Whitespaces between the start of the line and pos.
+// If there are the other characters, returns false.
+func (d *Document) indentAt(pos int) (string, bool) {
+	start := strings.LastIndexAny(d.src[:pos], "\r\n") + 1
+	indent := d.src[start:pos]
+	if strings.TrimLeft(indent, " \t") != "" {
+		return "", false
+	}
+	return indent, true
+}
+
+// Position after the line break that follows the child at index i.
+// Whitespaces and comments are skipped. If there is no line break, returns -1.
+func nextLineStart(parent *Node, i int) int {
+	for _, c := range parent.Children[i+1:] {
+		switch c.Kind {
+		case Node_Whitespace:
+			if k := strings.IndexAny(c.Text, "\r\n"); k >= 0 {
+				if strings.HasPrefix(c.Text[k:], "\r\n") {
+					return c.Start + k + 2
+				}
+				return c.Start + k + 1
+			}
+		case Node_Comment:
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+func (d *Document) formatValue(v interface{}, style FormatStyle) (string, error) {
+	if d.isTOML {
+		f := &tomlFormatter{opts: &formatOptsDefault, inline: 1}
+		if err := f.value(v); err != nil {
+			return "", err
+		}
+		return f.sb.String(), nil
+	}
+	return FormatJSON(v, &FormatOptions{Style: style})
+}
+
+// Style of the keys and values of the JSON document.
+// If the keys are quoted by double quotes, strict JSON is written.
+func (d *Document) jsonStyle() (FormatStyle, QuoteStyle) {
+	var key *Node
+	var find func(n *Node)
+	find = func(n *Node) {
+		for _, c := range n.Children {
+			if key != nil {
+				return
+			}
+			if c.Kind == Node_Key {
+				key = c
+				return
+			}
+			find(c)
+		}
+	}
+	find(d.root)
+	if key == nil || strings.HasPrefix(key.Children[0].Text, "\"") {
+		return Style_JSON, Quote_Double
+	}
+	if strings.HasPrefix(key.Children[0].Text, "'") {
+		return Style_JSON5, Quote_Single
+	}
+	return Style_JSON5, Quote_Double
+}
+
+func (d *Document) formatKey(key []string) string {
+	var sb strings.Builder
+	if d.isTOML {
+		f := &tomlFormatter{opts: &formatOptsDefault}
+		f.dottedKey(key)
+		return f.sb.String()
+	}
+
+	style, quote := d.jsonStyle()
+	f := &jsonFormatter{opts: &FormatOptions{Style: style, Quote: quote}}
+	for i, k := range key {
+		if i != 0 {
+			sb.WriteByte('.')
+		}
+		f.sb.Reset()
+		if style == Style_JSON5 && isIdentifier(k) {
+			f.sb.WriteString(k)
+		} else {
+			f.str(k)
+		}
+		sb.WriteString(f.sb.String())
+	}
+	return sb.String()
+}
+
+// Separator between the key and the value. (e.g. `: `)
+func (d *Document) separator(container *Node) string {
+	if container.Kind != Node_Object {
+		return " = "
+	}
+	var member *Node
+	for _, c := range container.Children {
+		if c.Kind == Node_Member {
+			member = c
+		}
+	}
+	if member == nil {
+		if d.isTOML {
+			return " = "
+		}
+		return ": "
+	}
+	var sb strings.Builder
+	for _, c := range member.Children[1 : len(member.Children)-1] {
+		c.writeTo(&sb)
+	}
+	return sb.String()
+}
+
+func (d *Document) memberText(container *Node, key []string, v interface{}) (string, error) {
+	style, _ := d.jsonStyle()
+	value, err := d.formatValue(v, style)
+	if err != nil {
+		return "", err
+	}
+	return d.formatKey(key) + d.separator(container) + value, nil
+}
+
+// Insert the element after the child (or at the start if child is nil) of the object or the array.
+func (d *Document) insertElement(container, child *Node, text string) error {
+	children := significantChildren(container)
+	open := children[0]
+	if child == nil {
+		return d.apply(textEdit{open.End, open.End, text})
+	}
+
+	// Layout of the elements
+	lb := d.linebreak()
+	indent, multiline := "", false
+	if first := children[1]; first.Start > open.End {
+		indent, multiline = d.indentAt(first.Start)
+	}
+
+	i := childIndex(container, child)
+	var comma *Node
+	for _, c := range container.Children[i+1:] {
+		if c.Kind == Node_Punct && c.Text == "," {
+			comma = c
+		}
+		if c.Kind != Node_Whitespace && c.Kind != Node_Comment {
+			break
+		}
+	}
+
+	if multiline {
+		// Insert the new line to keep the trailing comment of the child.
+		if comma != nil {
+			if pos := nextLineStart(container, childIndex(container, comma)); pos >= 0 {
+				return d.apply(textEdit{pos, pos, indent + text + "," + lb})
+			}
+		} else if pos := nextLineStart(container, i); pos >= 0 {
+			return d.apply(textEdit{child.End, child.End, ","}, textEdit{pos, pos, indent + text + lb})
+		}
+	}
+
+	sep := " "
+	if multiline {
+		sep = lb + indent
+	}
+	if comma != nil {
+		return d.apply(textEdit{comma.End, comma.End, sep + text + ","})
+	}
+	return d.apply(textEdit{child.End, child.End, "," + sep + text})
+}
+
+// Insert the key-value pair line into the TOML table after the member (or at the end if member is nil).
+func (d *Document) insertTomlLine(container, member *Node, key []string, v interface{}) error {
+	text, err := d.memberText(container, key, v)
+	if err != nil {
+		return err
+	}
+	lb := d.linebreak()
+
+	if member == nil {
+		for _, c := range container.Children {
+			if c.Kind == Node_Member {
+				member = c
+			}
+		}
+	}
+
+	var after *Node
+	indent := ""
+	if member != nil {
+		after = member
+		indent, _ = d.indentAt(member.Start)
+	} else if container.Kind != Node_Document {
+		after = container.Children[0] // Header
+	} else {
+		// Before the first section
+		sections := d.sections()
+		if len(sections) != 0 {
+			return d.apply(textEdit{sections[0].Start, sections[0].Start, text + lb + lb})
+		}
+		if d.src != "" && !strings.HasSuffix(d.src, "\n") && !strings.HasSuffix(d.src, "\r") {
+			return d.apply(textEdit{len(d.src), len(d.src), lb + text + lb})
+		}
+		return d.apply(textEdit{len(d.src), len(d.src), text + lb})
+	}
+
+	pos := nextLineStart(container, childIndex(container, after))
+	if pos < 0 {
+		// Last line without the line break
+		end := container.End
+		return d.apply(textEdit{end, end, lb + indent + text})
+	}
+	return d.apply(textEdit{pos, pos, indent + text + lb})
+}
+
+// Set the value of the path.
+// If the path does not exist, the key-value pair is added to the deepest existing table.
+// Missing tables are made by the dotted key (TOML) or the nested objects (JSON).
+//
+// path: Dotted key (e.g. `a.b."c.d"`)
+func (d *Document) Set(path string, value interface{}) error {
+	keys, err := parseDocPath(path)
+	if err != nil {
+		return err
+	}
+	loc, err := d.locate(keys)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		if d.isTOML {
+			return fmt.Errorf("Cannot replace the root table")
+		}
+		style, _ := d.jsonStyle()
+		text, err := d.formatValue(value, style)
+		if err != nil {
+			return err
+		}
+		v := d.jsonRootValue()
+		return d.apply(textEdit{v.Start, v.End, text})
+	}
+	if loc.section != nil {
+		return fmt.Errorf("Cannot replace the table section: %v", path)
+	}
+
+	if loc.member != nil {
+		style, _ := d.jsonStyle()
+		text, err := d.formatValue(value, style)
+		if err != nil {
+			return err
+		}
+		v := memberValue(loc.member)
+		return d.apply(textEdit{v.Start, v.End, text})
+	}
+
+	// The key should not be the implicit table that is made by the dotted keys or the sections.
+	for _, c := range loc.container.Children {
+		if c.Kind == Node_Member && hasKeyPrefix(memberKey(c), loc.rest) {
+			return fmt.Errorf("Cannot replace the table that is defined by the dotted keys: %v", path)
+		}
+	}
+	if d.isTOML {
+		for _, s := range d.sections() {
+			if hasKeyPrefix(sectionKey(s), keys) {
+				return fmt.Errorf("Cannot replace the table that has the sub table: %v", path)
+			}
+		}
+	}
+
+	if loc.container.Kind == Node_Object {
+		key := loc.rest
+		if !d.isTOML {
+			// Dotted keys are not the JSON syntax. Missing tables are made by the nested objects.
+			for i := len(key) - 1; 0 < i; i-- {
+				value = map[string]interface{}{key[i]: value}
+			}
+			key = key[:1]
+		}
+		text, err := d.memberText(loc.container, key, value)
+		if err != nil {
+			return err
+		}
+		var last *Node
+		for _, c := range loc.container.Children {
+			if c.Kind == Node_Member {
+				last = c
+			}
+		}
+		return d.insertElement(loc.container, last, text)
+	}
+	return d.insertTomlLine(loc.container, nil, loc.rest, value)
+}
+
+// Insert the key-value pair after the member of the path.
+// The new member is a sibling of the member.
+//
+// path: Dotted key of the existing member (e.g. `a.b`)
+//
+// key: Key of the new member (not dotted)
+func (d *Document) InsertAfter(path string, key string, value interface{}) error {
+	keys, err := parseDocPath(path)
+	if err != nil {
+		return err
+	}
+	loc, err := d.locate(keys)
+	if err != nil {
+		return err
+	}
+	if loc.member == nil {
+		return fmt.Errorf("%w: %v", ErrPathNotFound, path)
+	}
+
+	// The member may have the dotted key. (e.g. `a.b = 1` -> `a.key = value`)
+	newKey := append(append([]string{}, loc.rest[:loc.keyLen-1]...), key)
+	for _, c := range loc.container.Children {
+		if c.Kind == Node_Member && hasKeyPrefix(memberKey(c), newKey) {
+			return fmt.Errorf("Key already exists: %v", strings.Join(append(keys[:len(keys)-1:len(keys)-1], key), "."))
+		}
+	}
+
+	if loc.container.Kind == Node_Object {
+		text, err := d.memberText(loc.container, newKey, value)
+		if err != nil {
+			return err
+		}
+		return d.insertElement(loc.container, loc.member, text)
+	}
+	return d.insertTomlLine(loc.container, loc.member, newKey, value)
+}
+
+// Append the value to the array of the path.
+// If the path is the TOML array of tables, the new `[[array-of-tables]]` section is appended.
+//
+// path: Dotted key of the existing array (e.g. `a.b`). The empty path is the root array.
+func (d *Document) AppendToArray(path string, value interface{}) error {
+	keys, err := parseDocPath(path)
+	if err != nil {
+		return err
+	}
+
+	var arr *Node
+	if len(keys) == 0 && !d.isTOML {
+		arr = d.jsonRootValue()
+	} else {
+		loc, err := d.locate(keys)
+		if err != nil {
+			return err
+		}
+		switch {
+		case loc.section != nil && loc.section.Kind == Node_ArrayOfTables:
+			return d.appendArrayOfTables(keys, loc.section, value)
+		case loc.member != nil:
+			arr = memberValue(loc.member)
+		default:
+			return fmt.Errorf("%w: %v", ErrPathNotFound, path)
+		}
+	}
+	if arr.Kind != Node_Array {
+		return fmt.Errorf("Not an array: %v", path)
+	}
+
+	style, _ := d.jsonStyle()
+	text, err := d.formatValue(value, style)
+	if err != nil {
+		return err
+	}
+	children := significantChildren(arr)
+	var last *Node
+	for _, c := range children[1 : len(children)-1] {
+		if c.Kind != Node_Punct {
+			last = c
+		}
+	}
+	return d.insertElement(arr, last, text)
+}
+
+// Append the `[[array-of-tables]]` section after the last element and its sub tables.
+func (d *Document) appendArrayOfTables(keys []string, last *Node, value interface{}) error {
+	t, ok := asFmtTable(value)
+	if !ok {
+		return fmt.Errorf("Unsupported type: %T (The element of the array of tables should be a table)", value)
+	}
+
+	pos := last.End
+	found := false
+	for _, s := range d.sections() {
+		if s == last {
+			found = true
+		} else if found {
+			if !hasKeyPrefix(sectionKey(s), keys) || len(sectionKey(s)) == len(keys) {
+				break
+			}
+		}
+		if found {
+			pos = d.contentEnd(s)
+		}
+	}
+
+	f := &tomlFormatter{opts: &formatOptsDefault}
+	if err := f.table(keys, t, len(keys), true); err != nil {
+		return err
+	}
+	lb := d.linebreak()
+	text := lb + strings.ReplaceAll(f.sb.String(), "\n", lb)
+	if !strings.HasSuffix(d.src[:pos], "\n") && !strings.HasSuffix(d.src[:pos], "\r") {
+		text = lb + text
+	}
+	return d.apply(textEdit{pos, pos, text})
+}
+
+// Delete the member or the TOML table section of the path.
+// Sub tables of the section and the members whose dotted key starts with the path are also deleted.
+//
+// path: Dotted key (e.g. `a.b."c.d"`)
+func (d *Document) Delete(path string) error {
+	keys, err := parseDocPath(path)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("Cannot delete the root")
+	}
+	loc, err := d.locate(keys)
+	if err != nil {
+		return err
+	}
+
+	edits := make([]textEdit, 0)
+	if d.isTOML {
+		for _, s := range d.sections() {
+			if hasKeyPrefix(sectionKey(s), keys) {
+				edits = append(edits, textEdit{s.Start, d.contentEnd(s), ""})
+			}
+		}
+	}
+	if loc.section == nil {
+		for _, c := range loc.container.Children {
+			if c.Kind == Node_Member && hasKeyPrefix(memberKey(c), loc.rest) {
+				if loc.container.Kind == Node_Object {
+					edits = append(edits, d.deleteElement(loc.container, c))
+				} else {
+					edits = append(edits, d.deleteTomlLine(loc.container, c))
+				}
+			}
+		}
+	}
+	if len(edits) == 0 {
+		return fmt.Errorf("%w: %v", ErrPathNotFound, path)
+	}
+	return d.apply(edits...)
+}
+
+// End of the section excluding the trailing whitespaces and comments after the last line.
+func (d *Document) contentEnd(s *Node) int {
+	last := -1
+	for i, c := range s.Children {
+		if c.Kind != Node_Whitespace && c.Kind != Node_Comment {
+			last = i
+		}
+	}
+	if pos := nextLineStart(s, last); pos >= 0 {
+		return pos
+	}
+	return s.End
+}
+
+// Span of the key-value pair line including the indentation, the trailing comment and the line break.
+func (d *Document) deleteTomlLine(container, member *Node) textEdit {
+	start := member.Start
+	if _, ok := d.indentAt(start); ok {
+		start = strings.LastIndexAny(d.src[:start], "\r\n") + 1
+	}
+	i := childIndex(container, member)
+	end := nextLineStart(container, i)
+	if end < 0 {
+		// The last line without the line break.
+		// Remove the trailing whitespaces and comments of the line.
+		end = member.End
+		for _, c := range container.Children[i+1:] {
+			end = c.End
+		}
+	}
+	return textEdit{start, end, ""}
+}
+
+// Span of the element of the object or the array including the comma.
+func (d *Document) deleteElement(container, child *Node) textEdit {
+	children := significantChildren(container)
+	k := 0
+	for i, c := range children {
+		if c == child {
+			k = i
+		}
+	}
+
+	start, end := child.Start, child.End
+	next := children[k+1]
+	if next.Kind == Node_Punct && next.Text == "," {
+		end = next.End
+		if _, ok := d.indentAt(start); ok {
+			// The element is at the start of the line.
+			i := childIndex(container, next)
+			if pos := nextLineStart(container, i); pos >= 0 {
+				start = strings.LastIndexAny(d.src[:start], "\r\n") + 1
+				end = pos
+			}
+		} else if after := container.Children[childIndex(container, next)+1]; after.Kind == Node_Whitespace && !strings.ContainsAny(after.Text, "\r\n") {
+			end = after.End
+		}
+		return textEdit{start, end, ""}
+	}
+
+	prev := children[k-1]
+	if prev.Kind == Node_Punct && prev.Text == "," {
+		// The last element without the trailing comma
+		start = prev.Start
+	}
+	return textEdit{start, end, ""}
+}
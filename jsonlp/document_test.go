@@ -0,0 +1,179 @@
+package jsonlp_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func TestDocument1(t *testing.T) {
+	type op struct {
+		name  string // Set | Delete | InsertAfter | AppendToArray
+		path  string
+		key   string
+		value interface{}
+	}
+	tests := []struct {
+		name    string
+		s       string
+		isTOML  bool
+		ops     []op
+		want    string
+		wantErr bool
+	}{{
+		name: "doc1-1a",
+		s:    "{\n  // version\n  \"version\": \"1.0.0\", // current\n  \"deps\": [\"a\"]\n}\n",
+		ops: []op{
+			{"Set", "version", "", "1.1.0"},
+			{"Set", "name", "", "app"},
+			{"AppendToArray", "deps", "", "b"},
+		},
+		want: "{\n  // version\n  \"version\": \"1.1.0\", // current\n  \"deps\": [\"a\", \"b\"],\n  \"name\": \"app\"\n}\n",
+	}, {
+		name: "doc1-1b",
+		s:    "{a: 1, /* c */ b: {c: 2,},}",
+		ops: []op{
+			{"Set", "b.d", "", float64(3)},
+			{"InsertAfter", "a", "x", true},
+			{"Delete", "b.c", "", nil},
+		},
+		want: "{a: 1, x: true, /* c */ b: {d: 3,},}",
+	}, {
+		name: "doc1-1c",
+		s:    "{\n  a: 1, // one\n  b: 2 // two\n}",
+		ops: []op{
+			{"InsertAfter", "b", "c", float64(3)},
+			{"Delete", "a", "", nil},
+			{"Set", "e.f", "", []interface{}{}},
+			{"AppendToArray", "e.f", "", "x"},
+		},
+		want: "{\n  b: 2, // two\n  c: 3,\n  e: {f:[\"x\"]}\n}",
+	}, {
+		name: "doc1-1d",
+		s:    "[1, 2]",
+		ops: []op{
+			{"AppendToArray", "", "", float64(3)},
+		},
+		want: "[1, 2, 3]",
+	}, {
+		name:   "doc1-2a",
+		s:      "# head\ntitle = \"x\" # t\n\n[package] # p\nversion = \"1.0.0\"\n\n# deps\n[deps]\na = \"1\"\n",
+		isTOML: true,
+		ops: []op{
+			{"Set", "package.version", "", "1.1.0"},
+			{"Set", "package.edition", "", "2021"},
+			{"InsertAfter", "title", "desc", "y"},
+			{"Set", "deps.b.c", "", int64(2)},
+		},
+		want: "# head\ntitle = \"x\" # t\ndesc = \"y\"\n\n[package] # p\nversion = \"1.1.0\"\nedition = \"2021\"\n\n# deps\n[deps]\na = \"1\"\nb.c = 2\n",
+	}, {
+		name:   "doc1-2b",
+		s:      "a = 1\n[t]\nb = 2 # b\nc = { d = 3 }\n[t.u]\ne = 4\n\n[x]\ny = 5",
+		isTOML: true,
+		ops: []op{
+			{"Delete", "t.b", "", nil},
+			{"Set", "t.c.f", "", "g"},
+			{"Delete", "t.u", "", nil},
+			{"Set", "x.z", "", int64(6)},
+		},
+		want: "a = 1\n[t]\nc = { d = 3, f = \"g\" }\n\n[x]\ny = 5\nz = 6",
+	}, {
+		name:   "doc1-2c",
+		s:      "[[srv]]\nport = 80\n\n[[srv]]\nport = 81\n[srv.opt]\nk = 1\n\n# tail\n",
+		isTOML: true,
+		ops: []op{
+			{"Set", "srv.port", "", int64(8081)},
+			{"AppendToArray", "srv", "", map[string]interface{}{"port": int64(82)}},
+		},
+		want: "[[srv]]\nport = 80\n\n[[srv]]\nport = 8081\n[srv.opt]\nk = 1\n\n[[srv]]\nport = 82\n\n# tail\n",
+	}, {
+		name:   "doc1-2d",
+		s:      "a.b = 1\r\n",
+		isTOML: true,
+		ops: []op{
+			{"Set", "c", "", []interface{}{int64(1), "x"}},
+			{"InsertAfter", "a.b", "d", false},
+		},
+		want: "a.b = 1\r\na.d = false\r\nc = [1, \"x\"]\r\n",
+	}, {
+		name:    "doc1-3a",
+		s:       "a.b = 1\n",
+		isTOML:  true,
+		ops:     []op{{"Set", "a", "", int64(1)}},
+		wantErr: true,
+	}, {
+		name:    "doc1-3b",
+		s:       "{a: 1}",
+		ops:     []op{{"InsertAfter", "b", "c", int64(1)}},
+		wantErr: true,
+	}, {
+		name:    "doc1-3c",
+		s:       "{a: 1}",
+		ops:     []op{{"InsertAfter", "a", "a", int64(1)}},
+		wantErr: true,
+	}, {
+		name:    "doc1-3d",
+		s:       "{a: 1}",
+		ops:     []op{{"AppendToArray", "a", "", int64(1)}},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc *jsonlp.Document
+			var err error
+			if tt.isTOML {
+				doc, err = jsonlp.ParseTOMLDocument(tt.s, nil)
+			} else {
+				doc, err = jsonlp.ParseJSONDocument(tt.s, nil)
+			}
+			if err != nil {
+				t.Errorf("ParseDocument() error = %v", err)
+				return
+			}
+			for _, o := range tt.ops {
+				switch o.name {
+				case "Set":
+					err = doc.Set(o.path, o.value)
+				case "Delete":
+					err = doc.Delete(o.path)
+				case "InsertAfter":
+					err = doc.InsertAfter(o.path, o.key, o.value)
+				case "AppendToArray":
+					err = doc.AppendToArray(o.path, o.value)
+				}
+				if err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("%v error = %v, wantErr %v\n%v", tt.name, err, tt.wantErr, doc.String())
+				return
+			}
+			if err != nil {
+				if doc.String() != tt.s {
+					t.Errorf("String() = %q, want unchanged", doc.String())
+				}
+				return
+			}
+			if doc.String() != tt.want {
+				t.Errorf("String() = %q, want %q", doc.String(), tt.want)
+			}
+			if _, err := doc.Value(); err != nil {
+				t.Errorf("Value() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDocument2(t *testing.T) {
+	doc, err := jsonlp.ParseTOMLDocument("a = 1\n", nil)
+	if err != nil {
+		t.Errorf("ParseTOMLDocument() error = %v", err)
+		return
+	}
+	if err := doc.Delete("b"); !errors.Is(err, jsonlp.ErrPathNotFound) {
+		t.Errorf("Delete() error = %v, want ErrPathNotFound", err)
+	}
+}
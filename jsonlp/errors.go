@@ -0,0 +1,295 @@
+package jsonlp
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Machine-readable error code of the SyntaxError.
+type ErrorCode int
+
+const (
+	ErrorCode_Unknown ErrorCode = iota
+	ErrorCode_ParseFailed
+	ErrorCode_ExpectTermination
+	ErrorCode_ExpectValue
+	ErrorCode_ExpectArrayValue
+	ErrorCode_ExpectArrayClose
+	ErrorCode_ExpectObjectMember
+	ErrorCode_ExpectObjectClose
+	ErrorCode_ExpectTableClose
+	ErrorCode_ExpectArrayOfTableClose
+	ErrorCode_ExpectLinebreak
+	ErrorCode_UnterminatedComment
+	ErrorCode_UnterminatedString
+	ErrorCode_UnexpectedNewlineInString
+	ErrorCode_InvalidNumber
+	ErrorCode_InvalidDateTime
+	ErrorCode_DuplicateKey
+	ErrorCode_TableRedefinition
+	ErrorCode_InlineTableExtension
+	ErrorCode_ArrayOfTablesConflict
+	ErrorCode_InputTooLarge
+	ErrorCode_DepthLimitExceeded
+	ErrorCode_StringLengthLimitExceeded
+	ErrorCode_ArrayLengthLimitExceeded
+	ErrorCode_MemberLimitExceeded
+	ErrorCode_NodeLimitExceeded
+	ErrorCode_Canceled
+	ErrorCode_InvalidEscapeSequence
+	ErrorCode_InvalidCharacter
+	ErrorCode_ExtensionNotAllowed
+	ErrorCode_InvalidLiteral
+)
+
+// Convert ErrorCode to a string.
+func (c ErrorCode) String() string {
+	switch c {
+	case ErrorCode_ParseFailed:
+		return "ParseFailed"
+	case ErrorCode_ExpectTermination:
+		return "ExpectTermination"
+	case ErrorCode_ExpectValue:
+		return "ExpectValue"
+	case ErrorCode_ExpectArrayValue:
+		return "ExpectArrayValue"
+	case ErrorCode_ExpectArrayClose:
+		return "ExpectArrayClose"
+	case ErrorCode_ExpectObjectMember:
+		return "ExpectObjectMember"
+	case ErrorCode_ExpectObjectClose:
+		return "ExpectObjectClose"
+	case ErrorCode_ExpectTableClose:
+		return "ExpectTableClose"
+	case ErrorCode_ExpectArrayOfTableClose:
+		return "ExpectArrayOfTableClose"
+	case ErrorCode_ExpectLinebreak:
+		return "ExpectLinebreak"
+	case ErrorCode_UnterminatedComment:
+		return "UnterminatedComment"
+	case ErrorCode_UnterminatedString:
+		return "UnterminatedString"
+	case ErrorCode_UnexpectedNewlineInString:
+		return "UnexpectedNewlineInString"
+	case ErrorCode_InvalidNumber:
+		return "InvalidNumber"
+	case ErrorCode_InvalidDateTime:
+		return "InvalidDateTime"
+	case ErrorCode_DuplicateKey:
+		return "DuplicateKey"
+	case ErrorCode_TableRedefinition:
+		return "TableRedefinition"
+	case ErrorCode_InlineTableExtension:
+		return "InlineTableExtension"
+	case ErrorCode_ArrayOfTablesConflict:
+		return "ArrayOfTablesConflict"
+	case ErrorCode_InputTooLarge:
+		return "InputTooLarge"
+	case ErrorCode_DepthLimitExceeded:
+		return "DepthLimitExceeded"
+	case ErrorCode_StringLengthLimitExceeded:
+		return "StringLengthLimitExceeded"
+	case ErrorCode_ArrayLengthLimitExceeded:
+		return "ArrayLengthLimitExceeded"
+	case ErrorCode_MemberLimitExceeded:
+		return "MemberLimitExceeded"
+	case ErrorCode_NodeLimitExceeded:
+		return "NodeLimitExceeded"
+	case ErrorCode_Canceled:
+		return "Canceled"
+	case ErrorCode_InvalidEscapeSequence:
+		return "InvalidEscapeSequence"
+	case ErrorCode_InvalidCharacter:
+		return "InvalidCharacter"
+	case ErrorCode_ExtensionNotAllowed:
+		return "ExtensionNotAllowed"
+	case ErrorCode_InvalidLiteral:
+		return "InvalidLiteral"
+	default:
+		return "Unknown"
+	}
+}
+
+// Position in the source.
+type Position struct {
+	Line   int // Line number (1-based)
+	Col    int // Column number in bytes (1-based)
+	Offset int // Byte offset from the start of the source (0-based)
+}
+
+// Error that is returned if the source is not well-formed.
+// Use `errors.As` to get the position of the error.
+type SyntaxError struct {
+	Position
+	Code    ErrorCode // Machine-readable error code
+	Message string    // Error message without the position
+	Source  string    // Excerpt of the source around the error position
+
+	// Position of the first definition if the error is caused by the redefinition.
+	FirstDefinition *Position
+
+	err error
+}
+
+// Error message with the position and the source excerpt.
+func (e *SyntaxError) Error() string {
+	return e.Message +
+		"\n --> Line " + strconv.Itoa(e.Line) +
+		", Col " + strconv.Itoa(e.Col) + "\n" +
+		e.Source
+}
+
+// Underlying error. (e.g. *strconv.NumError)
+func (e *SyntaxError) Unwrap() error {
+	return e.err
+}
+
+// Errors that are collected in the error recovery mode.
+// They are sorted by the position.
+type SyntaxErrors []*SyntaxError
+
+// Error messages joined by line breaks.
+func (e SyntaxErrors) Error() string {
+	var sb strings.Builder
+	for i, x := range e {
+		if i != 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(x.Error())
+	}
+	return sb.String()
+}
+
+// Errors to be examined by `errors.Is` and `errors.As`. (Go 1.20 or later)
+func (e SyntaxErrors) Unwrap() []error {
+	ret := make([]error, len(e))
+	for i, x := range e {
+		ret[i] = x
+	}
+	return ret
+}
+
+// The first error. It returns nil if there is no error.
+func (e SyntaxErrors) First() *SyntaxError {
+	if len(e) == 0 {
+		return nil
+	}
+	return e[0]
+}
+
+// Returns true if some of the errors match target. (`errors.Is` of Go 1.18 and 1.19)
+func (e SyntaxErrors) Is(target error) bool {
+	for _, x := range e {
+		if errors.Is(x, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set target to the first error that matches it. (`errors.As` of Go 1.18 and 1.19)
+// (e.g. `var se *jsonlp.SyntaxError; errors.As(err, &se)`)
+func (e SyntaxErrors) As(target interface{}) bool {
+	for _, x := range e {
+		if errors.As(x, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Error that is raised by the parsers and transformers.
+// The position is resolved when it is converted to the SyntaxError.
+type parseError struct {
+	code  ErrorCode
+	msg   string
+	pos   *SourcePosition // If not nil, it overrides the error position
+	first *SourcePosition // Position of the first definition
+	err   error           // Underlying error
+}
+
+func (e *parseError) Error() string {
+	return e.msg
+}
+
+func newParseError(code ErrorCode, msg string) error {
+	return &parseError{code: code, msg: msg}
+}
+
+// Zero-width assertion (always error) with the error code.
+func syntaxError(code ErrorCode, msg string) ParserFn {
+	err := newParseError(code, msg)
+	return LightBaseParser("Error", func(ctx ParserContext) (ParserContext, error) {
+		ctx.Length = 0
+		ctx.MatchStatus = MatchStatus_Error
+		return ctx, err
+	})
+}
+
+func newSyntaxError(s string, pos SourcePosition, err error) *SyntaxError {
+	var first *Position
+	if pe, ok := err.(*parseError); ok {
+		if pe.pos != nil {
+			pos = *pe.pos
+		}
+		if pe.first != nil {
+			lc := GetLineAndColPosition(s, *pe.first, 4)
+			first = &Position{
+				Line:   lc.Line,
+				Col:    lc.Col,
+				Offset: lc.Position,
+			}
+		}
+	}
+
+	lc := GetLineAndColPosition(s, pos, 4)
+	ret := &SyntaxError{
+		Position: Position{
+			Line:   lc.Line,
+			Col:    lc.Col,
+			Offset: lc.Position,
+		},
+		Code:            ErrorCode_ParseFailed,
+		Message:         "Parse failed",
+		Source:          lc.ErrSource,
+		FirstDefinition: first,
+	}
+	if err != nil {
+		ret.Message = err.Error()
+		ret.Code = errorCodeOf(err)
+		if pe, ok := err.(*parseError); !ok {
+			ret.err = err
+		} else {
+			ret.err = pe.err
+		}
+	}
+	if first != nil {
+		ret.Message += " (first defined at Line " + strconv.Itoa(first.Line) +
+			", Col " + strconv.Itoa(first.Col) + ")"
+	}
+	return ret
+}
+
+func errorCodeOf(err error) ErrorCode {
+	var pe *parseError
+	var ne *strconv.NumError
+	var te *time.ParseError
+	switch {
+	case errors.As(err, &pe):
+		return pe.code
+	case errors.Is(err, ErrInputTooLarge):
+		return ErrorCode_InputTooLarge
+	case isContextError(err):
+		return ErrorCode_Canceled
+	case errors.As(err, &ne):
+		return ErrorCode_InvalidNumber
+	case errors.As(err, &te):
+		return ErrorCode_InvalidDateTime
+	default:
+		return ErrorCode_Unknown
+	}
+}
@@ -0,0 +1,151 @@
+package jsonlp_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func TestSyntaxError1(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		isTOML   bool
+		wantCode jsonlp.ErrorCode
+		wantLine int
+		wantCol  int
+	}{{
+		name:     "e1-1a",
+		s:        "{\n  a: 1,\n  b: 2\n",
+		wantCode: jsonlp.ErrorCode_ExpectObjectClose,
+		wantLine: 4,
+		wantCol:  1,
+	}, {
+		name:     "e1-1b",
+		s:        "[1, 2,\n  ;]",
+		wantCode: jsonlp.ErrorCode_ExpectArrayValue,
+		wantLine: 2,
+		wantCol:  3,
+	}, {
+		name:     "e1-1c",
+		s:        "{a: }",
+		wantCode: jsonlp.ErrorCode_ExpectValue,
+		wantLine: 1,
+		wantCol:  5,
+	}, {
+		name:     "e1-1d",
+		s:        "'abc",
+		wantCode: jsonlp.ErrorCode_UnterminatedString,
+		wantLine: 1,
+		wantCol:  5,
+	}, {
+		name:     "e1-1e",
+		s:        "[1] 2",
+		wantCode: jsonlp.ErrorCode_ExpectTermination,
+		wantLine: 1,
+		wantCol:  5,
+	}, {
+		name:     "e1-1f",
+		s:        "-0x10",
+		wantCode: jsonlp.ErrorCode_InvalidNumber,
+		wantLine: 1,
+		wantCol:  6,
+	}, {
+		name:     "e1-2a",
+		s:        "a = 1\n[b\nc = 2\n",
+		isTOML:   true,
+		wantCode: jsonlp.ErrorCode_ExpectTableClose,
+		wantLine: 2,
+		wantCol:  2,
+	}, {
+		name:     "e1-2b",
+		s:        "a = 1\n[[b]] x\n",
+		isTOML:   true,
+		wantCode: jsonlp.ErrorCode_ExpectLinebreak,
+		wantLine: 2,
+		wantCol:  7,
+	}, {
+		name:     "e1-2c",
+		s:        "a = 1\n/* b = 2\n",
+		isTOML:   true,
+		wantCode: jsonlp.ErrorCode_UnterminatedComment,
+		wantLine: 3,
+		wantCol:  1,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.isTOML {
+				_, err = jsonlp.ParseTOML(tt.s, jsonlp.Linebreak_Lf, jsonlp.Interop_None)
+			} else {
+				_, err = jsonlp.ParseJSON(tt.s, jsonlp.Linebreak_Lf, jsonlp.Interop_None)
+			}
+			var se *jsonlp.SyntaxError
+			if !errors.As(err, &se) {
+				t.Errorf("%v: error = %v, want *SyntaxError", tt.name, err)
+				return
+			}
+			if se.Code != tt.wantCode {
+				t.Errorf("%v: Code = %v, want %v", tt.name, se.Code, tt.wantCode)
+			}
+			if se.Line != tt.wantLine || se.Col != tt.wantCol {
+				t.Errorf("%v: Line, Col = %v, %v, want %v, %v", tt.name, se.Line, se.Col, tt.wantLine, tt.wantCol)
+			}
+			wantMsg := se.Message +
+				"\n --> Line " + strconv.Itoa(se.Line) +
+				", Col " + strconv.Itoa(se.Col) + "\n" +
+				se.Source
+			if se.Error() != wantMsg {
+				t.Errorf("%v: Error() = %q, want %q", tt.name, se.Error(), wantMsg)
+			}
+		})
+	}
+}
+
+func TestSyntaxError2(t *testing.T) {
+	_, err := jsonlp.ParseJSON("[99999999999999999999s64]", jsonlp.Linebreak_Lf, jsonlp.Interop_None)
+	var ne *strconv.NumError
+	if !errors.As(err, &ne) {
+		t.Errorf("error = %v, want *strconv.NumError", err)
+	}
+
+	var se *jsonlp.SyntaxError
+	if !errors.As(err, &se) {
+		t.Errorf("error = %v, want *SyntaxError", err)
+		return
+	}
+	if se.Code != jsonlp.ErrorCode_InvalidNumber {
+		t.Errorf("Code = %v, want %v", se.Code, jsonlp.ErrorCode_InvalidNumber)
+	}
+	if se.Offset != 24 {
+		t.Errorf("Offset = %v, want %v", se.Offset, 24)
+	}
+}
+
+func TestSyntaxError3(t *testing.T) {
+	_, err := jsonlp.ParseJSONWithOptions("[1, ;, 99999999999999999999s64]", &jsonlp.ParseOptions{
+		ErrorRecovery: true,
+	})
+	var errs jsonlp.SyntaxErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("error = %v, want 2 SyntaxErrors", err)
+		return
+	}
+
+	var ne *strconv.NumError
+	if !errors.As(err, &ne) {
+		t.Errorf("error = %v, want *strconv.NumError", err)
+	}
+	if !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("error = %v, want strconv.ErrRange", err)
+	}
+	if se := errs.First(); se.Code != jsonlp.ErrorCode_ExpectArrayValue {
+		t.Errorf("First().Code = %v, want %v", se.Code, jsonlp.ErrorCode_ExpectArrayValue)
+	}
+	if se := (jsonlp.SyntaxErrors{}).First(); se != nil {
+		t.Errorf("First() = %v, want nil", se)
+	}
+}
@@ -0,0 +1,673 @@
+package jsonlp
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Hand-written parser of the strict JSON subset.
+//
+// Loose constructs are parsed by the loose sub-parsers at the position where they appear:
+// values (e.g. single quoted strings, hex numbers), keys (e.g. identifiers, dotted keys) and whitespaces (comments).
+// If the array or the object has a loose structure (e.g. `[1,,]`), the loose sub-parser resumes
+// after the last element that the fast path parsed, and the elements are added to the partial container.
+// Errors are returned with the same position as the loose parser, without parsing the document again.
+// The loose sub-parsers are of the dialect, so the extensions that the dialect does not have are errors.
+type fastParser struct {
+	s      string
+	pos    int
+	opts   parseOptions
+	g      *jsonGrammar
+	err    error          // Error that stopped the fast path
+	errPos SourcePosition // Position of err
+}
+
+func newFastParser(s string, opts parseOptions) *fastParser {
+	opts.recovery = false
+	return &fastParser{s: s, opts: opts, g: jsonGrammarOf(opts)}
+}
+
+// Context of the loose sub-parsers and transformers at the current position.
+// It does not preallocate the AST stack, unlike strparser.NewStringParserContext.
+func (p *fastParser) context() ParserContext {
+	ctx := ParserContext{
+		Str: p.s,
+		Tag: p.opts,
+	}
+	ctx.Position = p.pos
+	return ctx
+}
+
+// Stop the fast path by the error.
+func (p *fastParser) fail(err error, pos SourcePosition) {
+	p.err = err
+	p.errPos = pos
+}
+
+// Parse the value at the current position by the loose sub-parser.
+func (p *fastParser) loose(fn ParserFn) (interface{}, string, bool) {
+	asts, ok := p.looseAsts(fn)
+	if !ok || len(asts) == 0 {
+		return nil, "", false
+	}
+	ast := asts[len(asts)-1]
+	return ast.Value, ast.ClassName, true
+}
+
+// Parse at the current position by the loose sub-parser, and return the ASTs.
+// If it does not match, p.err is nil.
+func (p *fastParser) looseAsts(fn ParserFn) (AstSlice, bool) {
+	ctx := p.context()
+	out, err := fn(ctx)
+	if err != nil {
+		p.fail(err, out.SourcePosition)
+		return nil, false
+	}
+	if out.MatchStatus != MatchStatus_Matched {
+		return nil, false
+	}
+	p.pos = out.Position
+	return out.AstStack, true
+}
+
+func (p *fastParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+// Skip whitespaces. Comments and the other whitespaces are skipped by the loose sub-parser.
+func (p *fastParser) ws() bool {
+	for p.pos < len(p.s) {
+		switch c := p.s[p.pos]; c {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		default:
+			if c == '/' || c == '#' || c == '\v' || c == '\f' || utf8.RuneSelf <= c {
+				if _, ok := p.looseAsts(p.g.sp); !ok {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return true
+}
+
+// Returns true if the value ends at the position.
+func (p *fastParser) atDelimiter(pos int) bool {
+	if len(p.s) <= pos {
+		return true
+	}
+	switch p.s[pos] {
+	case ' ', '\t', '\r', '\n', ',', ']', '}':
+		return true
+	}
+	return false
+}
+
+// Parse the document.
+// If it fails without p.err, the document does not start with a value.
+func (p *fastParser) document() (interface{}, bool) {
+	if !p.ws() {
+		return nil, false
+	}
+	v, _, ok := p.value()
+	if !ok || !p.ws() {
+		return nil, false
+	}
+	if p.pos != len(p.s) {
+		p.fail(newParseError(ErrorCode_ExpectTermination, "Expect terminatiion"), SourcePosition{Position: p.pos})
+		return nil, false
+	}
+	return v, true
+}
+
+// Parse the value.
+// If it fails without p.err, the value does not match, and the container resumes by the loose sub-parser.
+func (p *fastParser) value() (interface{}, string, bool) {
+	if err := p.opts.state.checkCancel(); err != nil {
+		p.fail(err, SourcePosition{Position: p.pos})
+		return nil, "", false
+	}
+
+	start := p.pos
+	switch p.peek() {
+	case '{':
+		return p.object()
+	case '[':
+		return p.array()
+	case '"':
+		if v, ok := p.str(); ok && p.atDelimiter(p.pos) {
+			return v, class.String, true
+		}
+	case 't':
+		if p.literal("true") {
+			return true, class.Bool, true
+		}
+	case 'f':
+		if p.literal("false") {
+			return false, class.Bool, true
+		}
+	case 'n':
+		if p.literal("null") {
+			return nil, class.Null, true
+		}
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		if v, cls, ok := p.number(); ok {
+			return v, cls, true
+		}
+	}
+	p.pos = start
+	return p.loose(p.g.value)
+}
+
+func (p *fastParser) literal(s string) bool {
+	if strings.HasPrefix(p.s[p.pos:], s) && p.atDelimiter(p.pos+len(s)) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func (p *fastParser) digits() int {
+	start := p.pos
+	for p.pos < len(p.s) && isDigit(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.pos - start
+}
+
+func (p *fastParser) number() (interface{}, string, bool) {
+	mode := p.opts.numberMode
+	if mode != Number_Float && mode != Number_Integer {
+		return nil, "", false
+	}
+
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	if p.peek() == '0' {
+		p.pos++
+	} else if p.digits() == 0 {
+		return nil, "", false
+	}
+	isFloat := false
+	if p.peek() == '.' {
+		p.pos++
+		if p.digits() == 0 {
+			return nil, "", false
+		}
+		isFloat = true
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		if p.digits() == 0 {
+			return nil, "", false
+		}
+		isFloat = true
+	}
+	if !p.atDelimiter(p.pos) {
+		return nil, "", false
+	}
+	// Complex number (e.g. `1 + 2i`)
+	for i := p.pos; i < len(p.s); i++ {
+		if c := p.s[i]; c != ' ' && c != '\t' {
+			if c == '+' || c == '-' {
+				return nil, "", false
+			}
+			break
+		}
+	}
+
+	lit := p.s[start:p.pos]
+	if mode == Number_Integer && !isFloat {
+		asts, err := parseInteger(lit, 10)
+		if err != nil {
+			return nil, "", false
+		}
+		return asts[0].Value, asts[0].ClassName, true
+	}
+	v, err := strconv.ParseFloat(lit, 64)
+	if err != nil {
+		return nil, "", false
+	}
+	return v, class.Float, true
+}
+
+func hexValue(c byte) rune {
+	switch {
+	case '0' <= c && c <= '9':
+		return rune(c - '0')
+	case 'a' <= c && c <= 'f':
+		return rune(c - 'a' + 10)
+	case 'A' <= c && c <= 'F':
+		return rune(c - 'A' + 10)
+	}
+	return -1
+}
+
+// Double quoted string without the loose escape sequences.
+func (p *fastParser) str() (string, bool) {
+	var sb strings.Builder
+	escaped := false
+	start := p.pos + 1
+	i := start
+	limit := p.opts.limits.stringLength
+
+	for i < len(p.s) {
+		if 0 < limit && limit < sb.Len()+i-start {
+			return "", false
+		}
+		switch c := p.s[i]; c {
+		case '"':
+			seg := p.s[start:i]
+			if !utf8.ValidString(seg) {
+				return "", false
+			}
+			var v string
+			if escaped {
+				sb.WriteString(seg)
+				v = sb.String()
+			} else {
+				v = seg
+			}
+			if 0 < limit && limit < len(v) {
+				return "", false
+			}
+			p.pos = i + 1
+			return v, true
+
+		case '\r', '\n':
+			return "", false
+
+		case '\\':
+			seg := p.s[start:i]
+			if !utf8.ValidString(seg) || len(p.s) <= i+1 {
+				return "", false
+			}
+			sb.WriteString(seg)
+			escaped = true
+
+			switch e := p.s[i+1]; e {
+			case '"', '\\', '/':
+				sb.WriteByte(e)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if len(p.s) < i+6 {
+					return "", false
+				}
+				var r rune
+				for _, h := range []byte(p.s[i+2 : i+6]) {
+					x := hexValue(h)
+					if x < 0 {
+						return "", false
+					}
+					r = r<<4 | x
+				}
+				i += 4
+				if 0xd800 <= r && r < 0xdc00 && i+8 <= len(p.s) && p.s[i+2] == '\\' && p.s[i+3] == 'u' {
+					// Surrogate pair (e.g. `\uD83D\uDE00`)
+					var lo rune
+					for _, h := range []byte(p.s[i+4 : i+8]) {
+						lo = lo<<4 | hexValue(h)
+					}
+					if 0xdc00 <= lo && lo < 0xe000 {
+						r = utf16.DecodeRune(r, lo)
+						i += 6
+					}
+				}
+				sb.WriteString(string(r))
+			default:
+				return "", false
+			}
+			i += 2
+			start = i
+
+		default:
+			if c < 0x20 && !p.g.features.has(Feature_ControlCharacter) {
+				return "", false
+			}
+			i++
+		}
+	}
+	return "", false
+}
+
+// Parse the array.
+// If it has a loose structure before the first element, parse it again by the loose sub-parser.
+func (p *fastParser) array() (interface{}, string, bool) {
+	start := p.pos
+	nodes := p.opts.state.nodes
+	v, ok, resume := p.arrayInner()
+	if resume {
+		p.pos = start
+		p.opts.state.nodes = nodes
+		return p.loose(p.g.list)
+	}
+	return v, class.Array, ok
+}
+
+func (p *fastParser) arrayInner() (interface{}, bool, bool) {
+	state := p.opts.state
+	limits := &p.opts.limits
+	start := p.pos
+
+	state.depth++
+	pending, elements := state.pending, state.elements
+	defer func() {
+		state.depth--
+		state.pending, state.elements = pending, elements
+	}()
+	if 0 < limits.depth && limits.depth < state.depth {
+		p.fail(newLimitError(ErrorCode_DepthLimitExceeded, "Too deeply nested", limits.depth, SourcePosition{Position: start}), SourcePosition{Position: start})
+		return nil, false, false
+	}
+
+	p.pos++
+	if !p.ws() {
+		return nil, false, false
+	}
+	v := make([]interface{}, 0)
+	tail := -1 // Position after the last element
+
+	closed := false
+	if p.peek() == ']' {
+		p.pos++
+		closed = true
+	}
+ELEMENTS:
+	for !closed {
+		switch p.peek() {
+		case ',', ']':
+			break ELEMENTS
+		}
+		elemStart := SourcePosition{Position: p.pos}
+		prevTail, prevPending, prevNodes := tail, state.pending, state.nodes
+		x, _, ok := p.value()
+		if !ok {
+			if p.err != nil {
+				return nil, false, false
+			}
+			break ELEMENTS
+		}
+		if !p.ws() {
+			return nil, false, false
+		}
+		v = append(v, x)
+
+		if err := limits.checkArrayLength(len(v), elemStart); err != nil {
+			p.fail(err, elemStart)
+			return nil, false, false
+		}
+		if err := limits.countPending(state, elemStart); err != nil {
+			p.fail(err, elemStart)
+			return nil, false, false
+		}
+		tail = p.pos
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+			if !p.ws() {
+				return nil, false, false
+			}
+			if p.peek() == ']' {
+				if !p.g.features.has(Feature_TrailingComma) {
+					break ELEMENTS
+				}
+				p.pos++
+				closed = true
+			}
+		case ']':
+			p.pos++
+			closed = true
+		default:
+			// The loose sub-parser may parse the element differently. (e.g. `1 /* c */ + 2i`)
+			v = v[:len(v)-1]
+			tail = prevTail
+			state.pending, state.nodes = prevPending, prevNodes
+			break ELEMENTS
+		}
+	}
+
+	if !closed {
+		if tail < 0 {
+			return nil, false, true
+		}
+		// Resume after the last element.
+		p.pos = tail
+		state.elements = len(v)
+		asts, ok := p.looseAsts(p.g.listTail)
+		if !ok {
+			return nil, false, false
+		}
+		for _, ast := range asts {
+			v = append(v, ast.Value)
+		}
+	}
+
+	state.pending = pending
+	if err := limits.countNodes(state, len(v), SourcePosition{Position: start}); err != nil {
+		p.fail(err, SourcePosition{Position: start})
+		return nil, false, false
+	}
+	return v, true, false
+}
+
+// Parse the object.
+// If it has a loose structure before the first key-value pair, parse it again by the loose sub-parser.
+func (p *fastParser) object() (interface{}, string, bool) {
+	start := p.pos
+	nodes := p.opts.state.nodes
+	v, ok, resume := p.objectInner()
+	if resume {
+		p.pos = start
+		p.opts.state.nodes = nodes
+		return p.loose(p.g.object)
+	}
+	return v, class.Object, ok
+}
+
+func (p *fastParser) key() (interface{}, bool) {
+	start := p.pos
+	if p.peek() == '"' {
+		if k, ok := p.str(); ok {
+			end := p.pos
+			// Dotted key (e.g. `"a"."b"`)
+			if p.ws() && p.peek() != '.' {
+				p.pos = end
+				return k, true
+			}
+		}
+		p.pos = start
+	}
+	k, _, ok := p.loose(p.g.key)
+	return k, ok
+}
+
+func (p *fastParser) objectInner() (interface{}, bool, bool) {
+	state := p.opts.state
+	limits := &p.opts.limits
+	start := p.pos
+
+	state.depth++
+	pending, elements := state.pending, state.elements
+	defer func() {
+		state.depth--
+		state.pending, state.elements = pending, elements
+	}()
+	if 0 < limits.depth && limits.depth < state.depth {
+		p.fail(newLimitError(ErrorCode_DepthLimitExceeded, "Too deeply nested", limits.depth, SourcePosition{Position: start}), SourcePosition{Position: start})
+		return nil, false, false
+	}
+
+	p.pos++
+	if !p.ws() {
+		return nil, false, false
+	}
+	asts := make(AstSlice, 0, 16)
+	tail := -1 // Position after the last key-value pair
+
+	closed := false
+	if p.peek() == '}' {
+		p.pos++
+		closed = true
+	}
+MEMBERS:
+	for !closed {
+		keyPos := p.pos
+		if c := p.peek(); c == ',' || c == '}' {
+			break MEMBERS
+		}
+		prevTail, prevPending, prevNodes := tail, state.pending, state.nodes
+		k, ok := p.key()
+		if !ok {
+			if p.err != nil {
+				return nil, false, false
+			}
+			break MEMBERS
+		}
+		if !p.ws() {
+			return nil, false, false
+		}
+
+		switch {
+		case p.peek() == ':':
+			p.pos++
+		case strings.HasPrefix(p.s[p.pos:], "=>") && p.g.features.has(Feature_ArrowSeparator):
+			p.pos += 2
+		case p.peek() == '=' && !strings.HasPrefix(p.s[p.pos:], "=>") && p.g.features.has(Feature_EqualSeparator):
+			p.pos++
+		default:
+			break MEMBERS
+		}
+		if !p.ws() {
+			return nil, false, false
+		}
+
+		x, cls, ok := p.value()
+		if !ok {
+			if p.err != nil {
+				return nil, false, false
+			}
+			break MEMBERS
+		}
+		if !p.ws() {
+			return nil, false, false
+		}
+		asts = append(asts,
+			Ast{Value: k, SourcePosition: SourcePosition{Position: keyPos}},
+			Ast{ClassName: cls, Type: AstType_Any, Value: x},
+		)
+		if err := limits.countPending(state, SourcePosition{Position: keyPos}); err != nil {
+			p.fail(err, SourcePosition{Position: keyPos})
+			return nil, false, false
+		}
+		tail = p.pos
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+			if !p.ws() {
+				return nil, false, false
+			}
+			if p.peek() == '}' {
+				if !p.g.features.has(Feature_TrailingComma) {
+					break MEMBERS
+				}
+				p.pos++
+				closed = true
+			}
+		case '}':
+			p.pos++
+			closed = true
+		default:
+			// The loose sub-parser may parse the value differently.
+			asts = asts[:len(asts)-2]
+			tail = prevTail
+			state.pending, state.nodes = prevPending, prevNodes
+			break MEMBERS
+		}
+	}
+
+	if closed {
+		if !p.ws() {
+			return nil, false, false
+		}
+	} else {
+		if tail < 0 {
+			return nil, false, true
+		}
+		// Resume after the last key-value pair.
+		p.pos = tail
+		state.elements = len(asts) / 2
+		rest, ok := p.looseAsts(p.g.objectTail)
+		if !ok {
+			return nil, false, false
+		}
+		asts = append(asts, rest...)
+	}
+
+	// The transformer is called at the start of the object, as the loose parser does.
+	state.pending = pending
+	ctx := p.context()
+	ctx.Position = start
+	out, err := tableTransformer(ctx, asts)
+	if err != nil {
+		p.fail(err, SourcePosition{Position: p.pos})
+		return nil, false, false
+	}
+	return out[0].Value, true, false
+}
+
+// Parse the loose JSON.
+// The fast path is tried first, and the loose parser is used if it fails.
+func parseJSON(s string, opts parseOptions) (interface{}, error) {
+	if !opts.fastPath {
+		return parse(jsonGrammarOf(opts).document, s, opts)
+	}
+	if err := opts.limits.checkInputSize(s); err != nil {
+		return nil, err
+	}
+	if opts.state.ctx != nil {
+		if err := opts.state.ctx.Err(); err != nil {
+			return nil, newSyntaxError(s, SourcePosition{}, err)
+		}
+	}
+
+	p := newFastParser(s, opts)
+	if v, ok := p.document(); ok {
+		return v, nil
+	}
+	if p.err != nil && (!opts.recovery || isContextError(p.err)) {
+		return nil, newSyntaxError(s, p.errPos, p.err)
+	}
+
+	// The document does not start with a value, or the error recovery collects all errors of the document.
+	opts.state = &parseState{ctx: opts.state.ctx}
+	return parse(jsonGrammarOf(opts).document, s, opts)
+}
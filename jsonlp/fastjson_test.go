@@ -0,0 +1,144 @@
+package jsonlp_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func TestFastPath1(t *testing.T) {
+	docs := []string{
+		`null`,
+		` true `,
+		`"abc"`,
+		`[]`,
+		`{}`,
+		`[1, -2.5, 3e10, -0, 0.5E-3, 12345678901234567890, 9223372036854775808, -9223372036854775809]`,
+		`{"a": [true, false, null], "b": {"c": "d\"e\\f\/g\b\f\n\r\tAあ😀"}}`,
+		"{\n  \"a\": 1,\n  \"b\": [1, 2, 3],\n  \"c\": {\"d\": {\"e\": []}}\n}\n",
+		`{"a": 1, "a": 2}`,
+		`{"a": {"b": 1}, "a": {"c": 2}}`,
+		`{"a": 1, "b": 2,}`,
+		`[1, 2,]`,
+		`[,]`,
+		`{,}`,
+		`[1,,]`,
+		`[1 2]`,
+		`{"a" 1}`,
+		`{"a": 1 "b": 2}`,
+		`{a: 1, 'b': 2, "c" = 3, "d" => 4}`,
+		`{"a"."b": 1, "a" . "c": 2}`,
+		`{"a": 0x12, "b": 1_000, "c": 123n, "d": 1s64, "e": 1.5, "f": .5, "g": 01}`,
+		`[1 + 2i, 1+2i, 1 /* c */ + 2i, Infinity, -Infinity, NaN]`,
+		`[2020-01-02, 12:34:56, 2020-01-02T12:34:56Z]`,
+		`["a\u{41}", "\x41", "\101", 'b', ` + "`c`" + `]`,
+		"[1, // comment\n 2 # comment\n, /* c */ 3]",
+		"[1, 2]",
+		"[\"a\nb\"]",
+		"\"\xff\"",
+		`["""a"""]`,
+		`[TRUE, Null, undefined, None, truex]`,
+		`[1x]`,
+		`{"a": [1, {"b": [2, {"c": 3}]}]}`,
+		`[1] 2`,
+		`[1`,
+		`{"a": `,
+		`"abc`,
+		`/* unterminated`,
+		`{"a": 1e400}`,
+		`[-]`,
+		`[1.]`,
+		`{"a": "b"}` + " // tail",
+		`{"` + strings.Repeat("x", 10) + `": "` + strings.Repeat("y", 10) + `"}`,
+		`{"a": [1, 2, {"b": 'c'}], "c": [1, 2,, 3]}`,
+		`{"a": 1, "b": [1, 2, [3, 4 5]]}`,
+		`{"a": {"b": 1, "c" 2}, "d": 3}`,
+		`{"a": 1, "b": 2, "a": 3, c: 4}`,
+		`[1, 2, 3 /* c */ + 4i, 5]`,
+		`[1, "a" "b"]`,
+		`[1, 2, 3, 4, @]`,
+		`[[1, 2], [3, 4], [5, 6], 7]`,
+		`{"a": [[[1]]], "b": 2}`,
+		`{"a": 1, "b": 2, "c": 3, 'd': 4}`,
+		`[1, 2, 3, 4, 'a']`,
+		`[{"a": 1, "b": 2}, {"a": 1, "b": 2, "c": 3}]`,
+		"[1, 2 /* c */ , 3] // tail\n",
+	}
+	optsList := []jsonlp.ParseOptions{
+		{},
+		{NumberMode: jsonlp.Number_Integer},
+		{NumberMode: jsonlp.Number_Lossless},
+		{NumberMode: jsonlp.Number_BigFloat},
+		{OrderedMap: true},
+		{Interop: jsonlp.Interop_JSON},
+		{Strictness: jsonlp.Strictness_NoRedefinition},
+		{ErrorRecovery: true},
+		{MaxDepth: 2, MaxStringLength: 5, MaxArrayLength: 3, MaxObjectMembers: 2, MaxNodes: 6},
+	}
+
+	for i, opts := range optsList {
+		for j, doc := range docs {
+			t.Run(fmt.Sprintf("fast1-%d-%d", i, j), func(t *testing.T) {
+				fastOpts := opts
+				looseOpts := opts
+				looseOpts.DisableFastPath = true
+
+				want, wantErr := jsonlp.ParseJSONWithOptions(doc, &looseOpts)
+				got, err := jsonlp.ParseJSONWithOptions(doc, &fastOpts)
+
+				if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+					t.Errorf("%q: error = %v, want %v", doc, err, wantErr)
+					return
+				}
+				var se, wantSe *jsonlp.SyntaxError
+				if errors.As(err, &se) != errors.As(wantErr, &wantSe) || (se != nil && se.Code != wantSe.Code) {
+					t.Errorf("%q: error = %#v, want %#v", doc, se, wantSe)
+					return
+				}
+				if !deepEqualNaN(got, want) || reflect.TypeOf(got) != reflect.TypeOf(want) {
+					t.Errorf("%q: got = %#v, want %#v", doc, got, want)
+				}
+			})
+		}
+	}
+}
+
+func benchmarkDocument() string {
+	var sb strings.Builder
+	sb.WriteString("[\n")
+	for i := 0; i < 1000; i++ {
+		if i != 0 {
+			sb.WriteString(",\n")
+		}
+		fmt.Fprintf(&sb, `  {"id": %d, "name": "item %d", "price": %d.25, "tags": ["a", "b\n"], "active": true, "parent": null}`, i, i, i)
+	}
+	sb.WriteString("\n]\n")
+	return sb.String()
+}
+
+func BenchmarkParseJSON_FastPath(b *testing.B) {
+	src := benchmarkDocument()
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := jsonlp.ParseJSONWithOptions(src, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseJSON_Loose(b *testing.B) {
+	src := benchmarkDocument()
+	opts := &jsonlp.ParseOptions{DisableFastPath: true}
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := jsonlp.ParseJSONWithOptions(src, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
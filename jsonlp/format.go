@@ -0,0 +1,636 @@
+package jsonlp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+type FormatStyle int
+
+const (
+	Style_JSON FormatStyle = iota
+	Style_JSON5
+	Style_Loose
+)
+
+type QuoteStyle int
+
+const (
+	Quote_Double QuoteStyle = iota
+	Quote_Single
+)
+
+// Options of the formatter.
+//
+// The zero value is the default options. (compact, strict JSON)
+// New fields may be added in the future without breaking changes.
+type FormatOptions struct {
+	// Output style. (`Style_JSON` | `Style_JSON5` | `Style_Loose`)
+	// Style_JSON writes strict JSON (RFC 8259).
+	// Style_JSON5 writes JSON5. Identifier keys are not quoted, and `NaN`, `Infinity` are written as is.
+	// Style_Loose writes the loose JSON of this package. The types of the values are preserved
+	// by the type suffixes (`s64`, `u64`, `n`), complex number literals and date-time literals.
+	Style FormatStyle
+
+	// Indentation string. (e.g. "  ", "\t")
+	// If empty, the output is compact.
+	Indent string
+
+	// If true, a comma is written after the last element of arrays and objects.
+	// It is ignored in Style_JSON and in the compact output.
+	TrailingComma bool
+
+	// Quotation mark of strings and keys. (`Quote_Double` | `Quote_Single`)
+	// It is ignored in Style_JSON.
+	Quote QuoteStyle
+
+	// If true, non-negative integers are written in hexadecimal. (e.g. `0xff`)
+	// It is ignored in Style_JSON.
+	Hex bool
+
+	// Substitution of the values that cannot be represented in the output style.
+	// If Interop_JSON is set, replace NaN, Infinity, complex number by `{nan:true}`, `{inf:+/-1}`, `{re:re,im:im}`.
+	// If Interop_TOML is set, replace complex number by `{re:re,im:im}`.
+	// If Interop_JSON_AsNull is set, replace NaN, Infinity, complex number by null.
+	// If Interop_TOML_AsNull is set, replace complex number by null.
+	// Otherwise, these values are errors.
+	// It is ignored in Style_Loose.
+	Interop InteropType
+
+	// Depth of the tables that are written as inline tables. (FormatTOML only)
+	// The root table is depth 0. If 2 is set, the tables in the root table are written as
+	// `[table]` sections, and the deeper tables are written as inline tables.
+	// If zero, all tables are written as sections.
+	// The arrays of tables are written as `[[array-of-tables]]` sections at any depth.
+	InlineTableDepth int
+}
+
+var formatOptsDefault = FormatOptions{}
+
+type jsonFormatter struct {
+	opts  *FormatOptions
+	sb    strings.Builder
+	depth int
+}
+
+// v:
+// nil | []any | map[string]any | float64 | int64 | uint64 | complex128 | string | bool | time.Time |
+// LocalDate | LocalTime | LocalDateTime |
+// Number | *big.Int | *big.Float | *big.Rat | *OrderedMap | []*OrderedMap | []map[string]any
+// Other slices, arrays, maps with string keys, and pointers to them are also accepted.
+// []byte is written as a base64 string. Keys of the maps are sorted.
+//
+// opts:
+// Pointer to struct of the formatter options. If nil, use default.
+func FormatJSON(v interface{}, opts *FormatOptions) (string, error) {
+	if opts == nil {
+		opts = &formatOptsDefault
+	}
+	f := &jsonFormatter{opts: opts}
+	if err := f.value(v); err != nil {
+		return "", err
+	}
+	return f.sb.String(), nil
+}
+
+func (f *jsonFormatter) value(v interface{}) error {
+	switch x := v.(type) {
+	case nil:
+		f.sb.WriteString("null")
+	case bool:
+		f.sb.WriteString(strconv.FormatBool(x))
+	case string:
+		f.str(x)
+	case int64:
+		f.int(x)
+	case uint64:
+		f.uint(x)
+	case float64:
+		return f.float(x, 64)
+	case complex128:
+		return f.complex(x, 64)
+	case time.Time:
+		f.time(x)
+	case LocalDate:
+		f.localTime(x.String())
+	case LocalTime:
+		f.localTime(x.String())
+	case LocalDateTime:
+		f.localTime(x.String())
+	case Number:
+		return f.number(x)
+	case *big.Int:
+		if x == nil {
+			f.sb.WriteString("null")
+			break
+		}
+		f.sb.WriteString(x.String())
+		if f.opts.Style == Style_Loose {
+			f.sb.WriteByte('n')
+		}
+	case *big.Float:
+		if x == nil {
+			f.sb.WriteString("null")
+			break
+		}
+		return f.bigFloat(x)
+	case *big.Rat:
+		if x == nil {
+			f.sb.WriteString("null")
+			break
+		}
+		prec := uint(64)
+		if n := uint(x.Num().BitLen() + x.Denom().BitLen()); n > prec {
+			prec = n
+		}
+		return f.bigFloat(new(big.Float).SetPrec(prec).SetRat(x))
+	case []byte:
+		if x == nil {
+			f.sb.WriteString("null")
+			break
+		}
+		f.str(base64.StdEncoding.EncodeToString(x))
+	case []interface{}:
+		return f.list(len(x), func(i int) error {
+			return f.value(x[i])
+		})
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return f.object(keys, func(i int) error {
+			return f.value(x[keys[i]])
+		})
+	case []map[string]interface{}:
+		return f.list(len(x), func(i int) error {
+			return f.value(x[i])
+		})
+	case *OrderedMap:
+		if x == nil {
+			f.sb.WriteString("null")
+			break
+		}
+		keys := x.Keys()
+		return f.object(keys, func(i int) error {
+			v, _ := x.Get(keys[i])
+			return f.value(v)
+		})
+	case []*OrderedMap:
+		return f.list(len(x), func(i int) error {
+			return f.value(x[i])
+		})
+	default:
+		return f.reflectValue(reflect.ValueOf(v))
+	}
+	return nil
+}
+
+func (f *jsonFormatter) reflectValue(rv reflect.Value) error {
+	switch rv.Kind() {
+	case reflect.Bool:
+		f.sb.WriteString(strconv.FormatBool(rv.Bool()))
+	case reflect.String:
+		f.str(rv.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.int(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		f.uint(rv.Uint())
+	case reflect.Float32:
+		return f.float(rv.Float(), 32)
+	case reflect.Float64:
+		return f.float(rv.Float(), 64)
+	case reflect.Complex64:
+		return f.complex(rv.Complex(), 32)
+	case reflect.Complex128:
+		return f.complex(rv.Complex(), 64)
+	case reflect.Slice:
+		if rv.IsNil() {
+			f.sb.WriteString("null")
+			break
+		}
+		return f.list(rv.Len(), func(i int) error {
+			return f.value(rv.Index(i).Interface())
+		})
+	case reflect.Array:
+		return f.list(rv.Len(), func(i int) error {
+			return f.value(rv.Index(i).Interface())
+		})
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("Unsupported type: %v (Map key type should be string)", rv.Type())
+		}
+		if rv.IsNil() {
+			f.sb.WriteString("null")
+			break
+		}
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]reflect.Value, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			keys = append(keys, k)
+			values[k] = iter.Value()
+		}
+		sort.Strings(keys)
+		return f.object(keys, func(i int) error {
+			return f.value(values[keys[i]].Interface())
+		})
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			f.sb.WriteString("null")
+			break
+		}
+		return f.value(rv.Elem().Interface())
+	case reflect.Invalid:
+		f.sb.WriteString("null")
+	default:
+		return fmt.Errorf("Unsupported type: %v", rv.Type())
+	}
+	return nil
+}
+
+func (f *jsonFormatter) newline() {
+	if f.opts.Indent == "" {
+		return
+	}
+	f.sb.WriteByte('\n')
+	for i := 0; i < f.depth; i++ {
+		f.sb.WriteString(f.opts.Indent)
+	}
+}
+
+func (f *jsonFormatter) list(n int, elem func(i int) error) error {
+	if n == 0 {
+		f.sb.WriteString("[]")
+		return nil
+	}
+	f.sb.WriteByte('[')
+	f.depth++
+	for i := 0; i < n; i++ {
+		if i != 0 {
+			f.sb.WriteByte(',')
+		}
+		f.newline()
+		if err := elem(i); err != nil {
+			return err
+		}
+	}
+	f.closing(']')
+	return nil
+}
+
+func (f *jsonFormatter) object(keys []string, member func(i int) error) error {
+	if len(keys) == 0 {
+		f.sb.WriteString("{}")
+		return nil
+	}
+	f.sb.WriteByte('{')
+	f.depth++
+	for i, k := range keys {
+		if i != 0 {
+			f.sb.WriteByte(',')
+		}
+		f.newline()
+		f.key(k)
+		f.sb.WriteByte(':')
+		if f.opts.Indent != "" {
+			f.sb.WriteByte(' ')
+		}
+		if err := member(i); err != nil {
+			return err
+		}
+	}
+	f.closing('}')
+	return nil
+}
+
+func (f *jsonFormatter) closing(c byte) {
+	if f.opts.TrailingComma && f.opts.Indent != "" && f.opts.Style != Style_JSON {
+		f.sb.WriteByte(',')
+	}
+	f.depth--
+	f.newline()
+	f.sb.WriteByte(c)
+}
+
+func (f *jsonFormatter) key(k string) {
+	switch f.opts.Style {
+	case Style_JSON5:
+		if isECMAScriptIdentifierName(k) {
+			f.sb.WriteString(k)
+			return
+		}
+	case Style_Loose:
+		if isIdentifier(k) {
+			f.sb.WriteString(k)
+			return
+		}
+	}
+	f.str(k)
+}
+
+// IdentifierName of ECMAScript. Reserved words are also accepted. (JSON5 allows them as keys)
+func isECMAScriptIdentifierName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '$' || c == '_':
+		case unicode.Is(unicode.L, c) || unicode.Is(unicode.Nl, c) || unicode.Is(unicode.Other_ID_Start, c):
+		case i != 0 && (unicode.Is(unicode.Mn, c) ||
+			unicode.Is(unicode.Mc, c) ||
+			unicode.Is(unicode.Nd, c) ||
+			unicode.Is(unicode.Pc, c) ||
+			unicode.Is(unicode.Other_ID_Continue, c) ||
+			c == 0x0200c || c == 0x0200d):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (f *jsonFormatter) str(s string) {
+	q := byte('"')
+	if f.opts.Quote == Quote_Single && f.opts.Style != Style_JSON {
+		q = '\''
+	}
+	f.sb.WriteByte(q)
+	writeEscapedString(&f.sb, s, rune(q))
+	f.sb.WriteByte(q)
+}
+
+// Write the string escaping the quotation mark, backslash and control characters.
+// Invalid UTF-8 sequences are replaced by U+FFFD.
+func writeEscapedString(sb *strings.Builder, s string, q rune) {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case c == q || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(c)
+		case c == '\n':
+			sb.WriteString("\\n")
+		case c == '\r':
+			sb.WriteString("\\r")
+		case c == '\t':
+			sb.WriteString("\\t")
+		case c == '\b':
+			sb.WriteString("\\b")
+		case c == '\f':
+			sb.WriteString("\\f")
+		case c < 0x20 || c == 0x7f:
+			sb.WriteString("\\u00")
+			sb.WriteByte(hex[c>>4])
+			sb.WriteByte(hex[c&0xf])
+		case c == 0x2028 || c == 0x2029:
+			// Line terminators in ECMAScript
+			sb.WriteString("\\u202")
+			sb.WriteByte(hex[c&0xf])
+		default:
+			// Invalid sequences are decoded as utf8.RuneError
+			sb.WriteRune(c)
+		}
+	}
+}
+
+func (f *jsonFormatter) int(v int64) {
+	if f.opts.Hex && v >= 0 && f.opts.Style != Style_JSON {
+		f.sb.WriteString("0x")
+		f.sb.WriteString(strconv.FormatInt(v, 16))
+	} else {
+		f.sb.WriteString(strconv.FormatInt(v, 10))
+	}
+	if f.opts.Style == Style_Loose {
+		f.sb.WriteString("s64")
+	}
+}
+
+func (f *jsonFormatter) uint(v uint64) {
+	if f.opts.Hex && f.opts.Style != Style_JSON {
+		f.sb.WriteString("0x")
+		f.sb.WriteString(strconv.FormatUint(v, 16))
+	} else {
+		f.sb.WriteString(strconv.FormatUint(v, 10))
+	}
+	if f.opts.Style == Style_Loose {
+		f.sb.WriteString("u64")
+	}
+}
+
+func (f *jsonFormatter) float(v float64, bitSize int) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		if f.opts.Style != Style_JSON {
+			switch {
+			case math.IsNaN(v):
+				f.sb.WriteString("NaN")
+			case v > 0:
+				f.sb.WriteString("Infinity")
+			default:
+				f.sb.WriteString("-Infinity")
+			}
+			return nil
+		}
+
+		switch f.opts.Interop {
+		case Interop_JSON:
+			m := NewOrderedMap()
+			if math.IsNaN(v) {
+				m.Set("nan", true)
+			} else if v > 0 {
+				m.Set("inf", float64(1))
+			} else {
+				m.Set("inf", float64(-1))
+			}
+			return f.value(m)
+		case Interop_JSON_AsNull:
+			f.sb.WriteString("null")
+			return nil
+		default:
+			return fmt.Errorf("Unsupported value: %v (Set FormatOptions.Interop to Interop_JSON or Interop_JSON_AsNull)", v)
+		}
+	}
+
+	s := formatFloat(v, bitSize)
+	f.sb.WriteString(s)
+	if f.opts.Style == Style_Loose && !strings.ContainsAny(s, ".e") {
+		// Keep the type in the Number_Integer mode.
+		f.sb.WriteString(".0")
+	}
+	return nil
+}
+
+// Format the finite float in the same way as encoding/json.
+func formatFloat(v float64, bitSize int) string {
+	abs := math.Abs(v)
+	fmtc := byte('f')
+	if abs != 0 {
+		if bitSize == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bitSize == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			fmtc = 'e'
+		}
+	}
+	s := strconv.FormatFloat(v, fmtc, -1, bitSize)
+	if fmtc == 'e' {
+		// clean up e-09 to e-9
+		n := len(s)
+		if n >= 4 && s[n-4] == 'e' && s[n-3] == '-' && s[n-2] == '0' {
+			s = s[:n-2] + s[n-1:]
+		}
+	}
+	return s
+}
+
+func (f *jsonFormatter) complex(v complex128, bitSize int) error {
+	if f.opts.Style == Style_Loose {
+		re, im := real(v), imag(v)
+		if err := f.complexPart(re, bitSize); err != nil {
+			return err
+		}
+		if im < 0 || math.IsInf(im, -1) || (im == 0 && math.Signbit(im)) {
+			f.sb.WriteByte('-')
+			im = -im
+		} else {
+			f.sb.WriteByte('+')
+		}
+		if err := f.complexPart(im, bitSize); err != nil {
+			return err
+		}
+		f.sb.WriteByte('i')
+		return nil
+	}
+
+	switch f.opts.Interop {
+	case Interop_JSON, Interop_TOML:
+		m := NewOrderedMap()
+		m.Set("re", real(v))
+		m.Set("im", imag(v))
+		return f.value(m)
+	case Interop_JSON_AsNull, Interop_TOML_AsNull:
+		f.sb.WriteString("null")
+		return nil
+	default:
+		return fmt.Errorf("Unsupported value: %v (Set FormatOptions.Interop to replace complex numbers)", v)
+	}
+}
+
+func (f *jsonFormatter) complexPart(v float64, bitSize int) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return f.float(v, bitSize)
+	}
+	f.sb.WriteString(formatFloat(v, bitSize))
+	return nil
+}
+
+func (f *jsonFormatter) time(v time.Time) {
+	s := v.Format(time.RFC3339Nano)
+	if f.opts.Style == Style_Loose {
+		f.sb.WriteString(s)
+	} else {
+		f.str(s)
+	}
+}
+
+// LocalDate, LocalTime and LocalDateTime.
+func (f *jsonFormatter) localTime(s string) {
+	if f.opts.Style == Style_Loose {
+		f.sb.WriteString(s)
+	} else {
+		f.str(s)
+	}
+}
+
+func (f *jsonFormatter) number(v Number) error {
+	s := string(v)
+	switch {
+	case f.opts.Style == Style_Loose:
+	case isJSONNumber(s):
+	case f.opts.Style == Style_JSON5 && isRadixInteger(s) &&
+		(strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")):
+	case isRadixInteger(s):
+		z, err := v.BigInt()
+		if err != nil {
+			return err
+		}
+		s = z.String()
+	default:
+		x, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		return f.float(x, 64)
+	}
+	f.sb.WriteString(s)
+	return nil
+}
+
+// Returns true if s is a number literal of RFC 8259.
+func isJSONNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && '1' <= s[i] && s[i] <= '9':
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+	default:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		start := i
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		start := i
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+func (f *jsonFormatter) bigFloat(v *big.Float) error {
+	if v.IsInf() {
+		if v.Sign() > 0 {
+			return f.float(math.Inf(1), 64)
+		}
+		return f.float(math.Inf(-1), 64)
+	}
+	s := v.Text('g', -1)
+	f.sb.WriteString(s)
+	if f.opts.Style == Style_Loose && !strings.ContainsAny(s, ".e") {
+		f.sb.WriteString(".0")
+	}
+	return nil
+}
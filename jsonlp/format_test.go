@@ -0,0 +1,452 @@
+package jsonlp_test
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func TestFormatJSON1(t *testing.T) {
+	om := jsonlp.NewOrderedMap()
+	om.Set("z", int64(1))
+	om.Set("a", "x")
+
+	tests := []struct {
+		name    string
+		v       interface{}
+		opts    *jsonlp.FormatOptions
+		want    string
+		wantErr bool
+	}{{
+		name: "fmt1-1a",
+		v: map[string]interface{}{
+			"b": []interface{}{nil, true, false, "a\"b\\c\n\x01"},
+			"a": float64(1.5),
+			"c": map[string]interface{}{},
+			"d": []interface{}{},
+		},
+		opts: nil,
+		want: `{"a":1.5,"b":[null,true,false,"a\"b\\c\n\u0001"],"c":{},"d":[]}`,
+	}, {
+		name: "fmt1-1b",
+		v:    []interface{}{int64(-1), uint64(math.MaxUint64), float64(1e21), float64(1e-7), float64(100)},
+		opts: nil,
+		want: `[-1,18446744073709551615,1e+21,1e-7,100]`,
+	}, {
+		name: "fmt1-1c",
+		v:    om,
+		opts: nil,
+		want: `{"z":1,"a":"x"}`,
+	}, {
+		name: "fmt1-1d",
+		v:    time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC),
+		opts: nil,
+		want: `"2020-01-02T03:04:05.006Z"`,
+	}, {
+		name: "fmt1-1e",
+		v:    []interface{}{jsonlp.Number("1.50"), jsonlp.Number("0x1f"), jsonlp.Number("1."), big.NewInt(123), big.NewRat(1, 4)},
+		opts: nil,
+		want: `[1.50,31,1,123,0.25]`,
+	}, {
+		name:    "fmt1-1f",
+		v:       struct{}{},
+		opts:    nil,
+		wantErr: true,
+	}, {
+		name: "fmt1-2a",
+		v:    []interface{}{math.NaN(), math.Inf(1), math.Inf(-1), complex(1, -2)},
+		opts: &jsonlp.FormatOptions{Interop: jsonlp.Interop_JSON},
+		want: `[{"nan":true},{"inf":1},{"inf":-1},{"re":1,"im":-2}]`,
+	}, {
+		name: "fmt1-2b",
+		v:    []interface{}{math.NaN(), math.Inf(1), complex(1, -2)},
+		opts: &jsonlp.FormatOptions{Interop: jsonlp.Interop_JSON_AsNull},
+		want: `[null,null,null]`,
+	}, {
+		name:    "fmt1-2c",
+		v:       []interface{}{math.NaN()},
+		opts:    &jsonlp.FormatOptions{Interop: jsonlp.Interop_TOML},
+		wantErr: true,
+	}, {
+		name:    "fmt1-2d",
+		v:       []interface{}{complex(1, 2)},
+		opts:    nil,
+		wantErr: true,
+	}, {
+		name: "fmt1-3a",
+		v: map[string]interface{}{
+			"a": []interface{}{float64(1), map[string]interface{}{"b": nil}},
+			"c": map[string]interface{}{},
+		},
+		opts: &jsonlp.FormatOptions{Indent: "  ", TrailingComma: true},
+		want: "{\n  \"a\": [\n    1,\n    {\n      \"b\": null\n    }\n  ],\n  \"c\": {}\n}",
+	}, {
+		name: "fmt1-4a",
+		v: map[string]interface{}{
+			"a":   []interface{}{float64(1), math.NaN(), math.Inf(-1)},
+			"$_b": "it's",
+			"1c":  int64(255),
+			"d-e": uint64(16),
+		},
+		opts: &jsonlp.FormatOptions{Style: jsonlp.Style_JSON5, Indent: "\t", TrailingComma: true, Quote: jsonlp.Quote_Single, Hex: true},
+		want: "{\n\t$_b: 'it\\'s',\n\t'1c': 0xff,\n\ta: [\n\t\t1,\n\t\tNaN,\n\t\t-Infinity,\n\t],\n\t'd-e': 0x10,\n}",
+	}, {
+		name: "fmt1-5a",
+		v: map[string]interface{}{
+			"a":    int64(-1),
+			"b-c":  uint64(2),
+			"d e":  float64(3),
+			"f":    complex(1, -2),
+			"g":    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			"h":    big.NewInt(4),
+			"true": math.Inf(1),
+		},
+		opts: &jsonlp.FormatOptions{Style: jsonlp.Style_Loose},
+		want: `{a:-1s64,b-c:2u64,"d e":3.0,f:1-2i,g:2020-01-02T03:04:05Z,h:4n,true:Infinity}`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonlp.FormatJSON(tt.v, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FormatJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FormatJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatJSON2(t *testing.T) {
+	// Round-trip
+	tests := []struct {
+		name string
+		s    string
+	}{{
+		name: "fmt2-1a",
+		s:    `{a: 1, b: [true, null, 'x\ty'], c: {"d e": -1.5e-10}}`,
+	}, {
+		name: "fmt2-1b",
+		s:    `[1s64, 18446744073709551615u64, 123n, 1+2i, -1.5-Infinityi, NaN, -Infinity, 2020-01-02T03:04:05.123+09:00]`,
+	}, {
+		name: "fmt2-1c",
+		s:    `{" \u0000": "\x7f\u{1F600}", "": 'a"b'}`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := jsonlp.ParseJSONWithOptions(tt.s, nil)
+			if err != nil {
+				t.Errorf("ParseJSONWithOptions() error = %v", err)
+				return
+			}
+			for _, opts := range []*jsonlp.FormatOptions{
+				{Style: jsonlp.Style_Loose},
+				{Style: jsonlp.Style_Loose, Indent: "  ", TrailingComma: true, Quote: jsonlp.Quote_Single, Hex: true},
+			} {
+				s, err := jsonlp.FormatJSON(want, opts)
+				if err != nil {
+					t.Errorf("FormatJSON() error = %v", err)
+					return
+				}
+				got, err := jsonlp.ParseJSONWithOptions(s, nil)
+				if err != nil {
+					t.Errorf("ParseJSONWithOptions() error = %v\n%v", err, s)
+					return
+				}
+				if !deepEqualNaN(got, want) {
+					t.Errorf("round-trip = %v, want %v\n%v", got, want, s)
+				}
+			}
+		})
+	}
+}
+
+// reflect.DeepEqual that treats NaN as equal to NaN.
+func deepEqualNaN(a, b interface{}) bool {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok && math.IsNaN(x) && math.IsNaN(y) {
+			return true
+		}
+	case complex128:
+		if y, ok := b.(complex128); ok {
+			return deepEqualNaN(real(x), real(y)) && deepEqualNaN(imag(x), imag(y))
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Equal(y)
+		}
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !deepEqualNaN(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k := range x {
+			if !deepEqualNaN(x[k], y[k]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func TestFormatTOML1(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		opts    *jsonlp.FormatOptions
+		want    string
+		wantErr bool
+	}{{
+		name: "fmtt1-1a",
+		v: map[string]interface{}{
+			"a": float64(1),
+			"b": map[string]interface{}{
+				"c":   "x\ny",
+				"d e": []interface{}{int64(1), uint64(2)},
+				"f": map[string]interface{}{
+					"g": nil,
+				},
+			},
+			"h": []map[string]interface{}{{"i": true}, {}},
+			"j": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+			"k": time.Date(1970, 1, 1, 3, 4, 5, 6000000, time.UTC),
+			"l": time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			"m": math.Inf(-1),
+		},
+		opts: nil,
+		want: "a = 1.0\nj = 2020-01-02T00:00:00Z\nk = 1970-01-01T03:04:05.006Z\nl = 2020-01-02T03:04:05Z\nm = -inf\n" +
+			"\n[b]\nc = \"\"\"\nx\ny\"\"\"\n\"d e\" = [1, 2]\n" +
+			"\n[b.f]\n" +
+			"\n[[h]]\ni = true\n" +
+			"\n[[h]]\n",
+	}, {
+		name: "fmtt1-1b",
+		v: map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": map[string]interface{}{"c": float64(1.5)},
+				"d": []interface{}{map[string]interface{}{"e": "f"}},
+			},
+		},
+		opts: &jsonlp.FormatOptions{InlineTableDepth: 2},
+		want: "[a]\nb = { c = 1.5 }\nd = [{ e = \"f\" }]\n",
+	}, {
+		name: "fmtt1-1d",
+		// Arrays of tables are written as sections at any depth, except in the inline tables.
+		v: map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": []map[string]interface{}{{"c": int64(1)}},
+				"d": map[string]interface{}{
+					"e": []map[string]interface{}{{"f": int64(2)}},
+				},
+			},
+		},
+		opts: &jsonlp.FormatOptions{InlineTableDepth: 2},
+		want: "[a]\nd = { e = [{ f = 2 }] }\n" +
+			"\n[[a.b]]\nc = 1\n",
+	}, {
+		name: "fmtt1-1e",
+		v:    map[string]interface{}{},
+		opts: nil,
+		want: "",
+	}, {
+		name: "fmtt1-1c",
+		v: map[string]interface{}{
+			"a": []interface{}{float64(1), []interface{}{"x", "it's"}},
+			"b": complex(1, 2),
+		},
+		opts: &jsonlp.FormatOptions{Indent: "  ", TrailingComma: true, Quote: jsonlp.Quote_Single, Interop: jsonlp.Interop_TOML},
+		want: "a = [\n  1.0,\n  [\n    'x',\n    \"it's\",\n  ],\n]\nb = { re = 1.0, im = 2.0 }\n",
+	}, {
+		name:    "fmtt1-2a",
+		v:       []interface{}{},
+		opts:    nil,
+		wantErr: true,
+	}, {
+		name:    "fmtt1-2b",
+		v:       map[string]interface{}{"a": []interface{}{nil}},
+		opts:    nil,
+		wantErr: true,
+	}, {
+		name:    "fmtt1-2c",
+		v:       map[string]interface{}{"u": uint64(math.MaxUint64)},
+		opts:    nil,
+		wantErr: true,
+	}, {
+		name:    "fmtt1-2d",
+		v:       map[string]interface{}{"b": new(big.Int).Lsh(big.NewInt(1), 64)},
+		opts:    nil,
+		wantErr: true,
+	}, {
+		name:    "fmtt1-2e",
+		v:       map[string]interface{}{"n": jsonlp.Number("123456789012345678901234567890")},
+		opts:    nil,
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonlp.FormatTOML(tt.v, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FormatTOML() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FormatTOML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTOML3(t *testing.T) {
+	// The output is TOML 1.0.
+	v := map[string]interface{}{
+		"$a":    int64(3),
+		"ü":     "x",
+		"日本":    "y",
+		"b-c_1": uint64(math.MaxInt64),
+		"d":     big.NewInt(-1),
+		"e":     jsonlp.Number("0x7fffffffffffffff"),
+		"f":     jsonlp.Number("1.5e3"),
+		"g":     jsonlp.Number("0X1F"),
+		"h.i":   map[string]interface{}{"j k": true},
+	}
+	for _, opts := range []*jsonlp.FormatOptions{nil, {Hex: true}, {InlineTableDepth: 1}} {
+		s, err := jsonlp.FormatTOML(v, opts)
+		if err != nil {
+			t.Errorf("FormatTOML() error = %v", err)
+			continue
+		}
+		got, err := jsonlp.ParseTOMLWithOptions(s, &jsonlp.ParseOptions{
+			Strictness: jsonlp.Strictness_Strict,
+			NumberMode: jsonlp.Number_Integer,
+		})
+		if err != nil {
+			t.Errorf("ParseTOMLWithOptions() error = %v\n%v", err, s)
+			continue
+		}
+		m := got.(map[string]interface{})
+		for _, k := range []string{"$a", "ü", "日本", "b-c_1", "d", "e", "f", "g", "h.i"} {
+			if _, ok := m[k]; !ok {
+				t.Errorf("key %q is lost\n%v", k, s)
+			}
+		}
+		if m["g"] != int64(31) || m["b-c_1"] != int64(math.MaxInt64) {
+			t.Errorf("round-trip = %v\n%v", m, s)
+		}
+	}
+}
+
+func TestFormatTOML2(t *testing.T) {
+	// Round-trip
+	tests := []struct {
+		name string
+		s    string
+		opts []*jsonlp.FormatOptions
+	}{{
+		name: "fmtt2-1a",
+		s: `
+			a = 1
+			b = "x\U00000001ab\ty\"\"\"z\"\\"
+			c = """
+			line1
+			line2"""
+			"d.e" = [1, [2.5, "x"], {f = nan}]
+			g = 1979-05-27T07:32:00.999+09:00
+			h = 1979-05-27
+			i = 07:32:00
+			[j.k]
+			l = inf
+			[[m]]
+			n = 1
+			[m.o]
+			p = "\r\n"
+			[[m]]
+			[[m.q]]
+			r = 'literal'
+		`,
+		opts: []*jsonlp.FormatOptions{
+			nil,
+			{Indent: "  ", TrailingComma: true, Quote: jsonlp.Quote_Single, Hex: true},
+		},
+	}, {
+		name: "fmtt2-1b",
+		s: `
+			a = {b = 1, c = [{d = 2}]}
+			[e.f]
+			g = "h"
+		`,
+		opts: []*jsonlp.FormatOptions{
+			nil,
+			{InlineTableDepth: 1},
+			{InlineTableDepth: 2},
+		},
+	}, {
+		name: "fmtt2-1c",
+		s: `
+			[[a]]
+			b = 1
+			[[a.c]]
+			d = 2
+			[[a.c]]
+			[[a.e]]
+			[[a.e.f]]
+		`,
+		opts: []*jsonlp.FormatOptions{
+			nil,
+			{InlineTableDepth: 1},
+			{InlineTableDepth: 2},
+		},
+	}, {
+		name: "fmtt2-1d",
+		s:    "# The empty table",
+		opts: []*jsonlp.FormatOptions{nil},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, mode := range []jsonlp.NumberModeType{jsonlp.Number_Float, jsonlp.Number_Integer} {
+				popts := &jsonlp.ParseOptions{NumberMode: mode}
+				want, err := jsonlp.ParseTOMLWithOptions(tt.s, popts)
+				if err != nil {
+					t.Errorf("ParseTOMLWithOptions() error = %v", err)
+					return
+				}
+				for _, opts := range tt.opts {
+					s, err := jsonlp.FormatTOML(want, opts)
+					if err != nil {
+						t.Errorf("FormatTOML() error = %v", err)
+						return
+					}
+					got, err := jsonlp.ParseTOMLWithOptions(s, popts)
+					if err != nil {
+						t.Errorf("ParseTOMLWithOptions() error = %v\n%v", err, s)
+						return
+					}
+					if !deepEqualNaN(got, want) {
+						t.Errorf("round-trip = %v, want %v\n%v", got, want, s)
+					}
+				}
+			}
+		})
+	}
+}
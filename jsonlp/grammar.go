@@ -0,0 +1,158 @@
+package jsonlp
+
+import (
+	"context"
+
+	"github.com/shellyln/go-loose-json-parser/internal/grammar"
+	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
+	. "github.com/shellyln/takenoco/base"
+	strparser "github.com/shellyln/takenoco/string"
+)
+
+// The dialect package builds the grammars by the internal grammar package.
+func init() {
+	grammar.SetNew(func(spec grammar.Spec) grammar.Grammar[*ParseOptions] {
+		return &customGrammar{g: newJSONGrammarOf(newGrammarSpecOf(&spec))}
+	})
+	grammar.String = func(f uint32) ParserFn {
+		return stringValue(Features(f).normalize())
+	}
+	grammar.Number = func(f uint32) ParserFn {
+		return numberValue(Features(f).normalize())
+	}
+	grammar.Primitive = func(f uint32) ParserFn {
+		return primitiveValue(Features(f).normalize())
+	}
+	grammar.Space = func(f uint32) ParserFn {
+		return sp0(Features(f).normalize())
+	}
+	grammar.LiteralError = func(err error) error {
+		return &parseError{code: ErrorCode_InvalidLiteral, msg: err.Error(), err: err}
+	}
+}
+
+// Pieces of the JSON grammar that the builders in json.go assemble.
+type grammarSpec struct {
+	f           Features
+	primitive   ParserFn // Custom values and the primitive values
+	key         ParserFn // Custom keys
+	unquotedKey ParserFn // Unquoted keys
+	separator   ParserFn // Key-value separator
+	delimiter   ParserFn // Delimiter of the array elements and the object members
+	sp          ParserFn // Whitespaces and comments
+}
+
+// Grammar of Dialect_Loose. (The documents use it for the paths)
+var grammarSpec_All = newGrammarSpec(Features_All)
+
+func newGrammarSpec(f Features) *grammarSpec {
+	return newGrammarSpecOf(&grammar.Spec{Features: uint32(f)})
+}
+
+// Grammar of the loose TOML parsers.
+// The unquoted keys are the bare keys that do not accept the escape sequences.
+func newTOMLGrammarSpec(f Features) *grammarSpec {
+	gs := newGrammarSpec(f)
+	gs.unquotedKey = unquotedKey(gs.f, false)
+	return gs
+}
+
+func newGrammarSpecOf(spec *grammar.Spec) *grammarSpec {
+	f := Features(spec.Features).normalize()
+
+	values := make([]ParserFn, 0, len(spec.Quotes)+len(spec.Values)+1)
+	keys := make([]ParserFn, 0, len(spec.Quotes)+len(spec.Keys))
+	for _, q := range spec.Quotes {
+		str := quotedString(f, q)
+		values = append(values, str)
+		keys = append(keys, str)
+	}
+	values = append(values, spec.Values...)
+	values = append(values, primitiveValue(f))
+	keys = append(keys, spec.Keys...)
+
+	separators := make([]ParserFn, 0, len(spec.Separators)+3)
+	for _, x := range spec.Separators {
+		separators = append(separators, strparser.Seq(x))
+	}
+	separators = append(separators,
+		strparser.CharClass(":"),
+		extension(f, Feature_ArrowSeparator, strparser.CharClass("=>"), nil),
+		extension(f, Feature_EqualSeparator, strparser.CharClass("="), nil),
+	)
+
+	delimiters := make([]ParserFn, 0, len(spec.Delimiters)+1)
+	delimiters = append(delimiters, strparser.Seq(","))
+	for _, x := range spec.Delimiters {
+		delimiters = append(delimiters, strparser.Seq(x))
+	}
+
+	sp := make([]ParserFn, 0, len(spec.LineComments)+len(spec.BlockComments)+2)
+	sp = append(sp, whitespace(f))
+	for _, x := range spec.LineComments {
+		sp = append(sp, lineCommentOf(x, false))
+	}
+	for _, x := range spec.BlockComments {
+		sp = append(sp, blockCommentOf(x.Open, x.Close))
+	}
+	sp = append(sp, comment(f))
+
+	return &grammarSpec{
+		f:           f,
+		primitive:   firstOf(values),
+		key:         firstOf(keys),
+		unquotedKey: unquotedKey(f, true),
+		separator:   erase(firstOf(separators)),
+		delimiter:   erase(firstOf(delimiters)),
+		sp:          erase(ZeroOrMoreTimes(First(sp...))),
+	}
+}
+
+func firstOf(fns []ParserFn) ParserFn {
+	switch len(fns) {
+	case 0:
+		return Unmatched()
+	case 1:
+		return fns[0]
+	default:
+		return First(fns...)
+	}
+}
+
+func quotedString(f Features, q grammar.Quote) ParserFn {
+	return limitStringLength(Trans(
+		stringLiteralInner(f, q.Quote, q.MultiLine),
+		strparser.Concat,
+		ChangeClassName(class.String),
+	))
+}
+
+// JSON grammar that is built from grammar.Spec.
+// It is safe for concurrent use.
+type customGrammar struct {
+	g *jsonGrammar
+}
+
+// src: JSON of the grammar
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+// Dialect, Features and DisableFastPath are ignored. (The fast path is not used)
+//
+// parsed:
+// Same as ParseJSONWithOptions
+func (g *customGrammar) Parse(s string, opts *ParseOptions) (interface{}, error) {
+	return parse(g.g.document, s, newParseOptions(opts, false))
+}
+
+// src: JSON of the grammar
+//
+// opts:
+// Same as Parse.
+//
+// If ctx is done while parsing, the SyntaxError with ErrorCode_Canceled is returned.
+func (g *customGrammar) ParseContext(ctx context.Context, s string, opts *ParseOptions) (interface{}, error) {
+	o := newParseOptions(opts, false)
+	o.state.ctx = ctx
+	return parse(g.g.document, s, o)
+}
@@ -1,9 +1,6 @@
 package jsonlp
 
 import (
-	"encoding/base64"
-	"strings"
-
 	. "github.com/shellyln/takenoco/base"
 )
 
@@ -20,22 +17,3 @@ func setStr(s string) TransformerFn {
 func replaceStr(fn ParserFn, s string) ParserFn {
 	return Trans(fn, setStr(s))
 }
-
-func makeDottedKey(name []string, length int) string {
-	var keySb strings.Builder
-	for i := 0; i < length; i++ {
-		if i != 0 {
-			keySb.WriteRune('.')
-		}
-		x := base64.StdEncoding.EncodeToString([]byte(name[i]))
-		keySb.WriteString(x)
-	}
-	return keySb.String()
-}
-
-func makeDottedKeyForSimpleName(name string) string {
-	var keySb strings.Builder
-	x := base64.StdEncoding.EncodeToString([]byte(name))
-	keySb.WriteString(x)
-	return keySb.String()
-}
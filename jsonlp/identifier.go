@@ -1,36 +1,105 @@
 package jsonlp
 
 import (
+	"strconv"
+	"strings"
 	"unicode"
 
 	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
 	. "github.com/shellyln/takenoco/base"
-	. "github.com/shellyln/takenoco/string"
+	strparser "github.com/shellyln/takenoco/string"
 )
 
+// ID_Continue + '$' + U+200C + U+200D + ('-' for TOML)
+func isIdentifierRune(c rune) bool {
+	if c == '$' || c == '-' {
+		return true
+	}
+	// Alnum(), '_', and ...
+	return (unicode.Is(unicode.L, c) ||
+		unicode.Is(unicode.Nl, c) ||
+		unicode.Is(unicode.Other_ID_Start, c) ||
+		unicode.Is(unicode.Mn, c) ||
+		unicode.Is(unicode.Mc, c) ||
+		unicode.Is(unicode.Nd, c) ||
+		unicode.Is(unicode.Pc, c) ||
+		unicode.Is(unicode.Other_ID_Continue, c) ||
+		c == 0x0200c || c == 0x0200d) &&
+		!unicode.Is(unicode.Pattern_Syntax, c) &&
+		!unicode.Is(unicode.Pattern_White_Space, c)
+}
+
+// Returns true if tomlUnicodeIdentifierStr() accepts the whole string.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !isIdentifierRune(c) {
+			return false
+		}
+	}
+	return true
+}
+
 func tomlUnicodeIdentifierStr() ParserFn {
+	return Trans(
+		FlatGroup(
+			OneOrMoreTimes(strparser.CharClassFn(isIdentifierRune)),
+		),
+		strparser.Concat,
+		ChangeClassName(class.IdentifierStr),
+	)
+}
+
+// tomlUnicodeIdentifierStr() and the Unicode escape sequences.
+func unicodeIdentifierStr() ParserFn {
 	return Trans(
 		FlatGroup(
 			OneOrMoreTimes(First(
-				// ID_Continue + '$' + U+200C + U+200D + ('-' for TOML)
-				CharClass("$", "-"),
-				CharClassFn(func(c rune) bool {
-					// Alnum(), '_', and ...
-					return (unicode.Is(unicode.L, c) ||
-						unicode.Is(unicode.Nl, c) ||
-						unicode.Is(unicode.Other_ID_Start, c) ||
-						unicode.Is(unicode.Mn, c) ||
-						unicode.Is(unicode.Mc, c) ||
-						unicode.Is(unicode.Nd, c) ||
-						unicode.Is(unicode.Pc, c) ||
-						unicode.Is(unicode.Other_ID_Continue, c) ||
-						c == 0x0200c || c == 0x0200d) &&
-						!unicode.Is(unicode.Pattern_Syntax, c) &&
-						!unicode.Is(unicode.Pattern_White_Space, c)
-				}),
+				strparser.CharClassFn(isIdentifierRune),
+				identifierEscape(),
 			)),
 		),
-		Concat,
+		strparser.Concat,
 		ChangeClassName(class.IdentifierStr),
 	)
 }
+
+// Unicode escape sequence of ECMAScript IdentifierName. (e.g. `\u0061`)
+// The character should be the identifier character.
+func identifierEscape() ParserFn {
+	return Trans(
+		FlatGroup(
+			erase(strparser.Seq("\\u")),
+			Repeat(Times{Min: 4, Max: 4}, strparser.HexNumber()),
+		),
+		strparser.ParseIntRadix(16),
+		func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+			if !isIdentifierRune(rune(asts[0].Value.(int64))) {
+				return nil, newParseError(ErrorCode_InvalidEscapeSequence, "Invalid escape sequence in the identifier")
+			}
+			return asts, nil
+		},
+		strparser.StringFromInt,
+	)
+}
+
+// Decode the escape sequences of the identifier.
+func unescapeIdentifier(s string) string {
+	if !strings.Contains(s, "\\u") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+6 <= len(s) {
+			if c, err := strconv.ParseUint(s[i+2:i+6], 16, 32); err == nil {
+				sb.WriteRune(rune(c))
+				i += 5
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,131 @@
+package jsonlp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Error that is returned if the input exceeds ParseOptions.MaxInputSize.
+var ErrInputTooLarge = errors.New("Input too large")
+
+// Read all of the reader into the buffer that is sized by the reader, and decode it.
+// The buffer is not copied if the input is UTF-8.
+func readInput(r io.Reader, maxSize int64) (string, error) {
+	size := inputSizeOf(r)
+	if maxSize > 0 {
+		if size > maxSize {
+			size = maxSize
+		}
+		r = io.LimitReader(r, maxSize+1)
+	}
+
+	// One more byte to read EOF without growing the buffer.
+	buf := make([]byte, 0, size+1)
+	for {
+		if len(buf) == cap(buf) {
+			buf = append(buf, 0)[:len(buf)]
+		}
+		n, err := r.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if maxSize > 0 && int64(len(buf)) > maxSize {
+		return "", ErrInputTooLarge
+	}
+
+	return decodeInput(buf)
+}
+
+// Size of the input if the reader knows it. (e.g. *os.File, *bytes.Reader, *strings.Reader)
+func inputSizeOf(r io.Reader) int64 {
+	switch x := r.(type) {
+	case interface{ Len() int }:
+		return int64(x.Len())
+	case *os.File:
+		if fi, err := x.Stat(); err == nil && fi.Mode().IsRegular() {
+			return fi.Size()
+		}
+	}
+	return 512
+}
+
+// Remove the UTF-8 BOM, or transcode UTF-16 and UTF-32 to UTF-8 by the BOM.
+// If there is no BOM, the input is treated as UTF-8.
+// The UTF-8 input is not copied. The string shares the memory of b.
+func decodeInput(b []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte("\xEF\xBB\xBF")):
+		return bytesToString(b[3:]), nil
+	case bytes.HasPrefix(b, []byte("\x00\x00\xFE\xFF")):
+		return decodeUTF32(b[4:], true)
+	case bytes.HasPrefix(b, []byte("\xFF\xFE\x00\x00")):
+		return decodeUTF32(b[4:], false)
+	case bytes.HasPrefix(b, []byte("\xFE\xFF")):
+		return decodeUTF16(b[2:], true)
+	case bytes.HasPrefix(b, []byte("\xFF\xFE")):
+		return decodeUTF16(b[2:], false)
+	}
+	return bytesToString(b), nil
+}
+
+func bytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+func decodeUTF16(b []byte, bigEndian bool) (string, error) {
+	if len(b)%2 != 0 {
+		return "", errors.New("Invalid UTF-16 input: odd length")
+	}
+
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(b[i*2])<<8 | uint16(b[i*2+1])
+		} else {
+			units[i] = uint16(b[i*2+1])<<8 | uint16(b[i*2])
+		}
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(units))
+	for _, c := range utf16.Decode(units) {
+		sb.WriteRune(c)
+	}
+	return sb.String(), nil
+}
+
+func decodeUTF32(b []byte, bigEndian bool) (string, error) {
+	if len(b)%4 != 0 {
+		return "", errors.New("Invalid UTF-32 input: length is not a multiple of 4")
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(b) / 4)
+	for i := 0; i < len(b); i += 4 {
+		var c rune
+		if bigEndian {
+			c = rune(b[i])<<24 | rune(b[i+1])<<16 | rune(b[i+2])<<8 | rune(b[i+3])
+		} else {
+			c = rune(b[i+3])<<24 | rune(b[i+2])<<16 | rune(b[i+1])<<8 | rune(b[i])
+		}
+		if !utf8.ValidRune(c) {
+			c = utf8.RuneError
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String(), nil
+}
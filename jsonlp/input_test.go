@@ -0,0 +1,141 @@
+package jsonlp_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func encodeUTF16(s string, bigEndian bool) []byte {
+	ret := make([]byte, 0, len(s)*2+2)
+	if bigEndian {
+		ret = append(ret, 0xFE, 0xFF)
+	} else {
+		ret = append(ret, 0xFF, 0xFE)
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			ret = append(ret, byte(u>>8), byte(u))
+		} else {
+			ret = append(ret, byte(u), byte(u>>8))
+		}
+	}
+	return ret
+}
+
+func encodeUTF32(s string, bigEndian bool) []byte {
+	ret := make([]byte, 0, len(s)*4+4)
+	if bigEndian {
+		ret = append(ret, 0x00, 0x00, 0xFE, 0xFF)
+	} else {
+		ret = append(ret, 0xFF, 0xFE, 0x00, 0x00)
+	}
+	for _, c := range s {
+		if bigEndian {
+			ret = append(ret, byte(c>>24), byte(c>>16), byte(c>>8), byte(c))
+		} else {
+			ret = append(ret, byte(c), byte(c>>8), byte(c>>16), byte(c>>24))
+		}
+	}
+	return ret
+}
+
+func TestParseBytes1(t *testing.T) {
+	const src = "{a: 'あいう😀', b: [1, 2]}"
+	want := map[string]interface{}{
+		"a": "あいう😀",
+		"b": []interface{}{float64(1), float64(2)},
+	}
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{name: "b1-1a", b: []byte(src)},
+		{name: "b1-1b", b: append([]byte("\xEF\xBB\xBF"), src...)},
+		{name: "b1-1c", b: encodeUTF16(src, false)},
+		{name: "b1-1d", b: encodeUTF16(src, true)},
+		{name: "b1-1e", b: encodeUTF32(src, false)},
+		{name: "b1-1f", b: encodeUTF32(src, true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonlp.ParseJSONBytes(tt.b, nil)
+			if err != nil {
+				t.Errorf("%v: ParseJSONBytes() error = %v", tt.name, err)
+				return
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%v: ParseJSONBytes() = %v, want %v", tt.name, got, want)
+			}
+
+			got, err = jsonlp.ParseJSONReader(bytes.NewReader(tt.b), nil)
+			if err != nil {
+				t.Errorf("%v: ParseJSONReader() error = %v", tt.name, err)
+				return
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%v: ParseJSONReader() = %v, want %v", tt.name, got, want)
+			}
+		})
+	}
+}
+
+func TestParseBytes2(t *testing.T) {
+	const src = "\xEF\xBB\xBFa = 1\n[b]\nc = 'x'\n"
+	want := map[string]interface{}{
+		"a": float64(1),
+		"b": map[string]interface{}{"c": "x"},
+	}
+
+	got, err := jsonlp.ParseTOMLBytes([]byte(src), nil)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTOMLBytes() = %v, %v, want %v", got, err, want)
+	}
+
+	got, err = jsonlp.ParseTOMLReader(strings.NewReader(src), &jsonlp.ParseOptions{MaxInputSize: int64(len(src))})
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTOMLReader() = %v, %v, want %v", got, err, want)
+	}
+
+	_, err = jsonlp.ParseTOMLReader(strings.NewReader(src), &jsonlp.ParseOptions{MaxInputSize: int64(len(src) - 1)})
+	if !errors.Is(err, jsonlp.ErrInputTooLarge) {
+		t.Errorf("ParseTOMLReader() error = %v, want ErrInputTooLarge", err)
+	}
+
+	_, err = jsonlp.ParseJSONBytes([]byte("\xFF\xFE{"), nil)
+	if err == nil {
+		t.Errorf("ParseJSONBytes() error = nil, want error")
+	}
+}
+
+func TestParseBytes3(t *testing.T) {
+	// Readers that do not know the size, and the input that is larger than the initial buffer.
+	src := "[" + strings.Repeat(`"abcdefgh", `, 100) + "1]"
+	want, err := jsonlp.ParseJSON(src, jsonlp.Linebreak_Lf, jsonlp.Interop_None)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []io.Reader{
+		strings.NewReader(src),
+		iotest.OneByteReader(strings.NewReader(src)),
+		iotest.HalfReader(strings.NewReader(src)),
+	} {
+		got, err := jsonlp.ParseJSONReader(r, &jsonlp.ParseOptions{MaxInputSize: int64(len(src))})
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseJSONReader() = %v, %v", got, err)
+		}
+	}
+
+	_, err = jsonlp.ParseJSONReader(iotest.OneByteReader(strings.NewReader(src)), &jsonlp.ParseOptions{MaxInputSize: 600})
+	if !errors.Is(err, jsonlp.ErrInputTooLarge) {
+		t.Errorf("ParseJSONReader() error = %v, want ErrInputTooLarge", err)
+	}
+}
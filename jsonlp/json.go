@@ -1,156 +1,275 @@
 package jsonlp
 
 import (
-	"errors"
-	"strconv"
+	"io"
+	"sync"
 
 	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
 	. "github.com/shellyln/takenoco/base"
-	. "github.com/shellyln/takenoco/string"
+	strparser "github.com/shellyln/takenoco/string"
 )
 
+// Parsers of the JSON grammar that is built for the set of the extensions.
+type jsonGrammar struct {
+	features Features
+	document ParserFn
+
+	// Sub-parsers that the fast path and the decoder resume with
+	value      ParserFn
+	list       ParserFn
+	object     ParserFn
+	listTail   ParserFn // Rest of the array after an element (e.g. `, 2]` of `[1, 2]`)
+	objectTail ParserFn // Rest of the object after a key-value pair (e.g. `, b: 2}` of `{a: 1, b: 2}`)
+	key        ParserFn
+	sp         ParserFn
+}
+
 var (
-	jsonParser ParserFn
+	jsonGrammars       map[Features]*jsonGrammar // Grammars of the dialects (read only)
+	jsonCustomGrammars sync.Map                  // Grammars of the custom sets (Features -> *jsonGrammar)
 )
 
 func init() {
-	jsonParser = jsonDocument()
+	jsonGrammars = make(map[Features]*jsonGrammar)
+	for _, d := range []DialectType{Dialect_Loose, Dialect_StrictJSON, Dialect_JSON5, Dialect_JSONC} {
+		jsonGrammars[d.Features()] = newJSONGrammar(d.Features())
+	}
 }
 
-func listValue() ParserFn {
-	return Trans(
+func newJSONGrammar(f Features) *jsonGrammar {
+	return newJSONGrammarOf(newGrammarSpec(f))
+}
+
+func newJSONGrammarOf(gs *grammarSpec) *jsonGrammar {
+	return &jsonGrammar{
+		features:   gs.f,
+		document:   jsonDocument(gs),
+		value:      First(gs.primitive, listValue(gs), objectValue(gs)),
+		list:       listValue(gs),
+		object:     objectValue(gs),
+		listTail:   FlatGroup(listElements(gs), listClose(gs)),
+		objectTail: FlatGroup(objectMembers(gs), objectClose(gs)),
+		key:        objectKey(gs, true),
+		sp:         gs.sp,
+	}
+}
+
+// Grammar of the extensions of the options.
+// The grammars of the custom sets are built on the first use, and cached.
+func jsonGrammarOf(opts parseOptions) *jsonGrammar {
+	if g, ok := jsonGrammars[opts.features]; ok {
+		return g
+	}
+	if g, ok := jsonCustomGrammars.Load(opts.features); ok {
+		return g.(*jsonGrammar)
+	}
+	g, _ := jsonCustomGrammars.LoadOrStore(opts.features, newJSONGrammar(opts.features))
+	return g.(*jsonGrammar)
+}
+
+// Array, object and primitive value
+func jsonValue(gs *grammarSpec) ParserFn {
+	return First(
+		gs.primitive,
+		Indirect(func() ParserFn { return listValue(gs) }),
+		Indirect(func() ParserFn { return objectValue(gs) }),
+	)
+}
+
+func listValue(gs *grammarSpec) ParserFn {
+	return limitNesting(Trans(
 		FlatGroup(
-			erase((Seq("["))),
-			sp0(),
+			erase((strparser.Seq("["))),
+			gs.sp,
 			ZeroOrOnce(
 				FlatGroup(
-					First(
-						primitiveValue(),
-						Indirect(listValue),
-						Indirect(objectValue),
+					recoverable(
+						limitElement(jsonValue(gs), true),
+						syncTo(","),
+						true,
 					),
-					sp0(),
+					gs.sp,
 				),
-				ZeroOrMoreTimes(
-					erase((Seq(","))),
-					sp0(),
-					First(
-						primitiveValue(),
-						Indirect(listValue),
-						Indirect(objectValue),
-						LookAhead(Seq("]")),
-						FlatGroup(
-							sp0(),
-							Error("Expect array closing parenthesis ')' or value"),
-						),
+				listElements(gs),
+			),
+			listClose(gs),
+		),
+		listTransformer,
+	))
+}
+
+// Elements of the array after the first element.
+func listElements(gs *grammarSpec) ParserFn {
+	return FlatGroup(
+		ZeroOrMoreTimes(
+			gs.delimiter,
+			gs.sp,
+			recoverable(
+				First(
+					limitElement(jsonValue(gs), true),
+					extension(gs.f, Feature_TrailingComma, LookAhead(strparser.Seq("]")), nil),
+					FlatGroup(
+						gs.sp,
+						syntaxError(ErrorCode_ExpectArrayValue, "Expect array closing parenthesis ')' or value"),
 					),
-					sp0(),
 				),
+				syncTo(","),
+				true,
 			),
+			gs.sp,
+		),
+		If(gs.f.has(Feature_TrailingComma),
 			ZeroOrOnce(
-				erase((Seq(","))),
-				sp0(),
+				gs.delimiter,
+				gs.sp,
 			),
+			Zero(),
+		),
+	)
+}
+
+func listClose(gs *grammarSpec) ParserFn {
+	return FlatGroup(
+		recoverable(
 			First(
-				erase((Seq("]"))),
+				erase((strparser.Seq("]"))),
 				FlatGroup(
-					sp0(),
-					Error("Expect array closing parenthesis ')'"),
+					gs.sp,
+					syntaxError(ErrorCode_ExpectArrayClose, "Expect array closing parenthesis ')'"),
 				),
 			),
-			sp0(),
+			syncToClose(']'),
+			false,
 		),
-		func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
-			length := len(asts)
-			v := make([]interface{}, length)
-			for i := 0; i < length; i++ {
-				v[i] = asts[i].Value
-			}
-			return AstSlice{{
-				ClassName: class.Array,
-				Type:      AstType_Any,
-				Value:     v,
-			}}, nil
-		},
+		gs.sp,
 	)
 }
 
-func objectKey(allowLb bool) ParserFn {
+func listTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	length := len(asts)
+	v := make([]interface{}, length)
+	for i := 0; i < length; i++ {
+		v[i] = asts[i].Value
+	}
+	return AstSlice{{
+		ClassName: class.Array,
+		Type:      AstType_Any,
+		Value:     v,
+	}}, nil
+}
+
+func objectKey(gs *grammarSpec, allowLb bool) ParserFn {
 	return Trans(
 		First(
-			dottedIdentifier(allowLb),
-			stringValue(),
-			identifier(),
+			extension(gs.f, Feature_DottedKey, dottedIdentifier(gs, allowLb), nil),
+			stringValue(gs.f),
+			gs.unquotedKey,
+			gs.key,
 		),
+		keyPositionTransformer,
 	)
 }
 
-func objectKeyValuePair() ParserFn {
+func objectKeyValuePair(gs *grammarSpec) ParserFn {
 	return FlatGroup(
-		objectKey(true),
-		sp0(),
-		erase(First(CharClass(":"), CharClass("=>"), CharClass("="))),
-		sp0(),
-		First(
-			primitiveValue(),
-			Indirect(listValue),
-			Indirect(objectValue),
-			Error("Expect object property value"),
+		objectKey(gs, true),
+		gs.sp,
+		gs.separator,
+		gs.sp,
+		recoverable(
+			First(
+				jsonValue(gs),
+				syntaxError(ErrorCode_ExpectValue, "Expect object property value"),
+			),
+			syncTo(","),
+			true,
 		),
-		sp0(),
+		gs.sp,
 	)
 }
 
-func objectValue() ParserFn {
-	return Trans(
+func objectValue(gs *grammarSpec) ParserFn {
+	return limitNesting(Trans(
 		FlatGroup(
-			erase((Seq("{"))),
-			sp0(),
+			erase((strparser.Seq("{"))),
+			gs.sp,
 			ZeroOrOnce(
-				objectKeyValuePair(),
-				ZeroOrMoreTimes(
-					erase((Seq(","))),
-					sp0(),
-					First(
-						objectKeyValuePair(),
-						LookAhead(Seq("}")),
-						FlatGroup(
-							sp0(),
-							Error("Expect object closing bracket '}' or key-value pair"),
-						),
+				limitElement(objectKeyValuePair(gs), false),
+				objectMembers(gs),
+			),
+			objectClose(gs),
+		),
+		tableTransformer,
+	))
+}
+
+// Key-value pairs of the object after the first pair.
+func objectMembers(gs *grammarSpec) ParserFn {
+	return FlatGroup(
+		ZeroOrMoreTimes(
+			gs.delimiter,
+			gs.sp,
+			recoverable(
+				First(
+					limitElement(objectKeyValuePair(gs), false),
+					extension(gs.f, Feature_TrailingComma, LookAhead(strparser.Seq("}")), nil),
+					FlatGroup(
+						gs.sp,
+						syntaxError(ErrorCode_ExpectObjectMember, "Expect object closing bracket '}' or key-value pair"),
 					),
 				),
-				ZeroOrOnce(
-					erase((Seq(","))),
-					sp0(),
-				),
+				syncTo(","),
+				false,
+			),
+		),
+		If(gs.f.has(Feature_TrailingComma),
+			ZeroOrOnce(
+				gs.delimiter,
+				gs.sp,
 			),
+			Zero(),
+		),
+	)
+}
+
+func objectClose(gs *grammarSpec) ParserFn {
+	return FlatGroup(
+		recoverable(
 			First(
-				erase((Seq("}"))),
+				erase((strparser.Seq("}"))),
 				FlatGroup(
-					sp0(),
-					Error("Expect object closing bracket '}'"),
+					gs.sp,
+					syntaxError(ErrorCode_ExpectObjectClose, "Expect object closing bracket '}'"),
 				),
 			),
-			sp0(),
+			syncToClose('}'),
+			false,
 		),
-		tableTransformer,
+		gs.sp,
 	)
 }
 
-func jsonDocument() ParserFn {
+func jsonDocument(gs *grammarSpec) ParserFn {
 	return FlatGroup(
 		Start(),
-		sp0(),
-		First(
-			primitiveValue(),
-			listValue(),
-			objectValue(),
+		gs.sp,
+		recoverable(
+			First(
+				gs.primitive,
+				listValue(gs),
+				objectValue(gs),
+			),
+			syncToEnd,
+			true,
 		),
-		sp0(),
-		First(
-			End(),
-			Error("Expect terminatiion"),
+		gs.sp,
+		recoverable(
+			First(
+				strparser.End(),
+				syntaxError(ErrorCode_ExpectTermination, "Expect terminatiion"),
+			),
+			syncToEnd,
+			false,
 		),
 	)
 }
@@ -171,38 +290,53 @@ func jsonDocument() ParserFn {
 // parsed:
 // nil | []any | map[string]any | float64 | int64 | uint64 | complex128 | string | bool | time.Time
 func ParseJSON(s string, plafLb PlatformLinebreakType, interop InteropType) (interface{}, error) {
-	ctx := *NewStringParserContext(s)
-	opts := parseOptions{
-		interop:           interop,
-		platformLinebreak: "\n",
-		isTOML:            false,
-	}
-	switch plafLb {
-	case Linebreak_CrLf:
-		opts.platformLinebreak = "\r\n"
-	case Linebreak_Cr:
-		opts.platformLinebreak = "\r"
-	}
-	ctx.Tag = opts
+	return ParseJSONWithOptions(s, &ParseOptions{
+		PlatformLinebreak: plafLb,
+		Interop:           interop,
+	})
+}
 
-	out, err := jsonParser(ctx)
+// src: Loose JSON
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+//
+// parsed:
+// nil | []any | map[string]any | float64 | int64 | uint64 | complex128 | string | bool | time.Time |
+// Number | *big.Int | *big.Float | *big.Rat | *OrderedMap | []*OrderedMap |
+// LocalDate | LocalTime | LocalDateTime (depends on the options)
+func ParseJSONWithOptions(s string, opts *ParseOptions) (interface{}, error) {
+	return parseJSON(s, newParseOptions(opts, false))
+}
+
+// src: Loose JSON in UTF-8, or UTF-16, UTF-32 with BOM.
+// The UTF-8 BOM is removed.
+// UTF-8 input is not copied. The parsed strings can share the memory of b,
+// so b should not be modified while the parsed value is used.
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+func ParseJSONBytes(b []byte, opts *ParseOptions) (interface{}, error) {
+	s, err := decodeInput(b)
 	if err != nil {
-		pos := GetLineAndColPosition(s, out.SourcePosition, 4)
-		return nil, errors.New(
-			err.Error() +
-				"\n --> Line " + strconv.Itoa(pos.Line) +
-				", Col " + strconv.Itoa(pos.Col) + "\n" +
-				pos.ErrSource)
+		return nil, err
 	}
+	return ParseJSONWithOptions(s, opts)
+}
 
-	if out.MatchStatus == MatchStatus_Matched {
-		return out.AstStack[0].Value, nil
-	} else {
-		pos := GetLineAndColPosition(s, out.SourcePosition, 4)
-		return nil, errors.New(
-			"Parse failed" +
-				"\n --> Line " + strconv.Itoa(pos.Line) +
-				", Col " + strconv.Itoa(pos.Col) + "\n" +
-				pos.ErrSource)
+// src: Loose JSON in UTF-8, or UTF-16, UTF-32 with BOM.
+// The UTF-8 BOM is removed.
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+// If the input exceeds opts.MaxInputSize, ErrInputTooLarge is returned.
+func ParseJSONReader(r io.Reader, opts *ParseOptions) (interface{}, error) {
+	if opts == nil {
+		opts = &parseOptsDefault
+	}
+	s, err := readInput(r, opts.MaxInputSize)
+	if err != nil {
+		return nil, err
 	}
+	return ParseJSONWithOptions(s, opts)
 }
@@ -14,6 +14,7 @@ type args struct {
 	s       string
 	plafLb  jsonlp.PlatformLinebreakType
 	interop jsonlp.InteropType
+	opts    *jsonlp.ParseOptions
 }
 
 type testMatrixItem struct {
@@ -27,7 +28,13 @@ type testMatrixItem struct {
 func runMatrixParse(t *testing.T, tests []testMatrixItem) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := jsonlp.ParseJSON(tt.args.s, tt.args.plafLb, tt.args.interop)
+			var got interface{}
+			var err error
+			if tt.args.opts != nil {
+				got, err = jsonlp.ParseJSONWithOptions(tt.args.s, tt.args.opts)
+			} else {
+				got, err = jsonlp.ParseJSON(tt.args.s, tt.args.plafLb, tt.args.interop)
+			}
 			if (err != nil) != tt.wantErr {
 				t.Errorf("%v: Parse() error = %v, wantErr %v", tt.name, err, tt.wantErr)
 				return
@@ -1078,3 +1085,52 @@ func TestJsonParse4(t *testing.T) {
 
 	runMatrixParse(t, tests)
 }
+
+func TestJsonParseWithOptions1(t *testing.T) {
+	tests := []testMatrixItem{{
+		name:    "jo1-1a",
+		args:    args{s: `[Infinity, 1+2i]`, opts: &jsonlp.ParseOptions{}},
+		want:    []interface{}{math.Inf(1), complex(1, 2)},
+		wantErr: false,
+	}, {
+		name: "jo1-1b",
+		args: args{s: `[NaN, 1+2i]`, opts: &jsonlp.ParseOptions{Interop: jsonlp.Interop_JSON}},
+		want: []interface{}{
+			map[string]interface{}{"nan": true},
+			map[string]interface{}{"re": float64(1), "im": float64(2)},
+		},
+		wantErr: false,
+	}, {
+		name:    "jo1-1c",
+		args:    args{s: `[NaN, 1+2i]`, opts: &jsonlp.ParseOptions{Interop: jsonlp.Interop_JSON_AsNull}},
+		want:    []interface{}{nil, nil},
+		wantErr: false,
+	}, {
+		name:    "jo1-2a",
+		args:    args{s: "`a\nb\r\nc`", opts: &jsonlp.ParseOptions{PlatformLinebreak: jsonlp.Linebreak_CrLf}},
+		want:    "a\r\nb\r\nc",
+		wantErr: false,
+	}, {
+		name:    "jo1-2b",
+		args:    args{s: "`a\nb\r\nc`", opts: &jsonlp.ParseOptions{PlatformLinebreak: jsonlp.Linebreak_Cr}},
+		want:    "a\rb\rc",
+		wantErr: false,
+	}, {
+		name:    "jo1-3a",
+		args:    args{s: `{a:`, opts: &jsonlp.ParseOptions{}},
+		want:    nil,
+		wantErr: true,
+	}}
+
+	runMatrixParse(t, tests)
+
+	got, err := jsonlp.ParseJSONWithOptions(`{a: [1, 2]}`, nil)
+	if err != nil {
+		t.Errorf("ParseJSONWithOptions(nil): error = %v", err)
+		return
+	}
+	want := map[string]interface{}{"a": []interface{}{float64(1), float64(2)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseJSONWithOptions(nil) = %v, want %v", got, want)
+	}
+}
@@ -1,101 +1,189 @@
 package jsonlp
 
 import (
+	"strconv"
+	"unicode/utf16"
+
 	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
 	. "github.com/shellyln/takenoco/base"
-	. "github.com/shellyln/takenoco/string"
+	strparser "github.com/shellyln/takenoco/string"
 )
 
-func stringLiteralInner(cc string, multiline bool) ParserFn {
+// UTF-16 surrogate pair of the escape sequences. (e.g. `\uD83D\uDE00`)
+// The leading `\` is already consumed.
+func surrogatePair() ParserFn {
+	return Trans(
+		FlatGroup(
+			erase(strparser.CharClass("u")),
+			strparser.CharClass("D", "d"),
+			strparser.CharClass("8", "9", "A", "B", "a", "b"),
+			Repeat(Times{Min: 2, Max: 2}, strparser.HexNumber()),
+			erase(strparser.Seq("\\u")),
+			strparser.CharClass("D", "d"),
+			strparser.CharClass("C", "D", "E", "F", "c", "d", "e", "f"),
+			Repeat(Times{Min: 2, Max: 2}, strparser.HexNumber()),
+		),
+		strparser.Concat,
+		func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+			s := asts[0].Value.(string)
+			hi, _ := strconv.ParseUint(s[:4], 16, 32)
+			lo, _ := strconv.ParseUint(s[4:], 16, 32)
+			return AstSlice{{
+				Type:  AstType_String,
+				Value: string(utf16.DecodeRune(rune(hi), rune(lo))),
+			}}, nil
+		},
+	)
+}
+
+func escapeSequence(f Features) ParserFn {
 	return FlatGroup(
-		erase(Seq(cc)),
-		ZeroOrMoreTimes(
-			First(
+		erase(strparser.Seq("\\")),
+		First(
+			// RFC 8259
+			strparser.CharClass("\\", "\"", "/"),
+			replaceStr(strparser.CharClass("n"), "\n"),
+			replaceStr(strparser.CharClass("r"), "\r"),
+			replaceStr(strparser.CharClass("t"), "\t"),
+			replaceStr(strparser.CharClass("b"), "\b"),
+			replaceStr(strparser.CharClass("f"), "\f"),
+			surrogatePair(),
+			Trans(
 				FlatGroup(
-					erase(Seq("\\")),
-					First(
-						CharClass("\\", "'", "\"", "`"),
-						replaceStr(CharClass("n", "N"), "\n"),
-						replaceStr(CharClass("r", "R"), "\r"),
-						replaceStr(CharClass("v", "V"), "\v"),
-						replaceStr(CharClass("t", "T"), "\t"),
-						replaceStr(CharClass("b", "B"), "\b"),
-						replaceStr(CharClass("f", "F"), "\f"),
-						Trans(
-							FlatGroup(
-								erase(CharClass("u")),
-								Repeat(Times{Min: 4, Max: 4}, HexNumber()),
-							),
-							ParseIntRadix(16),
-							StringFromInt,
-						),
-						Trans(
-							FlatGroup(
-								erase(CharClass("u{")),
-								Repeat(Times{Min: 1, Max: 6}, HexNumber()),
-								erase(CharClass("}")),
-							),
-							ParseIntRadix(16),
-							StringFromInt,
+					erase(strparser.CharClass("u")),
+					Repeat(Times{Min: 4, Max: 4}, strparser.HexNumber()),
+				),
+				strparser.ParseIntRadix(16),
+				strparser.StringFromInt,
+			),
+			If(f.has(Feature_ExtendedEscape),
+				First(
+					strparser.CharClass("`"),
+					replaceStr(strparser.CharClass("N"), "\n"),
+					replaceStr(strparser.CharClass("R"), "\r"),
+					replaceStr(strparser.CharClass("V"), "\v"),
+					replaceStr(strparser.CharClass("T"), "\t"),
+					replaceStr(strparser.CharClass("B"), "\b"),
+					replaceStr(strparser.CharClass("F"), "\f"),
+					Trans(
+						FlatGroup(
+							erase(strparser.CharClass("u{")),
+							Repeat(Times{Min: 1, Max: 6}, strparser.HexNumber()),
+							erase(strparser.CharClass("}")),
 						),
-						Trans(
-							FlatGroup(
-								erase(CharClass("x")),
-								Repeat(Times{Min: 2, Max: 2}, HexNumber()),
-							),
-							ParseIntRadix(16),
-							StringFromInt,
+						strparser.ParseIntRadix(16),
+						strparser.StringFromInt,
+					),
+				),
+				Unmatched(),
+			),
+			If(f.has(Feature_JSON5Escape),
+				First(
+					strparser.CharClass("'"),
+					replaceStr(strparser.CharClass("v"), "\v"),
+					Trans(
+						FlatGroup(
+							erase(strparser.CharClass("x")),
+							Repeat(Times{Min: 2, Max: 2}, strparser.HexNumber()),
 						),
-						Trans(
-							FlatGroup(
-								Repeat(Times{Min: 3, Max: 3}, OctNumber()),
-							),
-							ParseIntRadix(8),
-							StringFromInt,
+						strparser.ParseIntRadix(16),
+						strparser.StringFromInt,
+					),
+				),
+				Unmatched(),
+			),
+			If(f.has(Feature_ExtendedEscape),
+				Trans(
+					FlatGroup(
+						Repeat(Times{Min: 3, Max: 3}, strparser.OctNumber()),
+					),
+					strparser.ParseIntRadix(8),
+					strparser.StringFromInt,
+				),
+				Unmatched(),
+			),
+			If(f.has(Feature_JSON5Escape),
+				First(
+					// `\0` is NUL in JSON5. (The loose grammar treats it as `0`.)
+					If(f.has(Feature_ExtendedEscape),
+						Unmatched(),
+						FlatGroup(
+							replaceStr(strparser.CharClass("0"), "\x00"),
+							LookAheadN(strparser.CharRange(RuneRange{Start: '0', End: '9'})),
 						),
-						replaceStr(CharClass("\r\n", "\r", "\n"), ""),
-						Any(),
+					),
+					// Line continuation
+					replaceStr(strparser.CharClass("\r\n", "\r", "\n", "\u2028", "\u2029"), ""),
+					If(f.has(Feature_ExtendedEscape),
+						strparser.Any(),
+						strparser.CharClassN("0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "x", "u"),
 					),
 				),
+				Unmatched(),
+			),
+			If(f.has(Feature_JSON5Escape|Feature_ExtendedEscape),
+				Unmatched(),
+				FlatGroup(
+					LookAhead(strparser.Any()),
+					If(f.has(Feature_JSON5Escape),
+						extensionError(f, Feature_ExtendedEscape),
+						extensionError(f, Feature_JSON5Escape),
+					),
+				),
+			),
+		),
+	)
+}
+
+func stringLiteralInner(f Features, cc string, multiline bool) ParserFn {
+	return FlatGroup(
+		erase(strparser.Seq(cc)),
+		ZeroOrMoreTimes(
+			First(
+				escapeSequence(f),
 				If(multiline,
 					OneOrMoreTimes(
 						First(
 							Trans(
-								CharClass("\r\n", "\r", "\n"),
+								strparser.CharClass("\r\n", "\r", "\n"),
 								platformLinebreakTransformer,
 							),
-							CharClassN(cc, "\\"),
+							strparser.CharClassN(cc, "\\"),
 						),
+						limitStringChunk(),
 					),
 					OneOrMoreTimes(
 						First(
 							FlatGroup(
-								CharClass("\r", "\n"),
-								Error("An unexpected newline has appeared in the string literal."),
+								strparser.CharClass("\r", "\n"),
+								syntaxError(ErrorCode_UnexpectedNewlineInString, "An unexpected newline has appeared in the string literal."),
 							),
-							CharClassN(cc, "\\"),
+							extension(f, Feature_ControlCharacter, Unmatched(), strparser.CharClassFn(isControlRune)),
+							strparser.CharClassN(cc, "\\"),
 						),
+						limitStringChunk(),
 					),
 				),
 			),
+			limitStringChunk(),
 		),
 		First(
-			FlatGroup(End(), Error("An unexpected termination has appeared in the string literal.")),
-			erase(Seq(cc)),
+			FlatGroup(strparser.End(), syntaxError(ErrorCode_UnterminatedString, "An unexpected termination has appeared in the string literal.")),
+			erase(strparser.Seq(cc)),
 		),
 	)
 }
 
-func jsonStringValue() ParserFn {
+func jsonStringValue(f Features) ParserFn {
 	return Trans(
 		First(
-			tomlMultiLineBasicString(),
-			tomlMultiLineLiteralString(),
-			stringLiteralInner("\"", false),
-			stringLiteralInner("'", false),
-			stringLiteralInner("`", true),
+			extension(f, Feature_MultiLineString, tomlMultiLineBasicString(f), strparser.Seq(`"""`)),
+			extension(f, Feature_MultiLineString, tomlMultiLineLiteralString(), strparser.Seq(`'''`)),
+			stringLiteralInner(f, "\"", false),
+			extension(f, Feature_SingleQuote, stringLiteralInner(f, "'", false), strparser.Seq("'")),
+			extension(f, Feature_BackQuote, stringLiteralInner(f, "`", true), strparser.Seq("`")),
 		),
-		Concat,
+		strparser.Concat,
 		ChangeClassName(class.String),
 	)
 }
@@ -0,0 +1,240 @@
+package jsonlp
+
+import (
+	"errors"
+	"strconv"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Limits of the document. Zero means no limit.
+type parseLimits struct {
+	inputSize    int64
+	depth        int
+	stringLength int
+	arrayLength  int
+	members      int
+	nodes        int
+}
+
+func newLimitError(code ErrorCode, msg string, limit int, pos SourcePosition) error {
+	return &parseError{
+		code: code,
+		msg:  msg + " (limit: " + strconv.Itoa(limit) + ")",
+		pos:  &pos,
+	}
+}
+
+// Limit errors and cancellation are not recovered in the error recovery mode.
+func isFatalError(err error) bool {
+	if isContextError(err) {
+		return true
+	}
+	var pe *parseError
+	if errors.As(err, &pe) {
+		switch pe.code {
+		case ErrorCode_DepthLimitExceeded, ErrorCode_StringLengthLimitExceeded,
+			ErrorCode_ArrayLengthLimitExceeded, ErrorCode_MemberLimitExceeded, ErrorCode_NodeLimitExceeded:
+			return true
+		}
+	}
+	return false
+}
+
+func (l *parseLimits) checkInputSize(s string) error {
+	if 0 < l.inputSize && l.inputSize < int64(len(s)) {
+		return newSyntaxError(s, SourcePosition{Position: int(l.inputSize)}, ErrInputTooLarge)
+	}
+	return nil
+}
+
+func (l *parseLimits) checkArrayLength(n int, pos SourcePosition) error {
+	if 0 < l.arrayLength && l.arrayLength < n {
+		return newLimitError(ErrorCode_ArrayLengthLimitExceeded, "Too many array elements", l.arrayLength, pos)
+	}
+	return nil
+}
+
+func (l *parseLimits) checkMembers(table object, pos SourcePosition) error {
+	if 0 < l.members && l.members < table.length() {
+		return newLimitError(ErrorCode_MemberLimitExceeded, "Too many object members", l.members, pos)
+	}
+	return nil
+}
+
+// Count the array elements and the key-value pairs.
+func (l *parseLimits) countNodes(state *parseState, n int, pos SourcePosition) error {
+	state.nodes += n
+	if 0 < l.nodes && l.nodes < state.nodes {
+		return newLimitError(ErrorCode_NodeLimitExceeded, "Too many nodes", l.nodes, pos)
+	}
+	return nil
+}
+
+// Count the element of the container that is being parsed.
+// The pending elements are included in the nodes before the container is parsed.
+func (l *parseLimits) countPending(state *parseState, pos SourcePosition) error {
+	state.pending++
+	if 0 < l.nodes && l.nodes < state.nodes+state.pending {
+		return newLimitError(ErrorCode_NodeLimitExceeded, "Too many nodes", l.nodes, pos)
+	}
+	return nil
+}
+
+// String that is being parsed by limitStringLength.
+type stringLimitState struct {
+	active bool
+	pos    SourcePosition // Start of the string
+	bottom int            // Length of the AST stack at the start of the string
+	top    int            // Length of the AST stack that is counted
+	length int            // Length of the string pieces that are counted
+}
+
+// Check the string length while parsing the string.
+func (l *parseLimits) checkStringLength(n int, pos SourcePosition) error {
+	if 0 < l.stringLength && l.stringLength < n {
+		return newLimitError(ErrorCode_StringLengthLimitExceeded, "String too long", l.stringLength, pos)
+	}
+	return nil
+}
+
+func limitError(ctx ParserContext, err error) (ParserContext, error) {
+	ctx.MatchStatus = MatchStatus_Error
+	return ctx, err
+}
+
+// Check the nesting depth before parsing the array or the object.
+// After parsing the array, add the elements to the nodes.
+// (The elements are checked by limitElement while parsing)
+func limitNesting(fn ParserFn) ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		opts := ctx.Tag.(parseOptions)
+		limits := &opts.limits
+		state := opts.state
+
+		if err := state.checkCancel(); err != nil {
+			return limitError(ctx, err)
+		}
+
+		state.depth++
+		defer func() {
+			state.depth--
+		}()
+		if 0 < limits.depth && limits.depth < state.depth {
+			return limitError(ctx, newLimitError(ErrorCode_DepthLimitExceeded, "Too deeply nested", limits.depth, ctx.SourcePosition))
+		}
+
+		out, err := limitElements(fn)(ctx)
+		if err != nil || out.MatchStatus != MatchStatus_Matched || len(out.AstStack) <= len(ctx.AstStack) {
+			return out, err
+		}
+		if v, ok := out.AstStack[len(out.AstStack)-1].Value.([]interface{}); ok {
+			if err := limits.countNodes(state, len(v), ctx.SourcePosition); err != nil {
+				return limitError(out, err)
+			}
+		}
+		return out, nil
+	}
+}
+
+// Count the elements of the container (the array or the table) that fn parses.
+// The transformer of the container adds them to the nodes.
+func limitElements(fn ParserFn) ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		state := ctx.Tag.(parseOptions).state
+		pending, elements := state.pending, state.elements
+		state.elements = 0
+		defer func() {
+			state.pending, state.elements = pending, elements
+		}()
+		return fn(ctx)
+	}
+}
+
+// Check the limits after each element of the container is parsed,
+// so that the large containers fail before they are built.
+// If isArray is true, the elements are the array elements. Otherwise they are the key-value pairs.
+func limitElement(fn ParserFn, isArray bool) ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		out, err := fn(ctx)
+		if err != nil || out.MatchStatus != MatchStatus_Matched {
+			return out, err
+		}
+		opts := ctx.Tag.(parseOptions)
+		limits := &opts.limits
+		state := opts.state
+
+		state.elements++
+		if isArray {
+			if err := limits.checkArrayLength(state.elements, ctx.SourcePosition); err != nil {
+				return limitError(ctx, err)
+			}
+		}
+		if err := limits.countPending(state, ctx.SourcePosition); err != nil {
+			return limitError(ctx, err)
+		}
+		return out, nil
+	}
+}
+
+// Check the length of the string.
+// The pieces of the string are checked by limitStringChunk while parsing,
+// and the string is checked after parsing it.
+func limitStringLength(fn ParserFn) ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		opts := ctx.Tag.(parseOptions)
+		limits := &opts.limits
+		if limits.stringLength <= 0 {
+			return fn(ctx)
+		}
+
+		state := opts.state
+		saved := state.str
+		state.str = stringLimitState{
+			active: true,
+			pos:    ctx.SourcePosition,
+			bottom: len(ctx.AstStack),
+			top:    len(ctx.AstStack),
+		}
+		defer func() {
+			state.str = saved
+		}()
+
+		out, err := fn(ctx)
+		if err != nil || out.MatchStatus != MatchStatus_Matched || len(out.AstStack) <= len(ctx.AstStack) {
+			return out, err
+		}
+		if v, ok := out.AstStack[len(out.AstStack)-1].Value.(string); ok {
+			if err := limits.checkStringLength(len(v), ctx.SourcePosition); err != nil {
+				return limitError(out, err)
+			}
+		}
+		return out, nil
+	}
+}
+
+// Check the length of the string pieces that are pushed since the last check.
+// It should be the last child of the loops of the string literals.
+func limitStringChunk() ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		opts := ctx.Tag.(parseOptions)
+		str := &opts.state.str
+		if !str.active {
+			return ctx, nil
+		}
+		if len(ctx.AstStack) < str.top {
+			// Rewound
+			str.top, str.length = str.bottom, 0
+		}
+		for _, ast := range ctx.AstStack[str.top:] {
+			if v, ok := ast.Value.(string); ok {
+				str.length += len(v)
+			}
+		}
+		str.top = len(ctx.AstStack)
+		if err := opts.limits.checkStringLength(str.length, str.pos); err != nil {
+			return limitError(ctx, err)
+		}
+		return ctx, nil
+	}
+}
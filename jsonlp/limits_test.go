@@ -0,0 +1,214 @@
+package jsonlp_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func TestLimits1(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		isTOML   bool
+		opts     jsonlp.ParseOptions
+		wantCode jsonlp.ErrorCode
+		wantLine int
+		wantCol  int
+	}{{
+		name:     "lim1-1a",
+		s:        "[[[1]]]",
+		opts:     jsonlp.ParseOptions{MaxDepth: 2},
+		wantCode: jsonlp.ErrorCode_DepthLimitExceeded,
+		wantLine: 1,
+		wantCol:  3,
+	}, {
+		name:     "lim1-1b",
+		s:        strings.Repeat("[", 100000),
+		opts:     jsonlp.ParseOptions{MaxDepth: 64},
+		wantCode: jsonlp.ErrorCode_DepthLimitExceeded,
+		wantLine: 1,
+		wantCol:  65,
+	}, {
+		name:     "lim1-1c",
+		s:        "a = { b = { c = [1] } }",
+		isTOML:   true,
+		opts:     jsonlp.ParseOptions{MaxDepth: 2},
+		wantCode: jsonlp.ErrorCode_DepthLimitExceeded,
+		wantLine: 1,
+		wantCol:  17,
+	}, {
+		name:     "lim1-2a",
+		s:        "[1, 2, 3]",
+		opts:     jsonlp.ParseOptions{MaxInputSize: 8},
+		wantCode: jsonlp.ErrorCode_InputTooLarge,
+		wantLine: 1,
+		wantCol:  9,
+	}, {
+		name:     "lim1-3a",
+		s:        "{a: 'abc',\n 'bcde': 1}",
+		opts:     jsonlp.ParseOptions{MaxStringLength: 3},
+		wantCode: jsonlp.ErrorCode_StringLengthLimitExceeded,
+		wantLine: 2,
+		wantCol:  2,
+	}, {
+		// The limits are checked while parsing. (The value is not terminated)
+		name:     "lim1-3b",
+		s:        `["abcdef`,
+		opts:     jsonlp.ParseOptions{MaxStringLength: 3},
+		wantCode: jsonlp.ErrorCode_StringLengthLimitExceeded,
+		wantLine: 1,
+		wantCol:  2,
+	}, {
+		name:     "lim1-3c",
+		s:        `["\n\n\n\n`,
+		opts:     jsonlp.ParseOptions{MaxStringLength: 3},
+		wantCode: jsonlp.ErrorCode_StringLengthLimitExceeded,
+		wantLine: 1,
+		wantCol:  2,
+	}, {
+		name:     "lim1-3d",
+		s:        "a = \"\"\"ab\ncd",
+		isTOML:   true,
+		opts:     jsonlp.ParseOptions{MaxStringLength: 3},
+		wantCode: jsonlp.ErrorCode_StringLengthLimitExceeded,
+		wantLine: 1,
+		wantCol:  5,
+	}, {
+		name:     "lim1-3e",
+		s:        "a = 'abcd",
+		isTOML:   true,
+		opts:     jsonlp.ParseOptions{MaxStringLength: 3, Strictness: jsonlp.Strictness_Strict},
+		wantCode: jsonlp.ErrorCode_StringLengthLimitExceeded,
+		wantLine: 1,
+		wantCol:  5,
+	}, {
+		name:     "lim1-4a",
+		s:        "{a: [1, 2], b: [1, 2, 3]}",
+		opts:     jsonlp.ParseOptions{MaxArrayLength: 2},
+		wantCode: jsonlp.ErrorCode_ArrayLengthLimitExceeded,
+		wantLine: 1,
+		wantCol:  23,
+	}, {
+		name:     "lim1-4c",
+		s:        "[1, 2, 3, ",
+		opts:     jsonlp.ParseOptions{MaxArrayLength: 2},
+		wantCode: jsonlp.ErrorCode_ArrayLengthLimitExceeded,
+		wantLine: 1,
+		wantCol:  8,
+	}, {
+		name:     "lim1-4d",
+		s:        "a = [1, 2, 3,",
+		isTOML:   true,
+		opts:     jsonlp.ParseOptions{MaxArrayLength: 2, Strictness: jsonlp.Strictness_Strict},
+		wantCode: jsonlp.ErrorCode_ArrayLengthLimitExceeded,
+		wantLine: 1,
+		wantCol:  12,
+	}, {
+		name:     "lim1-4b",
+		s:        "[[a]]\n[[a]]\n[[a]]\n",
+		isTOML:   true,
+		opts:     jsonlp.ParseOptions{MaxArrayLength: 2},
+		wantCode: jsonlp.ErrorCode_ArrayLengthLimitExceeded,
+		wantLine: 3,
+		wantCol:  3,
+	}, {
+		name:     "lim1-5a",
+		s:        "{a: 1, b: 2, c: 3}",
+		opts:     jsonlp.ParseOptions{MaxObjectMembers: 2},
+		wantCode: jsonlp.ErrorCode_MemberLimitExceeded,
+		wantLine: 1,
+		wantCol:  14,
+	}, {
+		name:     "lim1-5b",
+		s:        "[x.y]\na = 1\n[x]\nb = 2\nc = 3\n",
+		isTOML:   true,
+		opts:     jsonlp.ParseOptions{MaxObjectMembers: 2},
+		wantCode: jsonlp.ErrorCode_MemberLimitExceeded,
+		wantLine: 3,
+		wantCol:  2,
+	}, {
+		name:     "lim1-5c",
+		s:        "a.b = 1\na.c = 2\na.d = 3\n",
+		isTOML:   true,
+		opts:     jsonlp.ParseOptions{MaxObjectMembers: 2},
+		wantCode: jsonlp.ErrorCode_MemberLimitExceeded,
+		wantLine: 3,
+		wantCol:  1,
+	}, {
+		name:     "lim1-6a",
+		s:        "{a: [1, 2], b: {c: 3}}",
+		opts:     jsonlp.ParseOptions{MaxNodes: 4},
+		wantCode: jsonlp.ErrorCode_NodeLimitExceeded,
+		wantLine: 1,
+		wantCol:  13,
+	}, {
+		name:     "lim1-6b",
+		s:        "{a: [1, 2], b: {c: 3}}",
+		opts:     jsonlp.ParseOptions{MaxNodes: 4, ErrorRecovery: true},
+		wantCode: jsonlp.ErrorCode_NodeLimitExceeded,
+		wantLine: 1,
+		wantCol:  13,
+	}, {
+		name:     "lim1-6c",
+		s:        "[[1, 2], [3, ",
+		opts:     jsonlp.ParseOptions{MaxNodes: 3},
+		wantCode: jsonlp.ErrorCode_NodeLimitExceeded,
+		wantLine: 1,
+		wantCol:  11,
+	}, {
+		name:     "lim1-6d",
+		s:        "{a: 1, b: 2, c: ",
+		opts:     jsonlp.ParseOptions{MaxNodes: 1},
+		wantCode: jsonlp.ErrorCode_NodeLimitExceeded,
+		wantLine: 1,
+		wantCol:  8,
+	}, {
+		name:     "lim1-6e",
+		s:        "a = 1\nb = 2\nc = 3\nd = [",
+		isTOML:   true,
+		opts:     jsonlp.ParseOptions{MaxNodes: 2},
+		wantCode: jsonlp.ErrorCode_NodeLimitExceeded,
+		wantLine: 3,
+		wantCol:  1,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.isTOML {
+				_, err = jsonlp.ParseTOMLWithOptions(tt.s, &tt.opts)
+			} else {
+				_, err = jsonlp.ParseJSONWithOptions(tt.s, &tt.opts)
+			}
+			var se *jsonlp.SyntaxError
+			if !errors.As(err, &se) {
+				t.Errorf("Parse() error = %v, want *SyntaxError", err)
+				return
+			}
+			if se.Code != tt.wantCode || se.Line != tt.wantLine || se.Col != tt.wantCol {
+				t.Errorf("Parse() error = %v (%v), want %v at %v:%v", se.Code, se, tt.wantCode, tt.wantLine, tt.wantCol)
+			}
+		})
+	}
+
+	// The limits are not exceeded
+	opts := &jsonlp.ParseOptions{
+		MaxInputSize:     22,
+		MaxDepth:         2,
+		MaxStringLength:  1,
+		MaxArrayLength:   2,
+		MaxObjectMembers: 2,
+		MaxNodes:         5,
+	}
+	if _, err := jsonlp.ParseJSONWithOptions("{a: [1, 2], b: {c: 3}}", opts); err != nil {
+		t.Errorf("ParseJSONWithOptions() error = %v", err)
+	}
+
+	_, err := jsonlp.ParseJSONWithOptions("[1, 2, 3]", &jsonlp.ParseOptions{MaxInputSize: 8})
+	if !errors.Is(err, jsonlp.ErrInputTooLarge) {
+		t.Errorf("ParseJSONWithOptions() error = %v, want ErrInputTooLarge", err)
+	}
+}
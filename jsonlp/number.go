@@ -0,0 +1,181 @@
+package jsonlp
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Numeric literal that is returned if the Number_Lossless mode is set.
+// It keeps the original literal without the digit separators (`_`) and the type suffix.
+// (e.g. `1_000` -> `1000`, `0xff_ffu64` -> `0xffff`)
+type Number string
+
+// Original literal.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Convert the literal to int64.
+// Prefixes `0b`, `0o`, `0x` are also accepted.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 0, 64)
+}
+
+// Convert the literal to uint64.
+// Prefixes `0b`, `0o`, `0x` are also accepted.
+func (n Number) Uint64() (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(string(n), "+"), 0, 64)
+}
+
+// Convert the literal to float64.
+func (n Number) Float64() (float64, error) {
+	s := string(n)
+	if isRadixInteger(s) {
+		// NOTE: strconv.ParseFloat does not accept `0b` and `0o` prefixes without `p` exponent.
+		z, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return 0, &strconv.NumError{Func: "ParseFloat", Num: s, Err: strconv.ErrSyntax}
+		}
+		f, _ := new(big.Float).SetInt(z).Float64()
+		return f, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// Convert the literal to *big.Int.
+// Prefixes `0b`, `0o`, `0x` are also accepted.
+func (n Number) BigInt() (*big.Int, error) {
+	z, ok := new(big.Int).SetString(string(n), 0)
+	if !ok {
+		return nil, &strconv.NumError{Func: "BigInt", Num: string(n), Err: strconv.ErrSyntax}
+	}
+	return z, nil
+}
+
+// Convert the literal to *big.Float.
+// The precision is large enough to represent the integer literal exactly.
+func (n Number) BigFloat() (*big.Float, error) {
+	prec := uint(len(n)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(string(n), 0, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, &strconv.NumError{Func: "BigFloat", Num: string(n), Err: strconv.ErrSyntax}
+	}
+	return f, nil
+}
+
+func isRadixInteger(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	if len(s) < 2 || s[0] != '0' {
+		return false
+	}
+	switch s[1] {
+	case 'b', 'B', 'o', 'O':
+		return true
+	case 'x', 'X':
+		return !strings.ContainsAny(s, ".pP")
+	}
+	return false
+}
+
+// Kind of the numeric literal whose value is made by numberLiteral().
+type numberLiteralKind int
+
+const (
+	numberLiteral_Integer numberLiteralKind = iota
+	numberLiteral_Float
+	numberLiteral_BigInt // Integer with `n` suffix
+)
+
+// Returns the kind of the literal and true if the value should be made by numberLiteral().
+// suffix is `s64`, `u64`, `n` or the others.
+func numberLiteralKindOf(ctx ParserContext, suffix string, isFloat bool) (numberLiteralKind, bool) {
+	if suffix == "n" {
+		return numberLiteral_BigInt, true
+	}
+	switch ctx.Tag.(parseOptions).numberMode {
+	case Number_Lossless:
+	case Number_BigFloat, Number_BigRat:
+		switch suffix {
+		case "s64", "S64", "u64", "U64":
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if isFloat {
+		return numberLiteral_Float, true
+	}
+	return numberLiteral_Integer, true
+}
+
+// Placeholder of the value.
+// The value is replaced by numberLiteral().
+func numberLiteralAsts(kind numberLiteralKind) AstSlice {
+	return AstSlice{{
+		OpCode:    0,
+		ClassName: class.Number,
+		Type:      AstType_Any,
+		Value:     kind,
+	}}
+}
+
+// Replace the placeholder that is made by numberLiteralAsts() by the value of the source literal.
+func numberLiteral(fn ParserFn) ParserFn {
+	return func(ctx ParserContext) (ParserContext, error) {
+		out, err := fn(ctx)
+		if err != nil || out.MatchStatus != MatchStatus_Matched {
+			return out, err
+		}
+		if len(out.AstStack) == 0 {
+			return out, nil
+		}
+		ast := &out.AstStack[len(out.AstStack)-1]
+		kind, ok := ast.Value.(numberLiteralKind)
+		if !ok || ast.ClassName != class.Number {
+			return out, nil
+		}
+
+		lit := strings.ReplaceAll(ctx.Str[ctx.Position:out.Position], "_", "")
+		if strings.HasSuffix(lit, "n") {
+			lit = lit[:len(lit)-1]
+		} else if len(lit) > 3 {
+			switch strings.ToLower(lit[len(lit)-3:]) {
+			case "s64", "u64":
+				lit = lit[:len(lit)-3]
+			}
+		}
+		n := Number(lit)
+
+		mode := ctx.Tag.(parseOptions).numberMode
+		switch {
+		case kind == numberLiteral_BigInt || (kind == numberLiteral_Integer && mode != Number_Lossless):
+			z, err := n.BigInt()
+			if err != nil {
+				return ctx, err
+			}
+			*ast = Ast{ClassName: class.BigInt, Type: AstType_Any, Value: z}
+		case mode == Number_Lossless:
+			*ast = Ast{ClassName: class.Number, Type: AstType_String, Value: n}
+		case mode == Number_BigFloat:
+			z, err := n.BigFloat()
+			if err != nil {
+				return ctx, err
+			}
+			*ast = Ast{ClassName: class.BigFloat, Type: AstType_Any, Value: z}
+		default:
+			z, ok := new(big.Rat).SetString(lit)
+			if !ok {
+				return ctx, &strconv.NumError{Func: "BigRat", Num: lit, Err: strconv.ErrSyntax}
+			}
+			*ast = Ast{ClassName: class.BigRat, Type: AstType_Any, Value: z}
+		}
+		return out, nil
+	}
+}
@@ -0,0 +1,205 @@
+package jsonlp_test
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func TestNumberModeInteger1(t *testing.T) {
+	opts := &jsonlp.ParseOptions{NumberMode: jsonlp.Number_Integer}
+	tests := []testMatrixItem{{
+		name:    "ni1-1a",
+		args:    args{s: `[0, 123, -123, +123, 1_000]`, opts: opts},
+		want:    []interface{}{int64(0), int64(123), int64(-123), int64(123), int64(1000)},
+		wantErr: false,
+	}, {
+		name:    "ni1-1b",
+		args:    args{s: `[9007199254740993, 9223372036854775807, -9223372036854775808]`, opts: opts},
+		want:    []interface{}{int64(9007199254740993), int64(9223372036854775807), int64(-9223372036854775808)},
+		wantErr: false,
+	}, {
+		name:    "ni1-1c",
+		args:    args{s: `[9223372036854775808, 18446744073709551615]`, opts: opts},
+		want:    []interface{}{uint64(9223372036854775808), uint64(18446744073709551615)},
+		wantErr: false,
+	}, {
+		name:    "ni1-1d",
+		args:    args{s: `18446744073709551616`, opts: opts},
+		want:    nil,
+		wantErr: true,
+	}, {
+		name:    "ni1-1e",
+		args:    args{s: `-9223372036854775809`, opts: opts},
+		want:    nil,
+		wantErr: true,
+	}, {
+		name:    "ni1-2a",
+		args:    args{s: `[0x7fff_ffff_ffff_ffff, 0xffff_ffff_ffff_ffff, 0o17, 0b101]`, opts: opts},
+		want:    []interface{}{int64(0x7fffffffffffffff), uint64(0xffffffffffffffff), int64(15), int64(5)},
+		wantErr: false,
+	}, {
+		name:    "ni1-3a",
+		args:    args{s: `[1.0, 1e3, 0x1p4, 1s64, 1u64, Infinity]`, opts: opts},
+		want:    []interface{}{float64(1), float64(1000), float64(16), int64(1), uint64(1), math.Inf(1)},
+		wantErr: false,
+	}}
+
+	runMatrixParse(t, tests)
+}
+
+func TestNumberModeInteger2(t *testing.T) {
+	opts := &jsonlp.ParseOptions{NumberMode: jsonlp.Number_Integer}
+	tests := []testMatrixItem{{
+		name: "ni2-1a",
+		args: args{s: "a = 9007199254740993\nb = 0xdead_beef\nc = 3.14\n", opts: opts},
+		want: map[string]interface{}{
+			"a": int64(9007199254740993),
+			"b": int64(0xdeadbeef),
+			"c": float64(3.14),
+		},
+		wantErr: false,
+	}}
+
+	runMatrixTomlParse(t, tests)
+}
+
+func TestNumberModeLossless1(t *testing.T) {
+	opts := &jsonlp.ParseOptions{NumberMode: jsonlp.Number_Lossless}
+	tests := []testMatrixItem{{
+		name: "nl1-1a",
+		args: args{s: `[0, -123, 1_000, 12.50, -1.5e+3, .5, 123456789012345678901234567890]`, opts: opts},
+		want: []interface{}{
+			jsonlp.Number("0"), jsonlp.Number("-123"), jsonlp.Number("1000"), jsonlp.Number("12.50"),
+			jsonlp.Number("-1.5e+3"), jsonlp.Number(".5"), jsonlp.Number("123456789012345678901234567890"),
+		},
+		wantErr: false,
+	}, {
+		name: "nl1-1b",
+		args: args{s: `[0xff_ff, 0o17, 0b101, 0x1.8p1, 1s64, 2u64, 0xffU64]`, opts: opts},
+		want: []interface{}{
+			jsonlp.Number("0xffff"), jsonlp.Number("0o17"), jsonlp.Number("0b101"), jsonlp.Number("0x1.8p1"),
+			jsonlp.Number("1"), jsonlp.Number("2"), jsonlp.Number("0xff"),
+		},
+		wantErr: false,
+	}, {
+		name:    "nl1-1c",
+		args:    args{s: `[1e400, Infinity, 1+2i]`, opts: opts},
+		want:    []interface{}{jsonlp.Number("1e400"), math.Inf(1), complex(1, 2)},
+		wantErr: false,
+	}, {
+		name:    "nl1-1d",
+		args:    args{s: `-0x10`, opts: opts},
+		want:    nil,
+		wantErr: true,
+	}}
+
+	runMatrixParse(t, tests)
+}
+
+func TestNumber1(t *testing.T) {
+	tests := []struct {
+		n        jsonlp.Number
+		wantI    int64
+		wantIErr bool
+		wantU    uint64
+		wantUErr bool
+		wantF    float64
+		wantB    string
+		wantBErr bool
+	}{
+		{n: "123", wantI: 123, wantU: 123, wantF: 123, wantB: "123"},
+		{n: "-123", wantI: -123, wantUErr: true, wantF: -123, wantB: "-123"},
+		{n: "0xff", wantI: 255, wantU: 255, wantF: 255, wantB: "255"},
+		{n: "0b101", wantI: 5, wantU: 5, wantF: 5, wantB: "5"},
+		{n: "0o17", wantI: 15, wantU: 15, wantF: 15, wantB: "15"},
+		{n: "18446744073709551615", wantIErr: true, wantU: 18446744073709551615, wantF: 18446744073709551615, wantB: "18446744073709551615"},
+		{n: "1.5", wantIErr: true, wantUErr: true, wantF: 1.5, wantBErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.n.String(), func(t *testing.T) {
+			if i, err := tt.n.Int64(); (err != nil) != tt.wantIErr || (err == nil && i != tt.wantI) {
+				t.Errorf("%v: Int64() = %v, %v", tt.n, i, err)
+			}
+			if u, err := tt.n.Uint64(); (err != nil) != tt.wantUErr || (err == nil && u != tt.wantU) {
+				t.Errorf("%v: Uint64() = %v, %v", tt.n, u, err)
+			}
+			if f, err := tt.n.Float64(); err != nil || f != tt.wantF {
+				t.Errorf("%v: Float64() = %v, %v", tt.n, f, err)
+			}
+			if b, err := tt.n.BigInt(); (err != nil) != tt.wantBErr || (err == nil && b.String() != tt.wantB) {
+				t.Errorf("%v: BigInt() = %v, %v", tt.n, b, err)
+			}
+			if b, err := tt.n.BigFloat(); err != nil {
+				t.Errorf("%v: BigFloat() = %v, %v", tt.n, b, err)
+			} else if f, _ := b.Float64(); f != tt.wantF {
+				t.Errorf("%v: BigFloat() = %v, want %v", tt.n, b, tt.wantF)
+			}
+		})
+	}
+}
+
+func TestNumberModeBig1(t *testing.T) {
+	bigInt := func(s string) *big.Int {
+		z, _ := new(big.Int).SetString(s, 0)
+		return z
+	}
+	bigRat := func(s string) *big.Rat {
+		z, _ := new(big.Rat).SetString(s)
+		return z
+	}
+	tests := []testMatrixItem{{
+		name:    "nb1-1a",
+		args:    args{s: `-0x10n`, opts: &jsonlp.ParseOptions{NumberMode: jsonlp.Number_BigRat}},
+		want:    nil,
+		wantErr: true,
+	}, {
+		name: "nb1-1b",
+		args: args{s: `[123, 0x10n, 123456789012345678901234567890, 1s64, 2u64]`, opts: &jsonlp.ParseOptions{NumberMode: jsonlp.Number_BigRat}},
+		want: []interface{}{
+			bigInt("123"), bigInt("16"), bigInt("123456789012345678901234567890"), int64(1), uint64(2),
+		},
+		wantErr: false,
+	}, {
+		name:    "nb1-1c",
+		args:    args{s: `[0.1, -1.5e3, 0x1.8p1, Infinity]`, opts: &jsonlp.ParseOptions{NumberMode: jsonlp.Number_BigRat}},
+		want:    []interface{}{bigRat("1/10"), bigRat("-1500"), bigRat("3"), math.Inf(1)},
+		wantErr: false,
+	}, {
+		name:    "nb1-2a",
+		args:    args{s: `[123n, -123n, 1_000n, 0b11n]`},
+		want:    []interface{}{bigInt("123"), bigInt("-123"), bigInt("1000"), bigInt("3")},
+		wantErr: false,
+	}, {
+		name:    "nb1-2b",
+		args:    args{s: `123n`, opts: &jsonlp.ParseOptions{NumberMode: jsonlp.Number_Lossless}},
+		want:    bigInt("123"),
+		wantErr: false,
+	}, {
+		name:    "nb1-2c",
+		args:    args{s: `1.5n`},
+		want:    nil,
+		wantErr: true,
+	}}
+
+	runMatrixParse(t, tests)
+}
+
+func TestNumberModeBig2(t *testing.T) {
+	got, err := jsonlp.ParseTOMLWithOptions("a = 0.1\nb = 123456789012345678901234567890\n", &jsonlp.ParseOptions{
+		NumberMode: jsonlp.Number_BigFloat,
+	})
+	if err != nil {
+		t.Errorf("ParseTOMLWithOptions: error = %v", err)
+		return
+	}
+	m := got.(map[string]interface{})
+	if a, ok := m["a"].(*big.Float); !ok || a.Text('g', 10) != "0.1" {
+		t.Errorf("a = %v, want *big.Float(0.1)", m["a"])
+	}
+	if b, ok := m["b"].(*big.Int); !ok || b.String() != "123456789012345678901234567890" {
+		t.Errorf("b = %v, want *big.Int(123456789012345678901234567890)", m["b"])
+	}
+}
@@ -0,0 +1,197 @@
+package jsonlp
+
+import (
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Map that keeps the insertion order of the keys.
+// It is returned for objects and tables if ParseOptions.OrderedMap is set.
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// Create a new empty OrderedMap.
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{
+		keys:   make([]string, 0, 8),
+		values: make(map[string]interface{}),
+	}
+}
+
+// Number of the entries.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// Keys in the insertion order.
+func (m *OrderedMap) Keys() []string {
+	ret := make([]string, len(m.keys))
+	copy(ret, m.keys)
+	return ret
+}
+
+// Get the value of the key.
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Set the value of the key.
+// If the key already exists, the value is replaced and the order is not changed.
+// Otherwise, the key is appended to the end.
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Delete the key.
+func (m *OrderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Call fn for each entry in the insertion order.
+// If fn returns false, the iteration is stopped.
+func (m *OrderedMap) Range(fn func(key string, value interface{}) bool) {
+	for _, k := range m.keys {
+		if !fn(k, m.values[k]) {
+			break
+		}
+	}
+}
+
+// Shallow copy to the map. The order is lost.
+func (m *OrderedMap) ToMap() map[string]interface{} {
+	ret := make(map[string]interface{}, len(m.keys))
+	for k, v := range m.values {
+		ret[k] = v
+	}
+	return ret
+}
+
+func (m *OrderedMap) get(key string) (interface{}, bool) {
+	return m.Get(key)
+}
+
+func (m *OrderedMap) set(key string, value interface{}) {
+	m.Set(key, value)
+}
+
+func (m *OrderedMap) keyList() []string {
+	return m.keys
+}
+
+func (m *OrderedMap) length() int {
+	return m.Len()
+}
+
+func (m *OrderedMap) value() interface{} {
+	return m
+}
+
+// Object or table that is made by tableTransformer.
+// It is `map[string]interface{}` or `*OrderedMap`.
+type object interface {
+	get(key string) (interface{}, bool)
+	set(key string, value interface{})
+	keyList() []string
+	length() int
+	value() interface{}
+}
+
+type mapObject map[string]interface{}
+
+func (m mapObject) get(key string) (interface{}, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func (m mapObject) set(key string, value interface{}) {
+	m[key] = value
+}
+
+func (m mapObject) keyList() []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	return ret
+}
+
+func (m mapObject) length() int {
+	return len(m)
+}
+
+func (m mapObject) value() interface{} {
+	return map[string]interface{}(m)
+}
+
+func newObject(ctx ParserContext) object {
+	if ctx.Tag.(parseOptions).orderedMap {
+		return NewOrderedMap()
+	}
+	return mapObject(make(map[string]interface{}))
+}
+
+func asObject(v interface{}) (object, bool) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return mapObject(x), true
+	case *OrderedMap:
+		return x, true
+	}
+	return nil, false
+}
+
+// Append the table to the array of tables.
+// If cur is not an array of tables, it is overwritten.
+// NOTE: If overwrite, it is invalid TOML
+func appendArrayOfTables(ctx ParserContext, cur interface{}, table object) interface{} {
+	switch a := cur.(type) {
+	case []map[string]interface{}:
+		if x, ok := table.(mapObject); ok {
+			return append(a, x)
+		}
+		return a
+	case []*OrderedMap:
+		if x, ok := table.(*OrderedMap); ok {
+			return append(a, x)
+		}
+		return a
+	}
+
+	if ctx.Tag.(parseOptions).orderedMap {
+		a := make([]*OrderedMap, 0, 8)
+		if x, ok := table.(*OrderedMap); ok {
+			a = append(a, x)
+		}
+		return a
+	} else {
+		a := make([]map[string]interface{}, 0, 8)
+		if x, ok := table.(mapObject); ok {
+			a = append(a, x)
+		}
+		return a
+	}
+}
+
+func lengthOfArrayOfTables(v interface{}) int {
+	switch a := v.(type) {
+	case []map[string]interface{}:
+		return len(a)
+	case []*OrderedMap:
+		return len(a)
+	}
+	return 0
+}
@@ -0,0 +1,121 @@
+package jsonlp_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+// Convert *OrderedMap to the list of the key and value pairs to compare the order.
+func toPairs(v interface{}) interface{} {
+	switch x := v.(type) {
+	case *jsonlp.OrderedMap:
+		ret := make([]interface{}, 0, x.Len()*2)
+		x.Range(func(key string, value interface{}) bool {
+			ret = append(ret, key, toPairs(value))
+			return true
+		})
+		return ret
+	case []*jsonlp.OrderedMap:
+		ret := make([]interface{}, 0, len(x))
+		for _, e := range x {
+			ret = append(ret, toPairs(e))
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, 0, len(x))
+		for _, e := range x {
+			ret = append(ret, toPairs(e))
+		}
+		return ret
+	default:
+		return v
+	}
+}
+
+func TestOrderedMap1(t *testing.T) {
+	opts := &jsonlp.ParseOptions{OrderedMap: true}
+	tests := []struct {
+		name   string
+		s      string
+		isTOML bool
+		want   interface{}
+	}{{
+		name: "om1-1a",
+		s:    `{z: 1, y: {b: 2, a: 3}, x: [{q: 4, p: 5}], "w": 6, z: 7}`,
+		want: []interface{}{
+			"z", float64(7),
+			"y", []interface{}{"b", float64(2), "a", float64(3)},
+			"x", []interface{}{[]interface{}{"q", float64(4), "p", float64(5)}},
+			"w", float64(6),
+		},
+	}, {
+		name:   "om1-2a",
+		s:      "z = 1\ny.b = 2\ny.a = 3\n[c]\nq = 4\n[[b]]\nz = 5\ny = 6\n[[b]]\nx = 7\n[b.k]\nj = 8\n",
+		isTOML: true,
+		want: []interface{}{
+			"z", float64(1),
+			"y", []interface{}{"b", float64(2), "a", float64(3)},
+			"c", []interface{}{"q", float64(4)},
+			"b", []interface{}{
+				[]interface{}{"z", float64(5), "y", float64(6)},
+				[]interface{}{"x", float64(7), "k", []interface{}{"j", float64(8)}},
+			},
+		},
+	}, {
+		name:   "om1-2b",
+		s:      "[a.c.d]\ne = 1\n[a]\nf = 2\n[a.c]\ng = 3\n",
+		isTOML: true,
+		want: []interface{}{
+			"a", []interface{}{
+				"c", []interface{}{
+					"d", []interface{}{"e", float64(1)},
+					"g", float64(3),
+				},
+				"f", float64(2),
+			},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got interface{}
+			var err error
+			if tt.isTOML {
+				got, err = jsonlp.ParseTOMLWithOptions(tt.s, opts)
+			} else {
+				got, err = jsonlp.ParseJSONWithOptions(tt.s, opts)
+			}
+			if err != nil {
+				t.Errorf("%v: error = %v", tt.name, err)
+				return
+			}
+			if p := toPairs(got); !reflect.DeepEqual(p, tt.want) {
+				t.Errorf("%v: got = %v, want %v", tt.name, p, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderedMap2(t *testing.T) {
+	m := jsonlp.NewOrderedMap()
+	m.Set("c", 1)
+	m.Set("a", 2)
+	m.Set("b", 3)
+	m.Set("a", 4)
+	m.Delete("c")
+	m.Delete("x")
+
+	if !reflect.DeepEqual(m.Keys(), []string{"a", "b"}) {
+		t.Errorf("Keys() = %v", m.Keys())
+	}
+	if v, ok := m.Get("a"); !ok || v != 4 {
+		t.Errorf("Get(a) = %v, %v", v, ok)
+	}
+	if _, ok := m.Get("c"); ok {
+		t.Errorf("Get(c) should not be found")
+	}
+	if !reflect.DeepEqual(m.ToMap(), map[string]interface{}{"a": 4, "b": 3}) {
+		t.Errorf("ToMap() = %v", m.ToMap())
+	}
+}
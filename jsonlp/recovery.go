@@ -0,0 +1,215 @@
+package jsonlp
+
+import (
+	"sort"
+	"strings"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Returns the position to resume parsing after the error at pos.
+type syncFn func(s string, pos int) int
+
+// Skip the string literal that starts at pos and returns the position of the closing quote.
+// Single line strings are terminated by the line break.
+func skipQuoted(s string, pos int) int {
+	q := s[pos]
+	if q != '`' && strings.HasPrefix(s[pos:], string([]byte{q, q, q})) {
+		if end := strings.Index(s[pos+3:], string([]byte{q, q, q})); end >= 0 {
+			return pos + 3 + end + 2
+		}
+		return len(s) - 1
+	}
+	for i := pos + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			return i
+		case '\r', '\n':
+			if q != '`' {
+				return i - 1
+			}
+		}
+	}
+	return len(s) - 1
+}
+
+// Skip the comment that starts at pos and returns the last position of the comment.
+// If it is not a comment, returns -1.
+func skipComment(s string, pos int) int {
+	switch {
+	case s[pos] == '#' || strings.HasPrefix(s[pos:], "//"):
+		if end := strings.IndexAny(s[pos:], "\r\n"); end >= 0 {
+			return pos + end - 1
+		}
+		return len(s) - 1
+	case strings.HasPrefix(s[pos:], "/*"):
+		if end := strings.Index(s[pos+2:], "*/"); end >= 0 {
+			return pos + 2 + end + 1
+		}
+		return len(s) - 1
+	}
+	return -1
+}
+
+// Resynchronize at one of the stop characters or at the unbalanced closing bracket.
+// The line break in the stop characters matches at any nesting level.
+func syncTo(stops string) syncFn {
+	stopAtLb := strings.ContainsAny(stops, "\r\n")
+	return func(s string, pos int) int {
+		depth := 0
+		for i := pos; i < len(s); i++ {
+			switch c := s[i]; c {
+			case '"', '\'', '`':
+				i = skipQuoted(s, i)
+			case '#', '/':
+				if end := skipComment(s, i); end >= 0 {
+					i = end
+				}
+			case '[', '{':
+				depth++
+			case ']', '}':
+				if depth == 0 {
+					return i
+				}
+				depth--
+			case '\r', '\n':
+				if stopAtLb {
+					return i
+				}
+			default:
+				if depth == 0 && strings.IndexByte(stops, c) >= 0 {
+					return i
+				}
+			}
+		}
+		return len(s)
+	}
+}
+
+// Resynchronize after the closing bracket.
+// If an unbalanced closing bracket of the other type is found, resynchronize at it.
+func syncToClose(closer byte) syncFn {
+	sync := syncTo("")
+	return func(s string, pos int) int {
+		i := sync(s, pos)
+		if i < len(s) && s[i] == closer {
+			i++
+		}
+		return i
+	}
+}
+
+// Resynchronize at the next line that starts with the TOML table header.
+func syncToTableHeader(s string, pos int) int {
+	for i := pos; i < len(s); i++ {
+		if s[i] != '\r' && s[i] != '\n' {
+			continue
+		}
+		j := i + 1
+		for j < len(s) && (s[j] == ' ' || s[j] == '\t') {
+			j++
+		}
+		if j < len(s) && s[j] == '[' {
+			return j
+		}
+	}
+	return len(s)
+}
+
+// Resynchronize at the end of the source.
+func syncToEnd(s string, pos int) int {
+	return len(s)
+}
+
+// If the error recovery is enabled, record the error that is raised by fn and
+// resume parsing at the position that is returned by sync.
+// If placeholder is true, null is pushed instead of the value.
+func recoverable(fn ParserFn, sync syncFn, placeholder bool) ParserFn {
+	return LightBaseParser("Recoverable", func(ctx ParserContext) (ParserContext, error) {
+		out, err := fn(ctx)
+		if err == nil {
+			return out, nil
+		}
+		opts := ctx.Tag.(parseOptions)
+		if !opts.recovery || isFatalError(err) {
+			return out, err
+		}
+		opts.state.errors = append(opts.state.errors, newSyntaxError(ctx.Str, out.SourcePosition, err))
+
+		pos := out.Position
+		if pos < ctx.Position {
+			pos = ctx.Position
+		}
+		pos = sync(ctx.Str, pos)
+
+		ret := ctx
+		ret.AstStack = ctx.AstStack[:len(ctx.AstStack):len(ctx.AstStack)]
+		if placeholder {
+			ast := nilAst
+			ast.SourcePosition = ctx.SourcePosition
+			ret.AstStack = append(ret.AstStack, ast)
+		}
+		ret.Position = pos
+		ret.Length = pos - ctx.Position
+		ret.MatchStatus = MatchStatus_Matched
+		return ret, nil
+	})
+}
+
+// If the error recovery is enabled, record the error and skip to the end of line.
+// It matches only if the current line does not start with the TOML table header.
+func recoverTomlLine(code ErrorCode, msg string) ParserFn {
+	err := newParseError(code, msg)
+	sync := syncTo("\n")
+	return LightBaseParser("RecoverLine", func(ctx ParserContext) (ParserContext, error) {
+		ctx.Length = 0
+		ctx.MatchStatus = MatchStatus_Unmatched
+		opts := ctx.Tag.(parseOptions)
+		if !opts.recovery || len(ctx.Str) <= ctx.Position || ctx.Str[ctx.Position] == '[' {
+			return ctx, nil
+		}
+		opts.state.errors = append(opts.state.errors, newSyntaxError(ctx.Str, ctx.SourcePosition, err))
+
+		pos := sync(ctx.Str, ctx.Position)
+		if pos == ctx.Position {
+			pos++
+		}
+		ctx.Length = pos - ctx.Position
+		ctx.Position = pos
+		ctx.MatchStatus = MatchStatus_Matched
+		return ctx, nil
+	})
+}
+
+// Returns the best-effort partial value and all errors that are collected in the error recovery mode.
+func parseResultWithRecovery(s string, out ParserContext, err error, state *parseState) (interface{}, error) {
+	var value interface{}
+	errs := state.errors
+
+	if err != nil {
+		errs = append(errs, newSyntaxError(s, out.SourcePosition, err))
+	} else if out.MatchStatus != MatchStatus_Matched {
+		errs = append(errs, newSyntaxError(s, out.SourcePosition, nil))
+	} else if 0 < len(out.AstStack) {
+		value = out.AstStack[0].Value
+	}
+
+	if len(errs) == 0 {
+		return value, nil
+	}
+
+	// The same error may be recorded more than once by backtracking.
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Offset < errs[j].Offset
+	})
+	uniq := errs[:1]
+	for _, e := range errs[1:] {
+		last := uniq[len(uniq)-1]
+		if e.Offset != last.Offset || e.Code != last.Code {
+			uniq = append(uniq, e)
+		}
+	}
+	return value, uniq
+}
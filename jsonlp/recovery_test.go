@@ -0,0 +1,173 @@
+package jsonlp_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+type recoveryTestItem struct {
+	name      string
+	s         string
+	isTOML    bool
+	want      interface{}
+	wantCodes []jsonlp.ErrorCode
+	wantLines []int
+}
+
+func runMatrixRecovery(t *testing.T, tests []recoveryTestItem) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &jsonlp.ParseOptions{ErrorRecovery: true}
+			var got interface{}
+			var err error
+			if tt.isTOML {
+				got, err = jsonlp.ParseTOMLWithOptions(tt.s, opts)
+			} else {
+				got, err = jsonlp.ParseJSONWithOptions(tt.s, opts)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%v: Parse() = %v, want %v", tt.name, got, tt.want)
+			}
+
+			if len(tt.wantCodes) == 0 {
+				if err != nil {
+					t.Errorf("%v: Parse() error = %v, want nil", tt.name, err)
+				}
+				return
+			}
+
+			var errs jsonlp.SyntaxErrors
+			if !errors.As(err, &errs) {
+				t.Errorf("%v: Parse() error = %v, want SyntaxErrors", tt.name, err)
+				return
+			}
+			codes := make([]jsonlp.ErrorCode, len(errs))
+			lines := make([]int, len(errs))
+			for i, e := range errs {
+				codes[i] = e.Code
+				lines[i] = e.Line
+			}
+			if !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("%v: Codes = %v, want %v", tt.name, codes, tt.wantCodes)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("%v: Lines = %v, want %v", tt.name, lines, tt.wantLines)
+			}
+
+			var se *jsonlp.SyntaxError
+			if !errors.As(err, &se) || se != errs.First() {
+				t.Errorf("%v: errors.As(*SyntaxError) = %v, want %v", tt.name, se, errs.First())
+			}
+		})
+	}
+}
+
+func TestRecovery1(t *testing.T) {
+	tests := []recoveryTestItem{{
+		name: "r1-1a",
+		s:    `{a: [1, 2], b: 'c'}`,
+		want: map[string]interface{}{"a": []interface{}{float64(1), float64(2)}, "b": "c"},
+	}, {
+		name:      "r1-2a",
+		s:         `[1, ;, 2]`,
+		want:      []interface{}{float64(1), nil, float64(2)},
+		wantCodes: []jsonlp.ErrorCode{jsonlp.ErrorCode_ExpectArrayValue},
+		wantLines: []int{1},
+	}, {
+		name: "r1-2b",
+		s: `{
+			a: ,
+			b: 2,
+			c: [1, 2 x, 3],
+			d: {e: 1,, f: 2},
+		}`,
+		want: map[string]interface{}{
+			"a": nil,
+			"b": float64(2),
+			"c": []interface{}{float64(1), float64(2)},
+			"d": map[string]interface{}{"e": float64(1), "f": float64(2)},
+		},
+		wantCodes: []jsonlp.ErrorCode{
+			jsonlp.ErrorCode_ExpectValue,
+			jsonlp.ErrorCode_ExpectArrayClose,
+			jsonlp.ErrorCode_ExpectObjectMember,
+		},
+		wantLines: []int{2, 4, 5},
+	}, {
+		name:      "r1-2c",
+		s:         `[1, 99999999999999999999999s64, 3]`,
+		want:      []interface{}{float64(1), nil, float64(3)},
+		wantCodes: []jsonlp.ErrorCode{jsonlp.ErrorCode_InvalidNumber},
+		wantLines: []int{1},
+	}, {
+		name:      "r1-2d",
+		s:         "[1] 2",
+		want:      []interface{}{float64(1)},
+		wantCodes: []jsonlp.ErrorCode{jsonlp.ErrorCode_ExpectTermination},
+		wantLines: []int{1},
+	}, {
+		name:      "r1-2e",
+		s:         "[1, 'abc",
+		want:      []interface{}{float64(1), nil},
+		wantCodes: []jsonlp.ErrorCode{jsonlp.ErrorCode_UnterminatedString, jsonlp.ErrorCode_ExpectArrayClose},
+		wantLines: []int{1, 1},
+	}, {
+		name:      "r1-2f",
+		s:         ";",
+		want:      nil,
+		wantCodes: []jsonlp.ErrorCode{jsonlp.ErrorCode_ParseFailed},
+		wantLines: []int{1},
+	}}
+
+	runMatrixRecovery(t, tests)
+}
+
+func TestRecovery2(t *testing.T) {
+	tests := []recoveryTestItem{{
+		name:   "r2-1a",
+		s:      "a = 1\n[b]\nc = 2\n",
+		isTOML: true,
+		want:   map[string]interface{}{"a": float64(1), "b": map[string]interface{}{"c": float64(2)}},
+	}, {
+		name: "r2-2a",
+		s: `a = 1
+b =
+c = 3
+!!! garbage
+[t]
+x = 1
+??
+y = 2
+[u
+z = 1
+[v] junk
+w = 1
+[[q]]
+r = [1, 2 x, 3]
+`,
+		isTOML: true,
+		want: map[string]interface{}{
+			"a": float64(1),
+			"b": nil,
+			"c": float64(3),
+			"t": map[string]interface{}{"x": float64(1), "y": float64(2)},
+			"v": map[string]interface{}{"w": float64(1)},
+			"q": []map[string]interface{}{{"r": []interface{}{float64(1), float64(2)}}},
+		},
+		wantCodes: []jsonlp.ErrorCode{
+			jsonlp.ErrorCode_ExpectValue,
+			jsonlp.ErrorCode_ExpectTermination,
+			jsonlp.ErrorCode_ExpectTermination,
+			jsonlp.ErrorCode_ExpectTableClose,
+			jsonlp.ErrorCode_ExpectLinebreak,
+			jsonlp.ErrorCode_ExpectArrayClose,
+		},
+		wantLines: []int{2, 4, 7, 9, 11, 14},
+	}}
+
+	runMatrixRecovery(t, tests)
+}
@@ -1,10 +1,12 @@
 package jsonlp
 
 import (
+	"context"
+
 	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
 	. "github.com/shellyln/takenoco/base"
 	"github.com/shellyln/takenoco/extra"
-	. "github.com/shellyln/takenoco/string"
+	strparser "github.com/shellyln/takenoco/string"
 )
 
 type InteropType int
@@ -17,6 +19,24 @@ const (
 	Interop_TOML_AsNull
 )
 
+type StrictnessType int
+
+const (
+	Strictness_None StrictnessType = iota
+	Strictness_NoRedefinition
+	Strictness_Strict
+)
+
+type NumberModeType int
+
+const (
+	Number_Float NumberModeType = iota
+	Number_Integer
+	Number_Lossless
+	Number_BigFloat
+	Number_BigRat
+)
+
 type PlatformLinebreakType int
 
 const (
@@ -25,10 +45,209 @@ const (
 	Linebreak_Cr
 )
 
+// Options of the parser.
+//
+// The zero value is the default options.
+// New fields may be added in the future without breaking changes.
+type ParseOptions struct {
+	// Platform-dependent line break. (`Linebreak_Lf` | `Linebreak_CrLf` | `Linebreak_Cr`)
+	// Line break codes in multi-line string are replaced by this specified line break.
+	// (Excluding line breaks by escape sequences)
+	PlatformLinebreak PlatformLinebreakType
+
+	// If Interop_JSON is set, replace NaN, Infinity, complex number by `{nan:true}`, `{inf:+/-1}`, `{re:re,im:im}`.
+	// If Interop_TOML is set, replace complex number by `{re:re,im:im}`.
+	// If Interop_JSON_AsNull is set, replace NaN, Infinity, complex number by null.
+	// If Interop_TOML_AsNull is set, replace complex number by null.
+	Interop InteropType
+
+	// If true, the parser does not stop at the first syntax error.
+	// It resynchronizes at `,`, `}`, `]` and TOML table headers, and
+	// returns a best-effort partial value and all errors as `SyntaxErrors`.
+	ErrorRecovery bool
+
+	// If Strictness_NoRedefinition is set, reject duplicate object keys,
+	// redefinition of TOML tables, dotted keys that extend inline tables,
+	// and TOML arrays of tables that conflict with static arrays.
+	// If Strictness_Strict is set, the TOML parsers accept TOML 1.0 only and reject all extensions.
+	// Integers are parsed as int64 in Number_Float and Number_Integer mode, and overflows are errors.
+	// It also implies Strictness_NoRedefinition.
+	// The JSON parsers treat it as Strictness_NoRedefinition.
+	Strictness StrictnessType
+
+	// Dialect of the JSON parsers. (`Dialect_Loose` | `Dialect_StrictJSON` | `Dialect_JSON5` | `Dialect_JSONC`)
+	// If it is not Dialect_Loose, the extensions that the dialect does not have
+	// fail with ErrorCode_ExtensionNotAllowed.
+	// The TOML parsers ignore it.
+	Dialect DialectType
+
+	// Extensions that the JSON parsers accept. (e.g. `jsonlp.Features_All &^ jsonlp.Feature_DateTime`)
+	// If it is not 0, it overrides Dialect.
+	// (Features_StrictJSON and the sets that are made from the Features_* constants are not 0)
+	// The loose TOML parsers use it only for the extensions that TOML 1.0 does not have.
+	// (Feature_LineComment, Feature_BlockComment, Feature_BackQuote, Feature_ArrowSeparator,
+	// Feature_Undefined, Feature_CaseInsensitive, Feature_NumberSuffix and Feature_Complex)
+	// Strictness_Strict ignores it.
+	Features Features
+
+	// If Number_Integer is set, integer literals without suffix are parsed as int64.
+	// If the value overflows int64, it is parsed as uint64.
+	// If it also overflows uint64, it is an error.
+	// Numbers with the fraction or exponent part are parsed as float64 in any mode.
+	// If Number_Lossless is set, all numeric literals (excluding Infinity and NaN) are
+	// returned as `Number` that keeps the original literal.
+	// If Number_BigFloat or Number_BigRat is set, integer literals are parsed as *big.Int,
+	// and the others are parsed as *big.Float or *big.Rat.
+	// Numbers with `s64`, `u64` suffix are parsed as int64, uint64 except in Number_Lossless mode.
+	// Integers with `n` suffix (e.g. `123n`) are parsed as *big.Int in any mode.
+	NumberMode NumberModeType
+
+	// If true, objects and tables are returned as `*OrderedMap` that keeps the order of the keys.
+	// Arrays of tables are returned as `[]*OrderedMap`.
+	OrderedMap bool
+
+	// Maximum size of the input in bytes.
+	// If zero, there is no limit.
+	// ParseJSONReader and ParseTOMLReader return ErrInputTooLarge without reading the rest of the input.
+	// The other functions return the SyntaxError with ErrorCode_InputTooLarge that wraps ErrInputTooLarge.
+	MaxInputSize int64
+
+	// Maximum nesting depth of the arrays and objects (including TOML inline tables).
+	// TOML tables that are defined by the headers and the dotted keys are not counted.
+	// If zero, there is no limit.
+	MaxDepth int
+
+	// Maximum length of the strings (values and quoted keys) in bytes after unescaping.
+	// If zero, there is no limit.
+	MaxStringLength int
+
+	// Maximum number of the elements of an array (including TOML arrays of tables).
+	// If zero, there is no limit.
+	MaxArrayLength int
+
+	// Maximum number of the members of an object or a table.
+	// If zero, there is no limit.
+	MaxObjectMembers int
+
+	// Maximum total number of the array elements and the key-value pairs in the document.
+	// If zero, there is no limit.
+	MaxNodes int
+
+	// If true, the JSON parsers do not use the hand-written fast path for the strict JSON subset.
+	// The results are the same either way.
+	DisableFastPath bool
+
+	// If true, local dates, local times and local datetimes (datetimes without the offset)
+	// are returned as `LocalDate`, `LocalTime` and `LocalDateTime`.
+	// Offset datetimes are returned as time.Time in any mode.
+	// If false, they are returned as time.Time in UTC.
+	// (local date: 00:00:00 UTC, local time: 1970-01-01 UTC)
+	LocalDateTime bool
+}
+
+var parseOptsDefault = ParseOptions{}
+
 type parseOptions struct {
 	interop           InteropType
 	platformLinebreak string
 	isTOML            bool
+	recovery          bool
+	strictness        StrictnessType
+	features          Features
+	numberMode        NumberModeType
+	orderedMap        bool
+	fastPath          bool
+	localDateTime     bool
+	limits            parseLimits
+	state             *parseState
+}
+
+// Mutable state shared by the parsers while parsing a document.
+type parseState struct {
+	errors SyntaxErrors
+	depth  int // Current nesting depth of the arrays and objects
+	nodes  int // Number of the array elements and the key-value pairs
+
+	// Elements of the arrays and the tables that are being parsed.
+	// They are added to the nodes after the array or the table is parsed.
+	pending  int // Number of the elements of all containers that are being parsed
+	elements int // Number of the elements of the innermost container
+	str      stringLimitState
+
+	ctx   context.Context // Context of ParseJSONContext and ParseTOMLContext
+	ticks int             // Number of the values that are parsed since the start
+}
+
+func newParseOptions(opts *ParseOptions, isTOML bool) parseOptions {
+	if opts == nil {
+		opts = &parseOptsDefault
+	}
+	ret := parseOptions{
+		interop:           opts.Interop,
+		platformLinebreak: "\n",
+		isTOML:            isTOML,
+		recovery:          opts.ErrorRecovery,
+		strictness:        opts.Strictness,
+		features:          opts.Dialect.Features(),
+		numberMode:        opts.NumberMode,
+		orderedMap:        opts.OrderedMap,
+		fastPath:          !opts.DisableFastPath,
+		localDateTime:     opts.LocalDateTime,
+		limits: parseLimits{
+			inputSize:    opts.MaxInputSize,
+			depth:        opts.MaxDepth,
+			stringLength: opts.MaxStringLength,
+			arrayLength:  opts.MaxArrayLength,
+			members:      opts.MaxObjectMembers,
+			nodes:        opts.MaxNodes,
+		},
+		state: &parseState{},
+	}
+	switch opts.PlatformLinebreak {
+	case Linebreak_CrLf:
+		ret.platformLinebreak = "\r\n"
+	case Linebreak_Cr:
+		ret.platformLinebreak = "\r"
+	}
+	if isTOML {
+		ret.features = Features_All
+		if opts.Features != 0 {
+			ret.features = opts.Features.normalize() | features_TOML
+		}
+	} else if opts.Features != 0 {
+		ret.features = opts.Features.normalize()
+	}
+	return ret
+}
+
+// Run the document parser and return the value of the document.
+func parse(parser ParserFn, s string, opts parseOptions) (interface{}, error) {
+	if err := opts.limits.checkInputSize(s); err != nil {
+		return nil, err
+	}
+	if opts.state.ctx != nil {
+		if err := opts.state.ctx.Err(); err != nil {
+			return nil, newSyntaxError(s, SourcePosition{}, err)
+		}
+	}
+
+	ctx := *strparser.NewStringParserContext(s)
+	ctx.Tag = opts
+
+	out, err := parser(ctx)
+	if opts.recovery {
+		return parseResultWithRecovery(s, out, err, opts.state)
+	}
+
+	if err != nil {
+		return nil, newSyntaxError(s, out.SourcePosition, err)
+	}
+
+	if out.MatchStatus == MatchStatus_Matched {
+		return out.AstStack[0].Value, nil
+	} else {
+		return nil, newSyntaxError(s, out.SourcePosition, nil)
+	}
 }
 
 // Remove the resulting AST.
@@ -37,99 +256,123 @@ func erase(fn ParserFn) ParserFn {
 }
 
 // Whitespaces
-func sp0() ParserFn {
-	return erase(ZeroOrMoreTimes(First(Whitespace(), comment())))
+func sp0(f Features) ParserFn {
+	return erase(ZeroOrMoreTimes(First(whitespace(f), comment(f))))
+}
+
+func whitespace(f Features) ParserFn {
+	extended := First(
+		strparser.Whitespace(),
+		strparser.CharClassFn(isExtendedWhitespaceRune),
+	)
+	if f.has(Feature_ExtendedWhitespace) {
+		return extended
+	}
+	return First(
+		strparser.CharClass(" ", "\t", "\r", "\n"),
+		extension(f, Feature_ExtendedWhitespace, nil, extended),
+	)
 }
 
 // Whitespaces
-func sp0NoLb() ParserFn {
-	return erase(ZeroOrMoreTimes(First(WhitespaceNoLineBreak(), commentLookAheadLb())))
+func sp0NoLb(f Features) ParserFn {
+	return erase(ZeroOrMoreTimes(First(strparser.WhitespaceNoLineBreak(), commentLookAheadLb(f))))
 }
 
 // Whitespaces
-func sp1NoLb() ParserFn {
-	return erase(OneOrMoreTimes(First(WhitespaceNoLineBreak(), commentLookAheadLb())))
+func sp1NoLb(f Features) ParserFn {
+	return erase(OneOrMoreTimes(First(strparser.WhitespaceNoLineBreak(), commentLookAheadLb(f))))
 }
 
 func lineComment(lookAheadLb bool) ParserFn {
-	return erase(FlatGroup(
-		Seq("//"),
-		FlatGroup(
-			ZeroOrMoreTimes(CharClassN("\r\n", "\n", "\r")),
-			First(
-				If(lookAheadLb,
-					LookAhead(CharClass("\r\n", "\n", "\r")),
-					CharClass("\r\n", "\n", "\r"),
-				),
-				LookAhead(End()),
-			),
-		),
-	))
+	return lineCommentOf("//", lookAheadLb)
 }
 
 func hashLineComment(lookAheadLb bool) ParserFn {
+	return lineCommentOf("#", lookAheadLb)
+}
+
+// Line comment that starts with the prefix.
+func lineCommentOf(prefix string, lookAheadLb bool) ParserFn {
 	return erase(FlatGroup(
-		Seq("#"),
+		strparser.Seq(prefix),
 		FlatGroup(
-			ZeroOrMoreTimes(CharClassN("\r\n", "\n", "\r")),
+			ZeroOrMoreTimes(strparser.CharClassN("\r\n", "\n", "\r")),
 			First(
 				If(lookAheadLb,
-					LookAhead(CharClass("\r\n", "\n", "\r")),
-					CharClass("\r\n", "\n", "\r"),
+					LookAhead(strparser.CharClass("\r\n", "\n", "\r")),
+					strparser.CharClass("\r\n", "\n", "\r"),
 				),
-				LookAhead(End()),
+				LookAhead(strparser.End()),
 			),
 		),
 	))
 }
 
 func blockComment() ParserFn {
+	return blockCommentOf("/*", "*/")
+}
+
+// Block comment that is enclosed by open and close.
+func blockCommentOf(open, close string) ParserFn {
 	return erase(FlatGroup(
-		Seq("/*"),
-		ZeroOrMoreTimes(CharClassN("*/")),
+		strparser.Seq(open),
+		ZeroOrMoreTimes(strparser.CharClassN(close)),
 		First(
-			Seq("*/"),
-			Error("An unexpected termination has appeared in the block comment."),
+			strparser.Seq(close),
+			syntaxError(ErrorCode_UnterminatedComment, "An unexpected termination has appeared in the block comment."),
 		),
 	))
 }
 
-func comment() ParserFn {
-	return First(lineComment(false), hashLineComment(false), blockComment())
+func comment(f Features) ParserFn {
+	return First(
+		extension(f, Feature_LineComment, lineComment(false), strparser.Seq("//")),
+		extension(f, Feature_HashComment, hashLineComment(false), strparser.Seq("#")),
+		extension(f, Feature_BlockComment, blockComment(), strparser.Seq("/*")),
+	)
 }
 
-func commentLookAheadLb() ParserFn {
-	return First(lineComment(true), hashLineComment(true), blockComment())
+func commentLookAheadLb(f Features) ParserFn {
+	return First(
+		extension(f, Feature_LineComment, lineComment(true), strparser.Seq("//")),
+		extension(f, Feature_HashComment, hashLineComment(true), strparser.Seq("#")),
+		extension(f, Feature_BlockComment, blockComment(), strparser.Seq("/*")),
+	)
 }
 
-func trueValue() ParserFn {
+func trueValue(f Features) ParserFn {
 	return FlatGroup(
-		erase(SeqI("true")),
+		erase(keyword(f, "true")),
 		extra.UnicodeWordBoundary(),
 		Zero(trueAst),
 	)
 }
 
-func falseValue() ParserFn {
+func falseValue(f Features) ParserFn {
 	return FlatGroup(
-		erase(SeqI("false")),
+		erase(keyword(f, "false")),
 		extra.UnicodeWordBoundary(),
 		Zero(falseAst),
 	)
 }
 
-func boolValue() ParserFn {
+func boolValue(f Features) ParserFn {
 	return First(
-		trueValue(),
-		falseValue(),
+		trueValue(f),
+		falseValue(f),
 	)
 }
 
-func nullValue() ParserFn {
+func nullValue(f Features) ParserFn {
+	undefined := strparser.CharClass("undefined", "None")
 	return FlatGroup(
 		erase(First(
-			SeqI("null"),
-			CharClass("undefined", "None"),
+			keyword(f, "null"),
+			extension(f, Feature_Undefined, undefined, FlatGroup(
+				undefined,
+				extra.UnicodeWordBoundary(),
+			)),
 		)),
 		extra.UnicodeWordBoundary(),
 		Zero(nilAst),
@@ -141,10 +384,10 @@ func positiveInfinityValue(checkBoundary bool) ParserFn {
 	infParser := Zero(positiveInfinityAst)
 	return FlatGroup(
 		erase(FlatGroup(
-			ZeroOrOnce(Seq("+")),
+			ZeroOrOnce(strparser.Seq("+")),
 			First(
-				SeqI("Infinity"),
-				SeqI("inf"),
+				strparser.SeqI("Infinity"),
+				strparser.SeqI("inf"),
 			),
 		)),
 		If(checkBoundary,
@@ -176,8 +419,8 @@ func negativeInfinityValue(checkBoundary bool) ParserFn {
 	infParser := Zero(negativeInfinityAst)
 	return FlatGroup(
 		erase(First(
-			SeqI("-Infinity"),
-			SeqI("-inf"),
+			strparser.SeqI("-Infinity"),
+			strparser.SeqI("-inf"),
 		)),
 		If(checkBoundary,
 			extra.UnicodeWordBoundary(),
@@ -208,8 +451,8 @@ func nanValue(checkBoundary bool) ParserFn {
 	nanParser := Zero(nanAst)
 	return FlatGroup(
 		erase(FlatGroup(
-			ZeroOrOnce(CharClass("+", "-")),
-			SeqI("nan"),
+			ZeroOrOnce(strparser.CharClass("+", "-")),
+			strparser.SeqI("nan"),
 		)),
 		If(checkBoundary,
 			extra.UnicodeWordBoundary(),
@@ -235,15 +478,15 @@ func nanValue(checkBoundary bool) ParserFn {
 	)
 }
 
-func radixNumberParser(prefix string, radix int, radixNumbrrStr ParserFn) ParserFn {
+func radixNumberParser(prefix string, radix int, signed bool, radixNumbrrStr ParserFn) ParserFn {
 	return Trans(
 		FlatGroup(
 			First(
-				CharClass("+", "-"),
+				strparser.CharClass("+", "-"),
 				Zero(Ast{Type: AstType_String, Value: ""}),
 			),
-			FlatGroup(erase(SeqI(prefix))),
-			erase(ZeroOrMoreTimes(Seq("_"))),
+			FlatGroup(erase(strparser.SeqI(prefix))),
+			erase(ZeroOrMoreTimes(strparser.Seq("_"))),
 			First(
 				FlatGroup(
 					First(
@@ -251,68 +494,87 @@ func radixNumberParser(prefix string, radix int, radixNumbrrStr ParserFn) Parser
 							Trans(
 								FlatGroup(
 									radixNumbrrStr,
-									Seq("."),
-									erase(ZeroOrMoreTimes(Seq("_"))),
+									strparser.Seq("."),
+									erase(ZeroOrMoreTimes(strparser.Seq("_"))),
 									ZeroOrOnce(radixNumbrrStr),
 								),
-								Concat,
+								strparser.Concat,
 							),
-							SeqI("p"),
+							strparser.SeqI("p"),
 						),
 						FlatGroup(
 							Trans(
 								FlatGroup(
-									Seq("."),
-									erase(ZeroOrMoreTimes(Seq("_"))),
+									strparser.Seq("."),
+									erase(ZeroOrMoreTimes(strparser.Seq("_"))),
 									radixNumbrrStr,
 								),
-								Concat,
+								strparser.Concat,
 							),
-							SeqI("p"),
+							strparser.SeqI("p"),
 						),
-						FlatGroup(radixNumbrrStr, SeqI("p")),
+						FlatGroup(radixNumbrrStr, strparser.SeqI("p")),
 					),
 					extra.IntegerNumberStr(),
 				),
 				FlatGroup(
 					radixNumbrrStr,
 					First(
-						SeqI("s64"),
-						SeqI("u64"),
+						strparser.SeqI("s64"),
+						strparser.SeqI("u64"),
+						strparser.Seq("n"),
 						Zero(Ast{Value: "f"}),
 					),
 				),
 			),
 		),
-		radixNumberTransformer(prefix, radix),
+		radixNumberTransformer(prefix, radix, signed),
 	)
 }
 
-func numberValueInner(checkBoundary bool) ParserFn {
+// The signed radix integers (e.g. `-0xff`) are accepted only if f does not have Feature_ExtendedNumber,
+// because TOML does not allow them.
+func numberValueInner(f Features, checkBoundary bool) ParserFn {
+	signed := !f.has(Feature_ExtendedNumber)
 	return First(
 		FlatGroup(
-			First(
-				radixNumberParser("0b", 2, extra.BinaryNumberStr()),
-				radixNumberParser("0o", 8, extra.OctalNumberStr()),
-				radixNumberParser("0x", 16, extra.HexNumberStr()),
+			numberLiteral(Trans(
+				// Trailing decimal point (e.g. `5.`)
+				FlatGroup(
+					erase(ZeroOrOnce(strparser.Seq("+"))),
+					extra.IntegerNumberStr(),
+					strparser.Seq("."),
+					LookAheadN(strparser.CharClassFn(func(c rune) bool {
+						return c == '.' || isIdentifierRune(c)
+					})),
+				),
+				strparser.Concat,
+				floatNumberTransformer,
+			)),
+		),
+		FlatGroup(
+			numberLiteral(First(
+				radixNumberParser("0b", 2, signed, extra.BinaryNumberStr()),
+				radixNumberParser("0o", 8, signed, extra.OctalNumberStr()),
+				radixNumberParser("0x", 16, signed, extra.HexNumberStr()),
 				Trans(
 					extra.FloatNumberStr(),
-					ParseFloat,
-					ChangeClassName(class.Float),
+					floatNumberTransformer,
 				),
 				Trans(
 					FlatGroup(
-						erase(ZeroOrOnce(Seq("+"))),
+						erase(ZeroOrOnce(strparser.Seq("+"))),
 						extra.IntegerNumberStr(),
 						First(
-							FlatGroup(SeqI("s64")),
-							FlatGroup(SeqI("u64")),
+							FlatGroup(strparser.SeqI("s64")),
+							FlatGroup(strparser.SeqI("u64")),
+							FlatGroup(strparser.Seq("n")),
 							FlatGroup(Zero(Ast{Value: "f"})),
 						),
 					),
 					decimalNumberTransformer,
 				),
-			),
+			)),
 			If(checkBoundary,
 				extra.UnicodeWordBoundary(),
 				Zero(),
@@ -324,34 +586,38 @@ func numberValueInner(checkBoundary bool) ParserFn {
 	)
 }
 
-func numberValue() ParserFn {
-	return Trans(
+func numberValue(f Features) ParserFn {
+	fn := Trans(
 		FlatGroup(
-			numberValueInner(true),
+			numberValueInner(f, true),
 			ZeroOrOnce(
-				sp0NoLb(),
-				CharClass("+", "-"),
-				sp0NoLb(),
-				numberValueInner(false),
-				erase(ZeroOrMoreTimes(Seq("_"))),
-				erase(Seq("i")),
+				sp0NoLb(f),
+				strparser.CharClass("+", "-"),
+				sp0NoLb(f),
+				numberValueInner(f, false),
+				erase(ZeroOrMoreTimes(strparser.Seq("_"))),
+				erase(strparser.Seq("i")),
 				extra.UnicodeWordBoundary(),
 			),
 		),
 		numberOrComplexTransform,
 	)
+	if f.has(Feature_JSON5Number | Feature_ExtendedNumber | Feature_NumberSuffix | Feature_Complex) {
+		return fn
+	}
+	return checkExtensions(f, fn, numberFeaturesOf)
 }
 
-func stringValue() ParserFn {
-	tomlStr := tomlStringValue()
-	jsonStr := jsonStringValue()
-	return func(ctx ParserContext) (ParserContext, error) {
+func stringValue(f Features) ParserFn {
+	tomlStr := tomlStringValue(f)
+	jsonStr := jsonStringValue(f)
+	return limitStringLength(func(ctx ParserContext) (ParserContext, error) {
 		if ctx.Tag.(parseOptions).isTOML {
 			return tomlStr(ctx)
 		} else {
 			return jsonStr(ctx)
 		}
-	}
+	})
 }
 
 func dateValue() ParserFn {
@@ -360,7 +626,20 @@ func dateValue() ParserFn {
 			extra.DateStr(),
 			extra.UnicodeWordBoundary(),
 		),
-		extra.ParseDate,
+		func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+			if !ctx.Tag.(parseOptions).localDateTime {
+				return extra.ParseDate(ctx, asts)
+			}
+			v, err := ParseLocalDate(asts[len(asts)-1].Value.(string))
+			if err != nil {
+				return nil, err
+			}
+			return AstSlice{{
+				ClassName: class.LocalDate,
+				Type:      AstType_Any,
+				Value:     v,
+			}}, nil
+		},
 	)
 }
 
@@ -370,7 +649,28 @@ func dateTimeValue() ParserFn {
 			dateTimeStr(),
 			extra.UnicodeWordBoundary(),
 		),
-		extra.ParseDateTime,
+		func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+			s := asts[len(asts)-1].Value.(string)
+			if hasOffset(s) {
+				return extra.ParseDateTime(ctx, asts)
+			}
+			if !ctx.Tag.(parseOptions).localDateTime {
+				// Treat as UTC
+				return extra.ParseDateTime(ctx, AstSlice{{
+					Type:  AstType_String,
+					Value: s + "+00:00",
+				}})
+			}
+			v, err := ParseLocalDateTime(s)
+			if err != nil {
+				return nil, err
+			}
+			return AstSlice{{
+				ClassName: class.LocalDateTime,
+				Type:      AstType_Any,
+				Value:     v,
+			}}, nil
+		},
 	)
 }
 
@@ -380,20 +680,35 @@ func timeValue() ParserFn {
 			extra.TimeStr(),
 			extra.UnicodeWordBoundary(),
 		),
-		extra.ParseTime,
+		func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+			if !ctx.Tag.(parseOptions).localDateTime {
+				return extra.ParseTime(ctx, asts)
+			}
+			v, err := ParseLocalTime(asts[len(asts)-1].Value.(string))
+			if err != nil {
+				return nil, err
+			}
+			return AstSlice{{
+				ClassName: class.LocalTime,
+				Type:      AstType_Any,
+				Value:     v,
+			}}, nil
+		},
 	)
 }
 
-func primitiveValue() ParserFn {
-	return First(
-		stringValue(),
-		boolValue(),
-		nullValue(),
-		timeValue(),
-		dateTimeValue(),
-		dateValue(),
-		numberValue(),
-	)
+func primitiveValue(f Features) ParserFn {
+	return cancelable(First(
+		stringValue(f),
+		boolValue(f),
+		nullValue(f),
+		extension(f, Feature_DateTime, First(
+			timeValue(),
+			dateTimeValue(),
+			dateValue(),
+		), nil),
+		numberValue(f),
+	))
 }
 
 func identifier() ParserFn {
@@ -403,40 +718,67 @@ func identifier() ParserFn {
 	)
 }
 
-func dottedIdentifier(allowLb bool) ParserFn {
+// Identifier that also accepts the Unicode escape sequences. (e.g. `\u0061`)
+func escapedIdentifier() ParserFn {
+	return Trans(
+		unicodeIdentifierStr(),
+		ChangeClassName(class.Idenitifier),
+	)
+}
+
+// Unquoted key. (e.g. `a`, `bare-key`)
+// If escape is true, the Unicode escape sequences of ECMAScript IdentifierName are also accepted.
+// (The loose TOML keys do not accept them.)
+func unquotedKey(f Features, escape bool) ParserFn {
+	id := identifier()
+	if escape {
+		id = escapedIdentifier()
+	}
+	return extension(f, Feature_UnquotedKey,
+		If(f.has(Feature_BareKey),
+			id,
+			checkExtensions(f, id, identifierFeaturesOf),
+		),
+		id,
+	)
+}
+
+func dottedIdentifier(gs *grammarSpec, allowLb bool) ParserFn {
 	return Trans(
 		FlatGroup(
 			First(
-				stringValue(),
-				identifier(),
+				stringValue(gs.f),
+				gs.unquotedKey,
 			),
 			OneOrMoreTimes(
 				If(allowLb,
-					sp0(),
-					sp0NoLb(),
+					sp0(gs.f),
+					sp0NoLb(gs.f),
 				),
-				erase(CharClass(".")),
+				erase(strparser.CharClass(".")),
 				If(allowLb,
-					sp0(),
-					sp0NoLb(),
+					sp0(gs.f),
+					sp0NoLb(gs.f),
 				),
 				First(
-					stringValue(),
-					identifier(),
+					stringValue(gs.f),
+					gs.unquotedKey,
 				),
 			),
 		),
-		func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
-			length := len(asts)
-			v := make([]string, length)
-			for i := 0; i < length; i++ {
-				v[i] = asts[i].Value.(string)
-			}
-			return AstSlice{{
-				ClassName: class.DottedIdenitifier,
-				Type:      AstType_ListOfAny,
-				Value:     v,
-			}}, nil
-		},
+		dottedKeyTransformer,
 	)
 }
+
+func dottedKeyTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	length := len(asts)
+	v := make([]string, length)
+	for i := 0; i < length; i++ {
+		v[i] = asts[i].Value.(string)
+	}
+	return AstSlice{{
+		ClassName: class.DottedIdenitifier,
+		Type:      AstType_ListOfAny,
+		Value:     v,
+	}}, nil
+}
@@ -0,0 +1,309 @@
+package jsonlp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Span in the source.
+type Span struct {
+	Start Position // Start of the span (inclusive)
+	End   Position // End of the span (exclusive)
+}
+
+// Source location of the parsed value.
+type SourceLocation struct {
+	// Span of the key.
+	// For the TOML table that is defined by the header, it is the key in the header.
+	// For the array elements and the root, it is the zero value.
+	Key Span
+
+	// Span of the value.
+	// For the TOML table that is defined by the header, it is the span of the section.
+	// For the table that is implicitly made by the dotted key, it is the span of the key-value pair or the section.
+	Value Span
+
+	// Key spans of the later definitions that extend the table.
+	// (e.g. `[a]` after `[a.b]`, `a.c = 1` after `a.b = 1`, and the second `[[a]]`)
+	// Key and Value are the first (defining) position.
+	Extensions []Span
+}
+
+// Map from the path of the value to the source location.
+//
+// The path is made by SourcePath. (e.g. `servers[2].port`, `a."b.c"`)
+// The root is the empty path.
+type SourceMap map[string]*SourceLocation
+
+// Make the path of the SourceMap.
+//
+// elems: string (key) | int (index of the array)
+//
+// Keys that are not bare keys are quoted by double quotes. (e.g. `a."b.c"`)
+func SourcePath(elems ...interface{}) string {
+	var sb strings.Builder
+	for _, e := range elems {
+		switch x := e.(type) {
+		case int:
+			sb.WriteByte('[')
+			sb.WriteString(strconv.Itoa(x))
+			sb.WriteByte(']')
+		case string:
+			if sb.Len() != 0 {
+				sb.WriteByte('.')
+			}
+			writePathKey(&sb, x)
+		}
+	}
+	return sb.String()
+}
+
+func writePathKey(sb *strings.Builder, k string) {
+	if isIdentifier(k) {
+		sb.WriteString(k)
+		return
+	}
+	sb.WriteByte('"')
+	writeEscapedString(sb, k, '"')
+	sb.WriteByte('"')
+}
+
+func appendPathKey(path, k string) string {
+	var sb strings.Builder
+	sb.WriteString(path)
+	if path != "" {
+		sb.WriteByte('.')
+	}
+	writePathKey(&sb, k)
+	return sb.String()
+}
+
+func appendPathIndex(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+type sourceMapBuilder struct {
+	lineStarts []int
+	m          SourceMap
+	tables     map[string]bool // Paths of the tables (objects)
+	aot        map[string]int  // Number of the elements of the arrays of tables
+}
+
+func newSourceMapBuilder(s string) *sourceMapBuilder {
+	lineStarts := []int{0}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\r':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+			lineStarts = append(lineStarts, i+1)
+		case '\n':
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	return &sourceMapBuilder{
+		lineStarts: lineStarts,
+		m:          make(SourceMap),
+		tables:     make(map[string]bool),
+		aot:        make(map[string]int),
+	}
+}
+
+func (b *sourceMapBuilder) position(offset int) Position {
+	line := sort.Search(len(b.lineStarts), func(i int) bool {
+		return b.lineStarts[i] > offset
+	}) - 1
+	return Position{
+		Line:   line + 1,
+		Col:    offset - b.lineStarts[line] + 1,
+		Offset: offset,
+	}
+}
+
+func (b *sourceMapBuilder) span(start, end int) Span {
+	return Span{Start: b.position(start), End: b.position(end)}
+}
+
+// Register the location of the path.
+// If the path is the table that is already defined, the key span is added as the extension.
+// Otherwise, the location is overwritten as the parsed value is.
+func (b *sourceMapBuilder) define(path string, key, value Span, isTable bool) {
+	if loc, ok := b.m[path]; ok && isTable && b.tables[path] {
+		loc.Extensions = append(loc.Extensions, key)
+		return
+	}
+	b.m[path] = &SourceLocation{Key: key, Value: value}
+	b.tables[path] = isTable
+}
+
+// End offsets of the key parts.
+// The key span of the n-th part is from the start of the key to the end of the n-th part.
+func keyPartEnds(key *Node) []int {
+	ret := make([]int, 0, 1)
+	for _, c := range key.Children {
+		if c.Kind == Node_Value {
+			ret = append(ret, c.End)
+		}
+	}
+	return ret
+}
+
+// End of the node excluding the trailing whitespaces and comments.
+func significantEnd(n *Node) int {
+	for i := len(n.Children) - 1; i >= 0; i-- {
+		if c := n.Children[i]; c.Kind != Node_Whitespace && c.Kind != Node_Comment {
+			return c.End
+		}
+	}
+	return n.Start
+}
+
+func (b *sourceMapBuilder) value(path string, n *Node) {
+	switch n.Kind {
+	case Node_Object:
+		b.members(path, n)
+	case Node_Array:
+		i := 0
+		for _, c := range n.Children {
+			switch c.Kind {
+			case Node_Whitespace, Node_Comment, Node_Punct:
+				continue
+			}
+			p := appendPathIndex(path, i)
+			b.define(p, Span{}, b.span(c.Start, c.End), c.Kind == Node_Object)
+			b.value(p, c)
+			i++
+		}
+	}
+}
+
+// Register the members of the object or the TOML section.
+func (b *sourceMapBuilder) members(path string, container *Node) {
+	for _, m := range container.Children {
+		if m.Kind != Node_Member {
+			continue
+		}
+		key := m.Children[0]
+		names := key.Value.([]string)
+		ends := keyPartEnds(key)
+		v := memberValue(m)
+
+		p := path
+		for j, name := range names {
+			p = appendPathKey(p, name)
+			if j < len(names)-1 {
+				b.define(p, b.span(key.Start, ends[j]), b.span(m.Start, m.End), true)
+			} else {
+				b.define(p, b.span(key.Start, ends[j]), b.span(v.Start, v.End), v.Kind == Node_Object)
+			}
+		}
+		b.value(p, v)
+	}
+}
+
+// Register the TOML table or the array of tables, and its members.
+func (b *sourceMapBuilder) section(s *Node) {
+	var key *Node
+	for _, c := range s.Children[0].Children {
+		if c.Kind == Node_Key {
+			key = c
+		}
+	}
+	names := key.Value.([]string)
+	ends := keyPartEnds(key)
+	value := b.span(s.Start, significantEnd(s))
+
+	p := ""
+	for j, name := range names {
+		p = appendPathKey(p, name)
+		keySpan := b.span(key.Start, ends[j])
+		if j < len(names)-1 {
+			// `[a.b]` after `[[a]]` extends the last element of `a`.
+			if n, ok := b.aot[p]; ok {
+				p = appendPathIndex(p, n-1)
+			}
+			b.define(p, keySpan, value, true)
+			continue
+		}
+
+		b.define(p, keySpan, value, true)
+		if s.Kind == Node_ArrayOfTables {
+			n := b.aot[p]
+			b.aot[p] = n + 1
+			p = appendPathIndex(p, n)
+			b.define(p, keySpan, value, true)
+		}
+	}
+	b.members(p, s)
+}
+
+func (b *sourceMapBuilder) jsonDocument(root *Node) SourceMap {
+	for _, c := range root.Children {
+		switch c.Kind {
+		case Node_Whitespace, Node_Comment:
+			continue
+		}
+		b.define("", Span{}, b.span(c.Start, c.End), c.Kind == Node_Object)
+		b.value("", c)
+	}
+	return b.m
+}
+
+func (b *sourceMapBuilder) tomlDocument(root *Node) SourceMap {
+	b.define("", Span{}, b.span(root.Start, root.End), true)
+	b.members("", root)
+	for _, c := range root.Children {
+		if c.Kind == Node_Table || c.Kind == Node_ArrayOfTables {
+			b.section(c)
+		}
+	}
+	return b.m
+}
+
+// Parse the document and build the source map.
+func parseWithSourceMap(valueParser, cstParser ParserFn, s string, opts parseOptions) (interface{}, SourceMap, error) {
+	opts.recovery = false
+	v, err := parse(valueParser, s, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	root, err := buildCST(cstParser, s, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b := newSourceMapBuilder(s)
+	if opts.isTOML {
+		return v, b.tomlDocument(root), nil
+	}
+	return v, b.jsonDocument(root), nil
+}
+
+// src: Loose JSON
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+// ErrorRecovery is ignored.
+//
+// Returns the parsed value and the source map of the keys and values.
+func ParseJSONWithSourceMap(s string, opts *ParseOptions) (interface{}, SourceMap, error) {
+	o := newParseOptions(opts, false)
+	return parseWithSourceMap(jsonGrammarOf(o).document, jsonCstParser, s, o)
+}
+
+// src: Loose TOML
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+// ErrorRecovery is ignored.
+//
+// Returns the parsed value and the source map of the keys and values.
+// Dotted keys, merged tables and arrays of tables are resolved to the paths of the parsed value.
+func ParseTOMLWithSourceMap(s string, opts *ParseOptions) (interface{}, SourceMap, error) {
+	o := newParseOptions(opts, true)
+	return parseWithSourceMap(tomlParserOf(o), tomlCstParser, s, o)
+}
@@ -0,0 +1,105 @@
+package jsonlp_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func TestSourceMap1(t *testing.T) {
+	type loc struct {
+		path       string
+		key        string // "line:col-line:col"
+		value      string
+		extensions int
+	}
+	tests := []struct {
+		name   string
+		s      string
+		isTOML bool
+		want   []loc
+	}{{
+		name: "srcmap1-1",
+		s:    "{\n  // c\n  \"a\": [1, {b: 2}],\n  c.d: true\n}",
+		want: []loc{
+			{"", "", "1:1-5:2", 0},
+			{"a", "3:3-3:6", "3:8-3:19", 0},
+			{"a[0]", "", "3:9-3:10", 0},
+			{"a[1].b", "3:13-3:14", "3:16-3:17", 0},
+			{"c", "4:3-4:4", "4:3-4:12", 0},
+			{"c.d", "4:3-4:6", "4:8-4:12", 0},
+		},
+	}, {
+		name: "srcmap1-2",
+		s: "title = 'x'\r\n" +
+			"[x.y.z]\r\n" +
+			"w = 1\r\n" +
+			"[x]\r\n" +
+			"v.u = 2 # c\r\n" +
+			"v.t = 3\r\n" +
+			"[[servers]]\r\n" +
+			"port = 80\r\n" +
+			"[[servers]]\r\n" +
+			"port = 81\r\n" +
+			"[servers.opt]\r\n" +
+			"\"a.b\" = 1\r\n",
+		isTOML: true,
+		want: []loc{
+			{"title", "1:1-1:6", "1:9-1:12", 0},
+			{"x", "2:2-2:3", "2:1-3:6", 1},
+			{"x.y", "2:2-2:5", "2:1-3:6", 0},
+			{"x.y.z", "2:2-2:7", "2:1-3:6", 0},
+			{"x.y.z.w", "3:1-3:2", "3:5-3:6", 0},
+			{"x.v", "5:1-5:2", "5:1-5:8", 1},
+			{"x.v.u", "5:1-5:4", "5:7-5:8", 0},
+			{"x.v.t", "6:1-6:4", "6:7-6:8", 0},
+			{"servers", "7:3-7:10", "7:1-8:10", 1},
+			{"servers[0]", "7:3-7:10", "7:1-8:10", 0},
+			{"servers[0].port", "8:1-8:5", "8:8-8:10", 0},
+			{"servers[1].port", "10:1-10:5", "10:8-10:10", 0},
+			{"servers[1]", "9:3-9:10", "9:1-10:10", 1},
+			{"servers[1].opt", "11:2-11:13", "11:1-12:10", 0},
+			{jsonlp.SourcePath("servers", 1, "opt", "a.b"), "12:1-12:6", "12:9-12:10", 0},
+		},
+	}}
+
+	spanString := func(s jsonlp.Span) string {
+		if s.End.Offset == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%v:%v-%v:%v", s.Start.Line, s.Start.Col, s.End.Line, s.End.Col)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m jsonlp.SourceMap
+			var err error
+			if tt.isTOML {
+				_, m, err = jsonlp.ParseTOMLWithSourceMap(tt.s, nil)
+			} else {
+				_, m, err = jsonlp.ParseJSONWithSourceMap(tt.s, nil)
+			}
+			if err != nil {
+				t.Errorf("ParseWithSourceMap() error = %v", err)
+				return
+			}
+			for _, w := range tt.want {
+				l, ok := m[w.path]
+				if !ok {
+					t.Errorf("%v: not found", w.path)
+					continue
+				}
+				if got := spanString(l.Key); w.key != got {
+					t.Errorf("%v: Key = %v, want %v", w.path, got, w.key)
+				}
+				if got := spanString(l.Value); w.value != got {
+					t.Errorf("%v: Value = %v, want %v", w.path, got, w.value)
+				}
+				if len(l.Extensions) != w.extensions {
+					t.Errorf("%v: Extensions = %v, want %v", w.path, len(l.Extensions), w.extensions)
+				}
+			}
+		})
+	}
+}
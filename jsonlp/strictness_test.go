@@ -0,0 +1,206 @@
+package jsonlp_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func TestStrictness1(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		isTOML    bool
+		wantCode  jsonlp.ErrorCode
+		wantLine  int
+		wantCol   int
+		wantFirst *jsonlp.Position
+	}{{
+		name:      "s1-1a",
+		s:         `{"a": 1, "a": 2}`,
+		wantCode:  jsonlp.ErrorCode_DuplicateKey,
+		wantLine:  1,
+		wantCol:   10,
+		wantFirst: &jsonlp.Position{Line: 1, Col: 2, Offset: 1},
+	}, {
+		name:      "s1-1b",
+		s:         "{\n  a: {b: 1},\n  'a': 3\n}",
+		wantCode:  jsonlp.ErrorCode_DuplicateKey,
+		wantLine:  3,
+		wantCol:   3,
+		wantFirst: &jsonlp.Position{Line: 2, Col: 3, Offset: 4},
+	}, {
+		name:      "s1-2a",
+		s:         "a = 1\na = 2\n",
+		isTOML:    true,
+		wantCode:  jsonlp.ErrorCode_DuplicateKey,
+		wantLine:  2,
+		wantCol:   1,
+		wantFirst: &jsonlp.Position{Line: 1, Col: 1, Offset: 0},
+	}, {
+		name:      "s1-2b",
+		s:         "[a]\nb = 1\n[a]\nc = 2\n",
+		isTOML:    true,
+		wantCode:  jsonlp.ErrorCode_TableRedefinition,
+		wantLine:  3,
+		wantCol:   2,
+		wantFirst: &jsonlp.Position{Line: 1, Col: 2, Offset: 1},
+	}, {
+		name:      "s1-2c",
+		s:         "a.b = 1\n[a]\n",
+		isTOML:    true,
+		wantCode:  jsonlp.ErrorCode_TableRedefinition,
+		wantLine:  2,
+		wantCol:   2,
+		wantFirst: &jsonlp.Position{Line: 1, Col: 1, Offset: 0},
+	}, {
+		name:      "s1-2d",
+		s:         "a = {b = 1}\na.c = 2\n",
+		isTOML:    true,
+		wantCode:  jsonlp.ErrorCode_InlineTableExtension,
+		wantLine:  2,
+		wantCol:   1,
+		wantFirst: &jsonlp.Position{Line: 1, Col: 1, Offset: 0},
+	}, {
+		name:      "s1-2e",
+		s:         "a = [1]\n[[a]]\n",
+		isTOML:    true,
+		wantCode:  jsonlp.ErrorCode_ArrayOfTablesConflict,
+		wantLine:  2,
+		wantCol:   3,
+		wantFirst: &jsonlp.Position{Line: 1, Col: 1, Offset: 0},
+	}, {
+		name:      "s1-2f",
+		s:         "[[a]]\n[a]\n",
+		isTOML:    true,
+		wantCode:  jsonlp.ErrorCode_ArrayOfTablesConflict,
+		wantLine:  2,
+		wantCol:   2,
+		wantFirst: &jsonlp.Position{Line: 1, Col: 3, Offset: 2},
+	}, {
+		name:      "s1-2g",
+		s:         "x = {a = 1, a = 2}\n",
+		isTOML:    true,
+		wantCode:  jsonlp.ErrorCode_DuplicateKey,
+		wantLine:  1,
+		wantCol:   13,
+		wantFirst: &jsonlp.Position{Line: 1, Col: 6, Offset: 5},
+	}, {
+		name:      "s1-2h",
+		s:         "[a]\nb.c = 1\n[a.b.d]\n[a.b]\n",
+		isTOML:    true,
+		wantCode:  jsonlp.ErrorCode_TableRedefinition,
+		wantLine:  4,
+		wantCol:   2,
+		wantFirst: &jsonlp.Position{Line: 2, Col: 1, Offset: 4},
+	}}
+	opts := &jsonlp.ParseOptions{
+		Strictness: jsonlp.Strictness_NoRedefinition,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.isTOML {
+				_, err = jsonlp.ParseTOMLWithOptions(tt.s, opts)
+			} else {
+				_, err = jsonlp.ParseJSONWithOptions(tt.s, opts)
+			}
+			var se *jsonlp.SyntaxError
+			if !errors.As(err, &se) {
+				t.Errorf("%v: err = %v, want *SyntaxError", tt.name, err)
+				return
+			}
+			if se.Code != tt.wantCode {
+				t.Errorf("%v: Code = %v, want %v", tt.name, se.Code, tt.wantCode)
+			}
+			if se.Line != tt.wantLine || se.Col != tt.wantCol {
+				t.Errorf("%v: Line, Col = %v, %v, want %v, %v", tt.name, se.Line, se.Col, tt.wantLine, tt.wantCol)
+			}
+			if !reflect.DeepEqual(se.FirstDefinition, tt.wantFirst) {
+				t.Errorf("%v: FirstDefinition = %v, want %v", tt.name, se.FirstDefinition, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestStrictness2(t *testing.T) {
+	// Valid documents should be parsed as same as the default mode.
+	tests := []struct {
+		name   string
+		s      string
+		isTOML bool
+	}{
+		{name: "s2-1a", s: `{"a": {"b": 1}, "c": [{"b": 1}, {"b": 2}]}`},
+		{name: "s2-2a", s: "[a.b]\nc = 1\n[a]\nd = 2\n", isTOML: true},
+		{name: "s2-2b", s: "a.b = 1\na.c = 2\n", isTOML: true},
+		{name: "s2-2c", s: "[[a]]\nb = 1\n[[a]]\nb = 2\n[a.c]\nd = 1\n", isTOML: true},
+		{name: "s2-2d", s: "[a]\nb.c = 1\n[a.d]\ne = 1\n", isTOML: true},
+		{name: "s2-2e", s: "[[a]]\n[a.b.c]\nx = 1\n[[a]]\n[a.b.c]\ny = 2\n", isTOML: true},
+	}
+	opts := &jsonlp.ParseOptions{
+		Strictness: jsonlp.Strictness_NoRedefinition,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, want interface{}
+			var err error
+			if tt.isTOML {
+				want, _ = jsonlp.ParseTOMLWithOptions(tt.s, nil)
+				got, err = jsonlp.ParseTOMLWithOptions(tt.s, opts)
+			} else {
+				want, _ = jsonlp.ParseJSONWithOptions(tt.s, nil)
+				got, err = jsonlp.ParseJSONWithOptions(tt.s, opts)
+			}
+			if err != nil {
+				t.Errorf("%v: err = %v", tt.name, err)
+				return
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%v: got = %v, want %v", tt.name, got, want)
+			}
+		})
+	}
+}
+
+func TestStrictness3(t *testing.T) {
+	// The first conflict in the source order is reported.
+	tests := []struct {
+		name      string
+		s         string
+		wantFirst *jsonlp.Position
+	}{{
+		name:      "s3-1a",
+		s:         "[a.b]\nx = 1\n[a.c]\ny = 1\n[a]\nc = 2\nb = 3\n",
+		wantFirst: &jsonlp.Position{Line: 3, Col: 2, Offset: 13},
+	}, {
+		name:      "s3-1b",
+		s:         "[a.b]\nx = 1\n[a.c]\ny = 1\n[a]\nb = 3\nc = 2\n",
+		wantFirst: &jsonlp.Position{Line: 1, Col: 2, Offset: 1},
+	}}
+	opts := &jsonlp.ParseOptions{
+		Strictness: jsonlp.Strictness_NoRedefinition,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Repeat it because the order of the map iteration is random.
+			for i := 0; i < 20; i++ {
+				_, err := jsonlp.ParseTOMLWithOptions(tt.s, opts)
+				var se *jsonlp.SyntaxError
+				if !errors.As(err, &se) {
+					t.Errorf("%v: err = %v, want *SyntaxError", tt.name, err)
+					return
+				}
+				if se.Code != jsonlp.ErrorCode_DuplicateKey || se.Line != 5 || se.Col != 2 {
+					t.Errorf("%v: Code, Line, Col = %v, %v, %v, want %v, 5, 2", tt.name, se.Code, se.Line, se.Col, jsonlp.ErrorCode_DuplicateKey)
+					return
+				}
+				if !reflect.DeepEqual(se.FirstDefinition, tt.wantFirst) {
+					t.Errorf("%v: FirstDefinition = %v, want %v", tt.name, se.FirstDefinition, tt.wantFirst)
+					return
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package jsonlp
+
+// Node of the table tree that is used while assembling the table by tableTransformer.
+// The tree has the nodes of the tables that can be extended by the later keys.
+type tableNode struct {
+	table    object
+	children map[string]*tableNode
+
+	// Definitions of the keys of the table. It is nil if the strictness check is disabled.
+	defs definitions
+}
+
+func newTableNode(table object, strict bool) *tableNode {
+	node := &tableNode{
+		table: table,
+	}
+	if strict {
+		node.defs = make(definitions)
+	}
+	return node
+}
+
+func (n *tableNode) child(key string) (*tableNode, bool) {
+	c, ok := n.children[key]
+	return c, ok
+}
+
+func (n *tableNode) setChild(key string, c *tableNode) {
+	if n.children == nil {
+		n.children = make(map[string]*tableNode)
+	}
+	n.children[key] = c
+}
+
+// Take over the sub tables and the definitions of the keys of the section
+// that is merged into the table of n.
+func (n *tableNode) adopt(section *tableNode) {
+	for key, c := range section.children {
+		n.setChild(key, c)
+	}
+	for key, d := range section.defs {
+		if n.defs != nil {
+			n.defs[key] = d
+		}
+	}
+}
@@ -0,0 +1,3 @@
+[
+    ,null
+]
\ No newline at end of file
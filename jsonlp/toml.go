@@ -1,128 +1,166 @@
 package jsonlp
 
 import (
-	"errors"
-	"strconv"
+	"io"
+	"sync"
 
 	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
 	. "github.com/shellyln/takenoco/base"
-	. "github.com/shellyln/takenoco/string"
+	strparser "github.com/shellyln/takenoco/string"
 )
 
+// Extensions of the loose TOML grammar that TOML 1.0 also has.
+// They are always accepted by the loose TOML parsers.
+const features_TOML = features_Explicit |
+	Feature_HashComment | Feature_ExtendedWhitespace | Feature_TrailingComma | Feature_SingleQuote |
+	Feature_MultiLineString | Feature_JSON5Escape | Feature_ExtendedEscape | Feature_ControlCharacter |
+	Feature_UnquotedKey | Feature_BareKey | Feature_DottedKey | Feature_EqualSeparator |
+	Feature_JSON5Number | Feature_ExtendedNumber | Feature_DateTime
+
 var (
-	tomlParser ParserFn
+	tomlParser        ParserFn // Parser of Features_All (read only)
+	tomlCustomParsers sync.Map // Parsers of the custom sets (Features -> ParserFn)
 )
 
 func init() {
-	tomlParser = tomlDocument()
+	tomlParser = tomlDocument(newTOMLGrammarSpec(Features_All))
+}
+
+// Loose TOML parser of the extensions of the options.
+// The parsers of the custom sets are built on the first use, and cached.
+func tomlLooseParserOf(opts parseOptions) ParserFn {
+	if opts.features == Features_All {
+		return tomlParser
+	}
+	if p, ok := tomlCustomParsers.Load(opts.features); ok {
+		return p.(ParserFn)
+	}
+	p, _ := tomlCustomParsers.LoadOrStore(opts.features, tomlDocument(newTOMLGrammarSpec(opts.features)))
+	return p.(ParserFn)
 }
 
-func tomlTableKeyValuePair() ParserFn {
+func tomlTableKeyValuePair(gs *grammarSpec) ParserFn {
 	return FlatGroup(
-		objectKey(false),
-		sp0NoLb(),
-		erase(CharClass("=")),
-		sp0NoLb(),
-		First(
-			FlatGroup(
-				primitiveValue(),
-				sp0NoLb(),
-				First(
-					erase(CharClass("\r\n", "\r", "\n")),
-					LookAhead(End()),
+		objectKey(gs, false),
+		sp0NoLb(gs.f),
+		erase(strparser.CharClass("=")),
+		sp0NoLb(gs.f),
+		recoverable(
+			First(
+				FlatGroup(
+					primitiveValue(gs.f),
+					sp0NoLb(gs.f),
+					First(
+						erase(strparser.CharClass("\r\n", "\r", "\n")),
+						LookAhead(strparser.End()),
+					),
 				),
+				Indirect(func() ParserFn { return listValue(gs) }),
+				Indirect(func() ParserFn { return objectValue(gs) }),
+				syntaxError(ErrorCode_ExpectValue, "Expect object property value"),
 			),
-			Indirect(listValue),
-			Indirect(objectValue),
-			Error("Expect object property value"),
+			syncTo("\n"),
+			true,
 		),
 	)
 }
 
-func tomlArrayOfTable() ParserFn {
+func tomlArrayOfTable(gs *grammarSpec) ParserFn {
 	return Trans(
 		FlatGroup(
-			erase(CharClass("[[")),
+			erase(strparser.CharClass("[[")),
 			First(
 				FlatGroup(
-					sp0NoLb(),
-					objectKey(false),
-					sp0NoLb(),
-					erase(CharClass("]]")),
-					sp0NoLb(),
+					sp0NoLb(gs.f),
+					objectKey(gs, false),
+					sp0NoLb(gs.f),
+					erase(strparser.CharClass("]]")),
+					sp0NoLb(gs.f),
 				),
-				Error("Expect array of table closing bracket ']]'"),
+				syntaxError(ErrorCode_ExpectArrayOfTableClose, "Expect array of table closing bracket ']]'"),
 			),
-			First(
-				erase(CharClass("\r\n", "\r", "\n")),
-				LookAhead(End()),
-				Error("Expect line break or EOF"),
+			recoverable(
+				First(
+					erase(strparser.CharClass("\r\n", "\r", "\n")),
+					LookAhead(strparser.End()),
+					syntaxError(ErrorCode_ExpectLinebreak, "Expect line break or EOF"),
+				),
+				syncTo("\n"),
+				false,
 			),
-			sp0(),
-			Trans(
+			gs.sp,
+			limitElements(Trans(
 				ZeroOrMoreTimes(
 					First(
-						tomlTableKeyValuePair(),
+						limitElement(tomlTableKeyValuePair(gs), false),
+						recoverTomlLine(ErrorCode_ExpectTermination, "Expect terminatiion"),
 					),
-					sp0(),
+					gs.sp,
 				),
-				tableTransformer,
+				sectionTransformer,
 				ChangeClassName(class.TomlArrayOfTable),
-			),
+			)),
 		),
 	)
 }
 
-func tomlTable() ParserFn {
+func tomlTable(gs *grammarSpec) ParserFn {
 	return Trans(
 		FlatGroup(
-			erase(CharClass("[")),
+			erase(strparser.CharClass("[")),
 			First(
 				FlatGroup(
-					sp0NoLb(),
-					objectKey(false),
-					sp0NoLb(),
-					erase(CharClass("]")),
-					sp0NoLb(),
+					sp0NoLb(gs.f),
+					objectKey(gs, false),
+					sp0NoLb(gs.f),
+					erase(strparser.CharClass("]")),
+					sp0NoLb(gs.f),
 				),
-				Error("Expect table closing bracket ']'"),
+				syntaxError(ErrorCode_ExpectTableClose, "Expect table closing bracket ']'"),
 			),
-			First(
-				erase(CharClass("\r\n", "\r", "\n")),
-				LookAhead(End()),
-				Error("Expect line break or EOF"),
+			recoverable(
+				First(
+					erase(strparser.CharClass("\r\n", "\r", "\n")),
+					LookAhead(strparser.End()),
+					syntaxError(ErrorCode_ExpectLinebreak, "Expect line break or EOF"),
+				),
+				syncTo("\n"),
+				false,
 			),
-			sp0(),
-			Trans(
+			gs.sp,
+			limitElements(Trans(
 				ZeroOrMoreTimes(
 					First(
-						tomlTableKeyValuePair(),
+						limitElement(tomlTableKeyValuePair(gs), false),
+						recoverTomlLine(ErrorCode_ExpectTermination, "Expect terminatiion"),
 					),
-					sp0(),
+					gs.sp,
 				),
-				tableTransformer,
+				sectionTransformer,
 				ChangeClassName(class.TomlTable),
-			),
+			)),
 		),
 	)
 }
 
-func tomlDocument() ParserFn {
+func tomlDocument(gs *grammarSpec) ParserFn {
 	return Trans(
 		FlatGroup(
 			Start(),
-			sp0(),
-			OneOrMoreTimes(
+			gs.sp,
+			// The empty document is the empty table.
+			ZeroOrMoreTimes(
 				First(
-					tomlTableKeyValuePair(),
-					tomlArrayOfTable(),
-					tomlTable(),
+					limitElement(tomlTableKeyValuePair(gs), false),
+					recoverable(tomlArrayOfTable(gs), syncToTableHeader, false),
+					recoverable(tomlTable(gs), syncToTableHeader, false),
+					recoverTomlLine(ErrorCode_ExpectTermination, "Expect terminatiion"),
 				),
-				sp0(),
+				gs.sp,
 			),
 			First(
-				End(),
-				Error("Expect terminatiion"),
+				strparser.End(),
+				syntaxError(ErrorCode_ExpectTermination, "Expect terminatiion"),
 			),
 		),
 		tableTransformer,
@@ -145,38 +183,54 @@ func tomlDocument() ParserFn {
 // parsed:
 // nil | []any | map[string]any | float64 | int64 | uint64 | complex128 | string | bool | time.Time
 func ParseTOML(s string, plafLb PlatformLinebreakType, interop InteropType) (interface{}, error) {
-	ctx := *NewStringParserContext(s)
-	opts := parseOptions{
-		interop:           interop,
-		platformLinebreak: "\n",
-		isTOML:            true,
-	}
-	switch plafLb {
-	case Linebreak_CrLf:
-		opts.platformLinebreak = "\r\n"
-	case Linebreak_Cr:
-		opts.platformLinebreak = "\r"
-	}
-	ctx.Tag = opts
+	return ParseTOMLWithOptions(s, &ParseOptions{
+		PlatformLinebreak: plafLb,
+		Interop:           interop,
+	})
+}
+
+// src: Loose TOML
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+//
+// parsed:
+// nil | []any | map[string]any | float64 | int64 | uint64 | complex128 | string | bool | time.Time |
+// Number | *big.Int | *big.Float | *big.Rat | *OrderedMap | []*OrderedMap |
+// LocalDate | LocalTime | LocalDateTime (depends on the options)
+func ParseTOMLWithOptions(s string, opts *ParseOptions) (interface{}, error) {
+	o := newParseOptions(opts, true)
+	return parse(tomlParserOf(o), s, o)
+}
 
-	out, err := tomlParser(ctx)
+// src: Loose TOML in UTF-8, or UTF-16, UTF-32 with BOM.
+// The UTF-8 BOM is removed.
+// UTF-8 input is not copied. The parsed strings can share the memory of b,
+// so b should not be modified while the parsed value is used.
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+func ParseTOMLBytes(b []byte, opts *ParseOptions) (interface{}, error) {
+	s, err := decodeInput(b)
 	if err != nil {
-		pos := GetLineAndColPosition(s, out.SourcePosition, 4)
-		return nil, errors.New(
-			err.Error() +
-				"\n --> Line " + strconv.Itoa(pos.Line) +
-				", Col " + strconv.Itoa(pos.Col) + "\n" +
-				pos.ErrSource)
+		return nil, err
 	}
+	return ParseTOMLWithOptions(s, opts)
+}
 
-	if out.MatchStatus == MatchStatus_Matched {
-		return out.AstStack[0].Value, nil
-	} else {
-		pos := GetLineAndColPosition(s, out.SourcePosition, 4)
-		return nil, errors.New(
-			"Parse failed" +
-				"\n --> Line " + strconv.Itoa(pos.Line) +
-				", Col " + strconv.Itoa(pos.Col) + "\n" +
-				pos.ErrSource)
+// src: Loose TOML in UTF-8, or UTF-16, UTF-32 with BOM.
+// The UTF-8 BOM is removed.
+//
+// opts:
+// Pointer to struct of the parser options. If nil, use default.
+// If the input exceeds opts.MaxInputSize, ErrInputTooLarge is returned.
+func ParseTOMLReader(r io.Reader, opts *ParseOptions) (interface{}, error) {
+	if opts == nil {
+		opts = &parseOptsDefault
+	}
+	s, err := readInput(r, opts.MaxInputSize)
+	if err != nil {
+		return nil, err
 	}
+	return ParseTOMLWithOptions(s, opts)
 }
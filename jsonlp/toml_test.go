@@ -14,7 +14,13 @@ func runMatrixTomlParse(t *testing.T, tests []testMatrixItem) {
 			if tt.dbg {
 				fmt.Println("")
 			}
-			got, err := jsonlp.ParseTOML(tt.args.s, tt.args.plafLb, tt.args.interop)
+			var got interface{}
+			var err error
+			if tt.args.opts != nil {
+				got, err = jsonlp.ParseTOMLWithOptions(tt.args.s, tt.args.opts)
+			} else {
+				got, err = jsonlp.ParseTOML(tt.args.s, tt.args.plafLb, tt.args.interop)
+			}
 			if tt.dbg {
 				fmt.Println("")
 			}
@@ -721,6 +727,24 @@ the lazy dog."""
 			"str1": "The quick\nbrown\rfox jumps over\rthe lazy dog.",
 		},
 		wantErr: false,
+	}, {
+		name: "t3-10a",
+		args: args{s: `
+		[[a]]
+		[a.b.c]
+		x = 1
+		[[a]]
+		[a.b.c]
+		y = 2
+		`},
+		want: map[string]interface{}{
+			"a": []map[string]interface{}{{
+				"b": map[string]interface{}{"c": map[string]interface{}{"x": float64(1)}},
+			}, {
+				"b": map[string]interface{}{"c": map[string]interface{}{"y": float64(2)}},
+			}},
+		},
+		wantErr: false,
 	}}
 
 	runMatrixTomlParse(t, tests)
@@ -808,3 +832,29 @@ func TestTomlParse4(t *testing.T) {
 		return
 	}
 }
+
+func TestTomlParseWithOptions1(t *testing.T) {
+	tests := []testMatrixItem{{
+		name:    "to1-1a",
+		args:    args{s: "a = 1+2i", opts: &jsonlp.ParseOptions{Interop: jsonlp.Interop_TOML}},
+		want:    map[string]interface{}{"a": map[string]interface{}{"re": float64(1), "im": float64(2)}},
+		wantErr: false,
+	}, {
+		name:    "to1-1b",
+		args:    args{s: "a = 1+2i", opts: &jsonlp.ParseOptions{Interop: jsonlp.Interop_TOML_AsNull}},
+		want:    map[string]interface{}{"a": nil},
+		wantErr: false,
+	}, {
+		name:    "to1-2a",
+		args:    args{s: "a = \"\"\"\nx\ny\"\"\"", opts: &jsonlp.ParseOptions{PlatformLinebreak: jsonlp.Linebreak_CrLf}},
+		want:    map[string]interface{}{"a": "x\r\ny"},
+		wantErr: false,
+	}, {
+		name:    "to1-3a",
+		args:    args{s: "[a", opts: &jsonlp.ParseOptions{}},
+		want:    nil,
+		wantErr: true,
+	}}
+
+	runMatrixTomlParse(t, tests)
+}
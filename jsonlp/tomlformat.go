@@ -0,0 +1,643 @@
+package jsonlp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type tomlFormatter struct {
+	opts   *FormatOptions
+	sb     strings.Builder
+	depth  int // Indentation depth of multi-line arrays
+	inline int // Greater than zero while writing inline tables
+}
+
+// Table to be formatted.
+type fmtTable struct {
+	keys []string
+	get  func(key string) interface{}
+}
+
+// Array to be formatted.
+type fmtList struct {
+	length int
+	at     func(i int) interface{}
+}
+
+// v:
+// map[string]any | *OrderedMap
+// Values of the members are the same as FormatJSON.
+// Nested tables are written as `[table]` sections (depends on opts.InlineTableDepth), and arrays of tables
+// (slices of the tables excluding []any) are written as `[[array-of-tables]]` sections at any depth.
+// Only the arrays of tables in the inline tables are written as the inline arrays,
+// and they are parsed as []any.
+// nil members are omitted, because TOML has no null.
+// The empty table is written as the empty document.
+// Keys are written as the bare keys only if they are `A-Za-z0-9_-`.
+// Integers that are out of the range of int64 are errors.
+//
+// time.Time is written as an offset datetime.
+// LocalDate, LocalTime and LocalDateTime are written as they are.
+// (Set ParseOptions.LocalDateTime to keep the local dates and times that ParseTOML returns.)
+//
+// opts:
+// Pointer to struct of the formatter options. If nil, use default.
+// Style is ignored. Indent and TrailingComma are applied to the arrays outside of inline tables.
+// Quote_Single writes literal strings if possible.
+func FormatTOML(v interface{}, opts *FormatOptions) (string, error) {
+	if opts == nil {
+		opts = &formatOptsDefault
+	}
+	t, ok := asFmtTable(v)
+	if !ok {
+		return "", fmt.Errorf("Unsupported type: %T (TOML document should be a table)", v)
+	}
+	f := &tomlFormatter{opts: opts}
+	if err := f.table(nil, t, 0, false); err != nil {
+		return "", err
+	}
+	return f.sb.String(), nil
+}
+
+func asFmtTable(v interface{}) (fmtTable, bool) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return fmtTable{keys: keys, get: func(key string) interface{} {
+			return x[key]
+		}}, true
+	case *OrderedMap:
+		if x == nil {
+			return fmtTable{}, false
+		}
+		return fmtTable{keys: x.Keys(), get: func(key string) interface{} {
+			v, _ := x.Get(key)
+			return v
+		}}, true
+	case nil:
+		return fmtTable{}, false
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return fmtTable{}, false
+	}
+	keys := make([]string, 0, rv.Len())
+	values := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		k := iter.Key().String()
+		keys = append(keys, k)
+		values[k] = iter.Value().Interface()
+	}
+	sort.Strings(keys)
+	return fmtTable{keys: keys, get: func(key string) interface{} {
+		return values[key]
+	}}, true
+}
+
+func asFmtList(v interface{}) (fmtList, bool) {
+	switch x := v.(type) {
+	case []interface{}:
+		return fmtList{length: len(x), at: func(i int) interface{} {
+			return x[i]
+		}}, true
+	case []map[string]interface{}:
+		return fmtList{length: len(x), at: func(i int) interface{} {
+			return x[i]
+		}}, true
+	case []*OrderedMap:
+		return fmtList{length: len(x), at: func(i int) interface{} {
+			return x[i]
+		}}, true
+	case []byte, nil:
+		return fmtList{}, false
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmtList{}, false
+	}
+	return fmtList{length: rv.Len(), at: func(i int) interface{} {
+		return rv.Index(i).Interface()
+	}}, true
+}
+
+// Returns true if the value is omitted from the table.
+func (f *tomlFormatter) isNull(v interface{}) bool {
+	switch v.(type) {
+	case nil:
+		return true
+	case complex128, complex64:
+		switch f.opts.Interop {
+		case Interop_JSON_AsNull, Interop_TOML_AsNull:
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the table at the depth is written as the section.
+func (f *tomlFormatter) isSection(depth int) bool {
+	return f.inline == 0 && (f.opts.InlineTableDepth <= 0 || depth < f.opts.InlineTableDepth)
+}
+
+// []any is written as the inline array, because ParseTOML returns it for the static arrays.
+// ([]map[string]any and []*OrderedMap are returned for the arrays of tables.)
+func isArrayOfTables(v interface{}) bool {
+	if _, ok := v.([]interface{}); ok {
+		return false
+	}
+	l, ok := asFmtList(v)
+	if !ok || l.length == 0 {
+		return false
+	}
+	for i := 0; i < l.length; i++ {
+		if _, ok := asFmtTable(l.at(i)); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Write the table at the depth. (The root table is depth 0)
+// If isArrayElement is true, the table is written as the element of the array of tables.
+func (f *tomlFormatter) table(path []string, t fmtTable, depth int, isArrayElement bool) error {
+	var simple, tables, arrays []string
+	for _, k := range t.keys {
+		v := t.get(k)
+		switch {
+		case f.isNull(v):
+		case isArrayOfTables(v):
+			// The inline arrays are parsed as []any.
+			arrays = append(arrays, k)
+		case f.isSection(depth + 1):
+			if _, ok := asFmtTable(v); ok {
+				tables = append(tables, k)
+			} else {
+				simple = append(simple, k)
+			}
+		default:
+			simple = append(simple, k)
+		}
+	}
+
+	if depth != 0 && (isArrayElement || len(simple) != 0 || len(tables)+len(arrays) == 0) {
+		if f.sb.Len() != 0 {
+			f.sb.WriteByte('\n')
+		}
+		if isArrayElement {
+			f.sb.WriteString("[[")
+		} else {
+			f.sb.WriteByte('[')
+		}
+		f.dottedKey(path)
+		if isArrayElement {
+			f.sb.WriteString("]]")
+		} else {
+			f.sb.WriteByte(']')
+		}
+		f.sb.WriteByte('\n')
+	}
+
+	for _, k := range simple {
+		f.key(k)
+		f.sb.WriteString(" = ")
+		if err := f.value(t.get(k)); err != nil {
+			return err
+		}
+		f.sb.WriteByte('\n')
+	}
+
+	for _, k := range tables {
+		sub, _ := asFmtTable(t.get(k))
+		if err := f.table(append(path[:len(path):len(path)], k), sub, depth+1, false); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range arrays {
+		l, _ := asFmtList(t.get(k))
+		for i := 0; i < l.length; i++ {
+			sub, _ := asFmtTable(l.at(i))
+			if err := f.table(append(path[:len(path):len(path)], k), sub, depth+1, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *tomlFormatter) dottedKey(path []string) {
+	for i, k := range path {
+		if i != 0 {
+			f.sb.WriteByte('.')
+		}
+		f.key(k)
+	}
+}
+
+// Keys are bare keys of TOML 1.0 (`A-Za-z0-9_-`) if possible.
+func (f *tomlFormatter) key(k string) {
+	if isTomlBareKey(k) {
+		f.sb.WriteString(k)
+	} else {
+		f.basicString(k)
+	}
+}
+
+func isTomlBareKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !isTomlBareKeyRune(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *tomlFormatter) value(v interface{}) error {
+	switch x := v.(type) {
+	case nil:
+		return fmt.Errorf("Unsupported value: nil (TOML has no null)")
+	case bool:
+		f.sb.WriteString(strconv.FormatBool(x))
+	case string:
+		f.str(x)
+	case int64:
+		f.int(x)
+	case uint64:
+		return f.uint(x)
+	case float64:
+		f.float(x, 64)
+	case complex128:
+		return f.complex(x, 64)
+	case time.Time:
+		f.time(x)
+	case LocalDate:
+		f.sb.WriteString(x.String())
+	case LocalTime:
+		f.sb.WriteString(x.String())
+	case LocalDateTime:
+		f.sb.WriteString(x.String())
+	case Number:
+		return f.number(x)
+	case *big.Int:
+		if x == nil {
+			return fmt.Errorf("Unsupported value: nil (TOML has no null)")
+		}
+		return f.bigInt(x)
+	case *big.Float:
+		if x == nil {
+			return fmt.Errorf("Unsupported value: nil (TOML has no null)")
+		}
+		f.bigFloat(x)
+	case *big.Rat:
+		if x == nil {
+			return fmt.Errorf("Unsupported value: nil (TOML has no null)")
+		}
+		prec := uint(64)
+		if n := uint(x.Num().BitLen() + x.Denom().BitLen()); n > prec {
+			prec = n
+		}
+		f.bigFloat(new(big.Float).SetPrec(prec).SetRat(x))
+	case []byte:
+		f.str(base64.StdEncoding.EncodeToString(x))
+	default:
+		if t, ok := asFmtTable(v); ok {
+			return f.inlineTable(t)
+		}
+		if l, ok := asFmtList(v); ok {
+			return f.array(l)
+		}
+		return f.reflectValue(reflect.ValueOf(v))
+	}
+	return nil
+}
+
+func (f *tomlFormatter) reflectValue(rv reflect.Value) error {
+	switch rv.Kind() {
+	case reflect.Bool:
+		f.sb.WriteString(strconv.FormatBool(rv.Bool()))
+	case reflect.String:
+		f.str(rv.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.int(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return f.uint(rv.Uint())
+	case reflect.Float32:
+		f.float(rv.Float(), 32)
+	case reflect.Float64:
+		f.float(rv.Float(), 64)
+	case reflect.Complex64:
+		return f.complex(rv.Complex(), 32)
+	case reflect.Complex128:
+		return f.complex(rv.Complex(), 64)
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return fmt.Errorf("Unsupported value: nil (TOML has no null)")
+		}
+		return f.value(rv.Elem().Interface())
+	default:
+		return fmt.Errorf("Unsupported type: %v", rv.Type())
+	}
+	return nil
+}
+
+func (f *tomlFormatter) inlineTable(t fmtTable) error {
+	f.inline++
+	defer func() { f.inline-- }()
+
+	f.sb.WriteByte('{')
+	n := 0
+	for _, k := range t.keys {
+		v := t.get(k)
+		if f.isNull(v) {
+			continue
+		}
+		if n != 0 {
+			f.sb.WriteByte(',')
+		}
+		f.sb.WriteByte(' ')
+		f.key(k)
+		f.sb.WriteString(" = ")
+		if err := f.value(v); err != nil {
+			return err
+		}
+		n++
+	}
+	if n != 0 {
+		f.sb.WriteByte(' ')
+	}
+	f.sb.WriteByte('}')
+	return nil
+}
+
+func (f *tomlFormatter) array(l fmtList) error {
+	if l.length == 0 {
+		f.sb.WriteString("[]")
+		return nil
+	}
+
+	// NOTE: Inline tables should be written in a single line.
+	multiline := f.opts.Indent != "" && f.inline == 0
+
+	f.sb.WriteByte('[')
+	f.depth++
+	for i := 0; i < l.length; i++ {
+		if i != 0 {
+			f.sb.WriteByte(',')
+		}
+		if multiline {
+			f.newline()
+		} else if i != 0 {
+			f.sb.WriteByte(' ')
+		}
+		if err := f.value(l.at(i)); err != nil {
+			return err
+		}
+	}
+	f.depth--
+	if multiline {
+		if f.opts.TrailingComma {
+			f.sb.WriteByte(',')
+		}
+		f.newline()
+	}
+	f.sb.WriteByte(']')
+	return nil
+}
+
+func (f *tomlFormatter) newline() {
+	f.sb.WriteByte('\n')
+	for i := 0; i < f.depth; i++ {
+		f.sb.WriteString(f.opts.Indent)
+	}
+}
+
+func (f *tomlFormatter) str(s string) {
+	multiline := strings.Contains(s, "\n")
+	if f.opts.Quote == Quote_Single && canBeTOMLLiteralString(s, multiline) {
+		if multiline {
+			// The first newline is trimmed by the parser.
+			f.sb.WriteString("'''\n")
+			f.sb.WriteString(s)
+			f.sb.WriteString("'''")
+		} else {
+			f.sb.WriteByte('\'')
+			f.sb.WriteString(s)
+			f.sb.WriteByte('\'')
+		}
+		return
+	}
+	if multiline {
+		f.multiLineBasicString(s)
+	} else {
+		f.basicString(s)
+	}
+}
+
+// Literal strings have no escape sequences.
+func canBeTOMLLiteralString(s string, multiline bool) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	if multiline {
+		if strings.Contains(s, "'''") || strings.HasSuffix(s, "'") {
+			return false
+		}
+	} else if strings.Contains(s, "'") {
+		return false
+	}
+	for _, c := range s {
+		if (c < 0x20 && c != '\t' && !(multiline && c == '\n')) || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *tomlFormatter) basicString(s string) {
+	f.sb.WriteByte('"')
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		f.escapedRune(c)
+	}
+	f.sb.WriteByte('"')
+}
+
+func (f *tomlFormatter) multiLineBasicString(s string) {
+	// The first newline is trimmed by the parser.
+	f.sb.WriteString("\"\"\"\n")
+	quotes := 0
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case c == '"':
+			// Escape the quotation mark that makes the delimiter.
+			if quotes == 2 || i == len(s) {
+				f.sb.WriteString("\\\"")
+				quotes = 0
+			} else {
+				f.sb.WriteByte('"')
+				quotes++
+			}
+			continue
+		case c == '\n':
+			f.sb.WriteByte('\n')
+		default:
+			f.escapedRune(c)
+		}
+		quotes = 0
+	}
+	f.sb.WriteString("\"\"\"")
+}
+
+func (f *tomlFormatter) escapedRune(c rune) {
+	switch {
+	case c == '"' || c == '\\':
+		f.sb.WriteByte('\\')
+		f.sb.WriteRune(c)
+	case c == '\n':
+		f.sb.WriteString("\\n")
+	case c == '\r':
+		f.sb.WriteString("\\r")
+	case c == '\t':
+		f.sb.WriteString("\\t")
+	case c == '\b':
+		f.sb.WriteString("\\b")
+	case c == '\f':
+		f.sb.WriteString("\\f")
+	case c < 0x20 || c == 0x7f:
+		// NOTE: `\u` is not used, because the following hex digits may be read as a part of it.
+		f.sb.WriteString(fmt.Sprintf("\\U%08x", c))
+	default:
+		// Invalid sequences are decoded as utf8.RuneError
+		f.sb.WriteRune(c)
+	}
+}
+
+func (f *tomlFormatter) int(v int64) {
+	if f.opts.Hex && v >= 0 {
+		f.sb.WriteString("0x")
+		f.sb.WriteString(strconv.FormatInt(v, 16))
+	} else {
+		f.sb.WriteString(strconv.FormatInt(v, 10))
+	}
+}
+
+// TOML integers are 64-bit signed integers.
+func (f *tomlFormatter) uint(v uint64) error {
+	if v > math.MaxInt64 {
+		return errIntegerOutOfRange(strconv.FormatUint(v, 10))
+	}
+	f.int(int64(v))
+	return nil
+}
+
+func (f *tomlFormatter) bigInt(v *big.Int) error {
+	if !v.IsInt64() {
+		return errIntegerOutOfRange(v.String())
+	}
+	f.int(v.Int64())
+	return nil
+}
+
+func errIntegerOutOfRange(s string) error {
+	return fmt.Errorf("Unsupported value: %v (TOML integers should be in the range of int64)", s)
+}
+
+func (f *tomlFormatter) float(v float64, bitSize int) {
+	switch {
+	case math.IsNaN(v):
+		f.sb.WriteString("nan")
+	case math.IsInf(v, 1):
+		f.sb.WriteString("inf")
+	case math.IsInf(v, -1):
+		f.sb.WriteString("-inf")
+	default:
+		s := formatFloat(v, bitSize)
+		f.sb.WriteString(s)
+		if !strings.ContainsAny(s, ".e") {
+			// Floats should have the fraction or the exponent part.
+			f.sb.WriteString(".0")
+		}
+	}
+}
+
+func (f *tomlFormatter) bigFloat(v *big.Float) {
+	if v.IsInf() {
+		f.float(math.Inf(v.Sign()), 64)
+		return
+	}
+	s := v.Text('g', -1)
+	f.sb.WriteString(s)
+	if !strings.ContainsAny(s, ".e") {
+		f.sb.WriteString(".0")
+	}
+}
+
+func (f *tomlFormatter) complex(v complex128, bitSize int) error {
+	switch f.opts.Interop {
+	case Interop_JSON, Interop_TOML:
+		f.inline++
+		defer func() { f.inline-- }()
+
+		f.sb.WriteString("{ re = ")
+		f.float(real(v), bitSize)
+		f.sb.WriteString(", im = ")
+		f.float(imag(v), bitSize)
+		f.sb.WriteString(" }")
+		return nil
+	default:
+		return fmt.Errorf("Unsupported value: %v (Set FormatOptions.Interop to replace complex numbers)", v)
+	}
+}
+
+func (f *tomlFormatter) time(v time.Time) {
+	f.sb.WriteString(v.Format(time.RFC3339Nano))
+}
+
+func (f *tomlFormatter) number(v Number) error {
+	s := string(v)
+	switch {
+	case isJSONNumber(s) && !strings.ContainsAny(s, ".eE"), isRadixInteger(s):
+		z, err := v.BigInt()
+		if err != nil {
+			return err
+		}
+		if !z.IsInt64() {
+			return errIntegerOutOfRange(s)
+		}
+		if !isRadixInteger(s) || s[0] == '-' || s[0] == '+' || !('a' <= s[1] && s[1] <= 'z') {
+			f.int(z.Int64())
+			return nil
+		}
+	case isJSONNumber(s):
+	default:
+		x, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		f.float(x, 64)
+		return nil
+	}
+	f.sb.WriteString(s)
+	return nil
+}
@@ -0,0 +1,813 @@
+package jsonlp
+
+import (
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
+	. "github.com/shellyln/takenoco/base"
+	strparser "github.com/shellyln/takenoco/string"
+)
+
+// TOML 1.0 grammar that is used if Strictness_Strict is set.
+// The lexical rules of the most tokens are different from the loose grammar,
+// so only the transformers and the table assembly are shared.
+
+var (
+	tomlStrictParser ParserFn
+)
+
+func init() {
+	tomlStrictParser = tomlStrictDocument()
+}
+
+// Document parser of the TOML parsers.
+func tomlParserOf(opts parseOptions) ParserFn {
+	if opts.strictness == Strictness_Strict {
+		return tomlStrictParser
+	}
+	return tomlLooseParserOf(opts)
+}
+
+// Control characters other than tab (U+0000 to U+0008, U+000A to U+001F, U+007F)
+func isTomlControlRune(c rune) bool {
+	return (c < 0x20 && c != '\t') || c == 0x7f
+}
+
+// A-Za-z0-9_-
+func isTomlBareKeyRune(c rune) bool {
+	return ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '_' || c == '-'
+}
+
+func isTomlBasicRune(c rune) bool {
+	return !isTomlControlRune(c) && c != '"' && c != '\\'
+}
+
+func isTomlLiteralRune(c rune) bool {
+	return !isTomlControlRune(c) && c != '\''
+}
+
+func isTomlCommentRune(c rune) bool {
+	return !isTomlControlRune(c)
+}
+
+// Zero-width assertion that the source is valid UTF-8.
+func tomlStrictValidUTF8() ParserFn {
+	return LightBaseParser("ValidUTF8", func(ctx ParserContext) (ParserContext, error) {
+		ctx.Length = 0
+		if utf8.ValidString(ctx.Str) {
+			ctx.MatchStatus = MatchStatus_Matched
+			return ctx, nil
+		}
+		i := 0
+		for i < len(ctx.Str) {
+			c, size := utf8.DecodeRuneInString(ctx.Str[i:])
+			if c == utf8.RuneError && size == 1 {
+				break
+			}
+			i += size
+		}
+		ctx.MatchStatus = MatchStatus_Error
+		return ctx, &parseError{
+			code: ErrorCode_InvalidCharacter,
+			msg:  "Invalid UTF-8 sequence",
+			pos:  &SourcePosition{Position: i},
+		}
+	})
+}
+
+// Spaces and tabs
+func tomlStrictWs() ParserFn {
+	return erase(ZeroOrMoreTimes(strparser.CharClass(" ", "\t")))
+}
+
+func tomlStrictNewline() ParserFn {
+	return strparser.CharClass("\r\n", "\n")
+}
+
+func tomlStrictComment() ParserFn {
+	return erase(FlatGroup(
+		strparser.Seq("#"),
+		ZeroOrMoreTimes(strparser.CharClassFn(isTomlCommentRune)),
+	))
+}
+
+// Spaces, tabs, comments and line breaks
+func tomlStrictWsCommentNewline() ParserFn {
+	return erase(ZeroOrMoreTimes(First(
+		strparser.CharClass(" ", "\t"),
+		tomlStrictComment(),
+		tomlStrictNewline(),
+	)))
+}
+
+// Zero-width assertion (error) at the control character.
+func tomlStrictControlCharError() ParserFn {
+	return FlatGroup(
+		LookAhead(strparser.CharClassFn(isTomlControlRune)),
+		syntaxError(ErrorCode_InvalidCharacter, "Control characters are not allowed"),
+	)
+}
+
+// End of the line that can have the comment.
+func tomlStrictLineEnd() ParserFn {
+	return FlatGroup(
+		tomlStrictWs(),
+		ZeroOrOnce(tomlStrictComment()),
+		First(
+			erase(tomlStrictNewline()),
+			LookAhead(strparser.End()),
+			tomlStrictControlCharError(),
+			syntaxError(ErrorCode_ExpectLinebreak, "Expect line break or EOF"),
+		),
+	)
+}
+
+// Convert the hex digits of `\uXXXX` and `\UXXXXXXXX` to the string.
+func unicodeScalarTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	s := asts[0].Value.(string)
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || v > utf8.MaxRune || (0xd800 <= v && v <= 0xdfff) {
+		return nil, newParseError(ErrorCode_InvalidEscapeSequence, "Invalid Unicode scalar value: "+s)
+	}
+	return AstSlice{{
+		Type:  AstType_String,
+		Value: string(rune(v)),
+	}}, nil
+}
+
+func tomlStrictEscape() ParserFn {
+	return FlatGroup(
+		erase(strparser.Seq("\\")),
+		First(
+			strparser.CharClass("\\", "\""),
+			replaceStr(strparser.Seq("b"), "\b"),
+			replaceStr(strparser.Seq("t"), "\t"),
+			replaceStr(strparser.Seq("n"), "\n"),
+			replaceStr(strparser.Seq("f"), "\f"),
+			replaceStr(strparser.Seq("r"), "\r"),
+			Trans(
+				FlatGroup(
+					erase(strparser.Seq("u")),
+					Repeat(Times{Min: 4, Max: 4}, strparser.HexNumber()),
+				),
+				strparser.Concat,
+				unicodeScalarTransformer,
+			),
+			Trans(
+				FlatGroup(
+					erase(strparser.Seq("U")),
+					Repeat(Times{Min: 8, Max: 8}, strparser.HexNumber()),
+				),
+				strparser.Concat,
+				unicodeScalarTransformer,
+			),
+			syntaxError(ErrorCode_InvalidEscapeSequence, "Invalid escape sequence"),
+		),
+	)
+}
+
+// Error at the character that terminates the string literal unexpectedly.
+func tomlStrictStringError() ParserFn {
+	return First(
+		FlatGroup(strparser.End(), syntaxError(ErrorCode_UnterminatedString, "An unexpected termination has appeared in the string literal.")),
+		FlatGroup(LookAhead(tomlStrictNewline()), syntaxError(ErrorCode_UnexpectedNewlineInString, "An unexpected newline has appeared in the string literal.")),
+		syntaxError(ErrorCode_InvalidCharacter, "Control characters are not allowed in the string literal."),
+	)
+}
+
+func tomlStrictBasicString() ParserFn {
+	return FlatGroup(
+		erase(strparser.Seq("\"")),
+		ZeroOrMoreTimes(
+			First(
+				tomlStrictEscape(),
+				OneOrMoreTimes(strparser.CharClassFn(isTomlBasicRune), limitStringChunk()),
+			),
+			limitStringChunk(),
+		),
+		First(
+			erase(strparser.Seq("\"")),
+			tomlStrictStringError(),
+		),
+	)
+}
+
+func tomlStrictMultiLineBasicString() ParserFn {
+	return FlatGroup(
+		erase(strparser.Seq("\"\"\"")),
+		ZeroOrOnce(erase(tomlStrictNewline())),
+		ZeroOrMoreTimes(
+			First(
+				// Line ending backslash
+				erase(FlatGroup(
+					strparser.Seq("\\"),
+					ZeroOrMoreTimes(strparser.CharClass(" ", "\t")),
+					tomlStrictNewline(),
+					ZeroOrMoreTimes(strparser.CharClass(" ", "\t", "\r\n", "\n")),
+				)),
+				tomlStrictEscape(),
+				Trans(
+					tomlStrictNewline(),
+					platformLinebreakTransformer,
+				),
+				FlatGroup(
+					strparser.CharClass("\"\"", "\""),
+					LookAheadN(strparser.Seq("\"")),
+				),
+				OneOrMoreTimes(strparser.CharClassFn(isTomlBasicRune), limitStringChunk()),
+			),
+			limitStringChunk(),
+		),
+		First(
+			// Up to two quotes are allowed just before the closing delimiter.
+			FlatGroup(strparser.Seq("\"\""), erase(strparser.Seq("\"\"\""))),
+			FlatGroup(strparser.Seq("\""), erase(strparser.Seq("\"\"\""))),
+			erase(strparser.Seq("\"\"\"")),
+			tomlStrictStringError(),
+		),
+	)
+}
+
+func tomlStrictLiteralString() ParserFn {
+	return FlatGroup(
+		erase(strparser.Seq("'")),
+		ZeroOrMoreTimes(strparser.CharClassFn(isTomlLiteralRune), limitStringChunk()),
+		First(
+			erase(strparser.Seq("'")),
+			tomlStrictStringError(),
+		),
+	)
+}
+
+func tomlStrictMultiLineLiteralString() ParserFn {
+	return FlatGroup(
+		erase(strparser.Seq("'''")),
+		ZeroOrOnce(erase(tomlStrictNewline())),
+		ZeroOrMoreTimes(
+			First(
+				Trans(
+					tomlStrictNewline(),
+					platformLinebreakTransformer,
+				),
+				FlatGroup(
+					strparser.CharClass("''", "'"),
+					LookAheadN(strparser.Seq("'")),
+				),
+				OneOrMoreTimes(strparser.CharClassFn(isTomlLiteralRune), limitStringChunk()),
+			),
+			limitStringChunk(),
+		),
+		First(
+			// Up to two quotes are allowed just before the closing delimiter.
+			FlatGroup(strparser.Seq("''"), erase(strparser.Seq("'''"))),
+			FlatGroup(strparser.Seq("'"), erase(strparser.Seq("'''"))),
+			erase(strparser.Seq("'''")),
+			tomlStrictStringError(),
+		),
+	)
+}
+
+func tomlStrictStringValue() ParserFn {
+	return limitStringLength(Trans(
+		First(
+			tomlStrictMultiLineBasicString(),
+			tomlStrictBasicString(),
+			tomlStrictMultiLineLiteralString(),
+			tomlStrictLiteralString(),
+		),
+		strparser.Concat,
+		ChangeClassName(class.String),
+	))
+}
+
+func tomlStrictSimpleKey() ParserFn {
+	return First(
+		limitStringLength(Trans(
+			First(
+				tomlStrictBasicString(),
+				tomlStrictLiteralString(),
+			),
+			strparser.Concat,
+			ChangeClassName(class.String),
+		)),
+		Trans(
+			OneOrMoreTimes(strparser.CharClassFn(isTomlBareKeyRune)),
+			strparser.Concat,
+			ChangeClassName(class.Idenitifier),
+		),
+	)
+}
+
+func tomlStrictKey() ParserFn {
+	return Trans(
+		First(
+			Trans(
+				FlatGroup(
+					tomlStrictSimpleKey(),
+					OneOrMoreTimes(
+						tomlStrictWs(),
+						erase(strparser.Seq(".")),
+						tomlStrictWs(),
+						tomlStrictSimpleKey(),
+					),
+				),
+				dottedKeyTransformer,
+			),
+			tomlStrictSimpleKey(),
+		),
+		keyPositionTransformer,
+	)
+}
+
+func tomlStrictBoolValue() ParserFn {
+	return First(
+		FlatGroup(erase(strparser.Seq("true")), Zero(trueAst)),
+		FlatGroup(erase(strparser.Seq("false")), Zero(falseAst)),
+	)
+}
+
+func tomlStrictDigit() ParserFn {
+	return strparser.CharRange(RuneRange{Start: '0', End: '9'})
+}
+
+// Digits that can be delimited by the underscores. (e.g. `1_000`)
+// Each underscore must be surrounded by the digits.
+func tomlStrictDigits(digit ParserFn) ParserFn {
+	return FlatGroup(
+		digit,
+		ZeroOrMoreTimes(
+			ZeroOrOnce(erase(strparser.Seq("_"))),
+			digit,
+		),
+	)
+}
+
+// Decimal integer without the leading zeros.
+func tomlStrictDecIntStr() ParserFn {
+	return FlatGroup(
+		ZeroOrOnce(strparser.CharClass("+", "-")),
+		First(
+			FlatGroup(
+				strparser.CharRange(RuneRange{Start: '1', End: '9'}),
+				ZeroOrMoreTimes(
+					ZeroOrOnce(erase(strparser.Seq("_"))),
+					tomlStrictDigit(),
+				),
+			),
+			strparser.Seq("0"),
+		),
+	)
+}
+
+func tomlStrictIntegerTransformer(radix int) TransformerFn {
+	return func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+		if kind, ok := numberLiteralKindOf(ctx, "", false); ok {
+			return numberLiteralAsts(kind), nil
+		}
+		v, err := strconv.ParseInt(asts[0].Value.(string), radix, 64)
+		if err != nil {
+			return nil, err
+		}
+		return AstSlice{{
+			ClassName: class.Int,
+			Type:      AstType_Int,
+			Value:     v,
+		}}, nil
+	}
+}
+
+func tomlStrictFloatTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	s := asts[0].Value.(string)
+	var v float64
+	switch s {
+	case "inf", "+inf":
+		v = math.Inf(1)
+	case "-inf":
+		v = math.Inf(-1)
+	case "nan", "+nan", "-nan":
+		v = math.NaN()
+	default:
+		if kind, ok := numberLiteralKindOf(ctx, "", true); ok {
+			return numberLiteralAsts(kind), nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		v = f
+	}
+	return AstSlice{nonFiniteAst(ctx, v)}, nil
+}
+
+// Replace Infinity and NaN as same as the loose grammar. (depends on the interop option)
+func nonFiniteAst(ctx ParserContext, v float64) Ast {
+	if !math.IsInf(v, 0) && !math.IsNaN(v) {
+		return Ast{ClassName: class.Float, Type: AstType_Float, Value: v}
+	}
+	switch ctx.Tag.(parseOptions).interop {
+	case Interop_JSON:
+		if math.IsNaN(v) {
+			return Ast{ClassName: class.NaN, Type: AstType_Any, Value: map[string]interface{}{"nan": true}}
+		}
+		sign := float64(1)
+		if v < 0 {
+			sign = -1
+		}
+		return Ast{ClassName: class.Inf, Type: AstType_Any, Value: map[string]interface{}{"inf": sign}}
+	case Interop_JSON_AsNull:
+		return nilAst
+	default:
+		return Ast{ClassName: class.Float, Type: AstType_Float, Value: v}
+	}
+}
+
+func tomlStrictNumberValue() ParserFn {
+	exp := FlatGroup(
+		strparser.CharClass("e", "E"),
+		ZeroOrOnce(strparser.CharClass("+", "-")),
+		tomlStrictDigits(tomlStrictDigit()),
+	)
+	return numberLiteral(First(
+		Trans(
+			FlatGroup(
+				ZeroOrOnce(strparser.CharClass("+", "-")),
+				strparser.CharClass("inf", "nan"),
+			),
+			strparser.Concat,
+			tomlStrictFloatTransformer,
+		),
+		Trans(
+			FlatGroup(erase(strparser.Seq("0x")), tomlStrictDigits(strparser.HexNumber())),
+			strparser.Concat,
+			tomlStrictIntegerTransformer(16),
+		),
+		Trans(
+			FlatGroup(erase(strparser.Seq("0o")), tomlStrictDigits(strparser.OctNumber())),
+			strparser.Concat,
+			tomlStrictIntegerTransformer(8),
+		),
+		Trans(
+			FlatGroup(erase(strparser.Seq("0b")), tomlStrictDigits(strparser.BinNumber())),
+			strparser.Concat,
+			tomlStrictIntegerTransformer(2),
+		),
+		Trans(
+			FlatGroup(
+				tomlStrictDecIntStr(),
+				First(
+					FlatGroup(
+						strparser.Seq("."),
+						tomlStrictDigits(tomlStrictDigit()),
+						ZeroOrOnce(exp),
+					),
+					exp,
+				),
+			),
+			strparser.Concat,
+			tomlStrictFloatTransformer,
+		),
+		Trans(
+			tomlStrictDecIntStr(),
+			strparser.Concat,
+			tomlStrictIntegerTransformer(10),
+		),
+	))
+}
+
+func tomlStrictDigitsN(n int) ParserFn {
+	return Repeat(Times{Min: n, Max: n}, tomlStrictDigit())
+}
+
+// yyyy-MM-dd
+func tomlStrictDateStr() ParserFn {
+	return FlatGroup(
+		tomlStrictDigitsN(4),
+		strparser.Seq("-"),
+		tomlStrictDigitsN(2),
+		strparser.Seq("-"),
+		tomlStrictDigitsN(2),
+	)
+}
+
+// hh:mm:ss , hh:mm:ss.fff...
+func tomlStrictTimeStr() ParserFn {
+	return FlatGroup(
+		tomlStrictDigitsN(2),
+		strparser.Seq(":"),
+		tomlStrictDigitsN(2),
+		strparser.Seq(":"),
+		tomlStrictDigitsN(2),
+		ZeroOrOnce(
+			strparser.Seq("."),
+			OneOrMoreTimes(tomlStrictDigit()),
+		),
+	)
+}
+
+// Offset date-time (yyyy-MM-ddThh:mm:ssZ , yyyy-MM-ddThh:mm:ss+hh:mm) and
+// local date-time (yyyy-MM-ddThh:mm:ss).
+// The fractional seconds are truncated to nanoseconds.
+func tomlStrictDateTimeTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	s := asts[0].Value.(string)
+	if s[len(s)-1] == 'Z' || hasOffset(s) {
+		if s[len(s)-1] != 'Z' && (s[len(s)-5:len(s)-3] > "23" || s[len(s)-2:] > "59") {
+			return nil, newParseError(ErrorCode_InvalidDateTime, "Invalid time offset: "+s)
+		}
+		t, err := time.Parse("2006-01-02T15:04:05.999999999Z07:00", s)
+		if err != nil {
+			return nil, err
+		}
+		return AstSlice{{
+			ClassName: class.DateTime,
+			Type:      AstType_Any,
+			Value:     t.UTC(),
+		}}, nil
+	}
+
+	t, err := time.Parse(localDateTimeLayout, s)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Tag.(parseOptions).localDateTime {
+		return AstSlice{{
+			ClassName: class.LocalDateTime,
+			Type:      AstType_Any,
+			Value:     LocalDateTimeOf(t),
+		}}, nil
+	}
+	return AstSlice{{
+		ClassName: class.DateTime,
+		Type:      AstType_Any,
+		Value:     t,
+	}}, nil
+}
+
+func tomlStrictDateTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	t, err := time.Parse(localDateLayout, asts[0].Value.(string))
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Tag.(parseOptions).localDateTime {
+		return AstSlice{{
+			ClassName: class.LocalDate,
+			Type:      AstType_Any,
+			Value:     LocalDateOf(t),
+		}}, nil
+	}
+	return AstSlice{{
+		ClassName: class.Date,
+		Type:      AstType_Any,
+		Value:     t,
+	}}, nil
+}
+
+func tomlStrictTimeTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	t, err := time.Parse(localTimeLayout, asts[0].Value.(string))
+	if err != nil {
+		return nil, err
+	}
+	v := LocalTimeOf(t)
+	if ctx.Tag.(parseOptions).localDateTime {
+		return AstSlice{{
+			ClassName: class.LocalTime,
+			Type:      AstType_Any,
+			Value:     v,
+		}}, nil
+	}
+	return AstSlice{{
+		ClassName: class.Time,
+		Type:      AstType_Any,
+		Value:     LocalDate{Year: 1970, Month: time.January, Day: 1}.At(v).In(time.UTC),
+	}}, nil
+}
+
+func tomlStrictDateTimeValue() ParserFn {
+	return First(
+		Trans(
+			FlatGroup(
+				tomlStrictDateStr(),
+				First(
+					replaceStr(strparser.CharClass("T", "t"), "T"),
+					FlatGroup(
+						replaceStr(strparser.Seq(" "), "T"),
+						LookAhead(tomlStrictDigit()),
+					),
+				),
+				tomlStrictTimeStr(),
+				ZeroOrOnce(
+					First(
+						replaceStr(strparser.CharClass("Z", "z"), "Z"),
+						FlatGroup(
+							strparser.CharClass("+", "-"),
+							tomlStrictDigitsN(2),
+							strparser.Seq(":"),
+							tomlStrictDigitsN(2),
+						),
+					),
+				),
+			),
+			strparser.Concat,
+			tomlStrictDateTimeTransformer,
+		),
+		Trans(
+			tomlStrictDateStr(),
+			strparser.Concat,
+			tomlStrictDateTransformer,
+		),
+		Trans(
+			tomlStrictTimeStr(),
+			strparser.Concat,
+			tomlStrictTimeTransformer,
+		),
+	)
+}
+
+func tomlStrictValue() ParserFn {
+	return First(
+		cancelable(First(
+			tomlStrictStringValue(),
+			tomlStrictBoolValue(),
+			tomlStrictDateTimeValue(),
+			tomlStrictNumberValue(),
+		)),
+		Indirect(tomlStrictArray),
+		Indirect(tomlStrictInlineTable),
+	)
+}
+
+// Arrays can span multiple lines, and can have comments and the trailing comma.
+func tomlStrictArray() ParserFn {
+	return limitNesting(Trans(
+		FlatGroup(
+			erase(strparser.Seq("[")),
+			tomlStrictWsCommentNewline(),
+			ZeroOrOnce(
+				limitElement(tomlStrictValue(), true),
+				tomlStrictWsCommentNewline(),
+				ZeroOrMoreTimes(
+					erase(strparser.Seq(",")),
+					tomlStrictWsCommentNewline(),
+					limitElement(tomlStrictValue(), true),
+					tomlStrictWsCommentNewline(),
+				),
+				ZeroOrOnce(
+					erase(strparser.Seq(",")),
+					tomlStrictWsCommentNewline(),
+				),
+			),
+			First(
+				erase(strparser.Seq("]")),
+				syntaxError(ErrorCode_ExpectArrayClose, "Expect array closing bracket ']'"),
+			),
+		),
+		listTransformer,
+	))
+}
+
+func tomlStrictKeyValue() ParserFn {
+	return FlatGroup(
+		tomlStrictKey(),
+		tomlStrictWs(),
+		First(
+			erase(strparser.Seq("=")),
+			syntaxError(ErrorCode_ExpectValue, "Expect '=' after the key"),
+		),
+		tomlStrictWs(),
+		First(
+			tomlStrictValue(),
+			syntaxError(ErrorCode_ExpectValue, "Expect value"),
+		),
+	)
+}
+
+// Inline tables must be on a single line, and cannot have the trailing comma.
+func tomlStrictInlineTable() ParserFn {
+	return limitNesting(Trans(
+		FlatGroup(
+			erase(strparser.Seq("{")),
+			tomlStrictWs(),
+			ZeroOrOnce(
+				limitElement(tomlStrictKeyValue(), false),
+				tomlStrictWs(),
+				ZeroOrMoreTimes(
+					erase(strparser.Seq(",")),
+					tomlStrictWs(),
+					First(
+						limitElement(tomlStrictKeyValue(), false),
+						syntaxError(ErrorCode_ExpectObjectMember, "Expect key-value pair"),
+					),
+					tomlStrictWs(),
+				),
+			),
+			First(
+				erase(strparser.Seq("}")),
+				syntaxError(ErrorCode_ExpectObjectClose, "Expect inline table closing bracket '}'"),
+			),
+		),
+		tableTransformer,
+	))
+}
+
+func tomlStrictKeyValueLine() ParserFn {
+	return FlatGroup(
+		tomlStrictKey(),
+		tomlStrictWs(),
+		First(
+			erase(strparser.Seq("=")),
+			syntaxError(ErrorCode_ExpectValue, "Expect '=' after the key"),
+		),
+		tomlStrictWs(),
+		recoverable(
+			First(
+				FlatGroup(
+					tomlStrictValue(),
+					tomlStrictLineEnd(),
+				),
+				syntaxError(ErrorCode_ExpectValue, "Expect value"),
+			),
+			syncTo("\n"),
+			true,
+		),
+	)
+}
+
+// Key-value pairs until the next table header.
+func tomlStrictTableBody() ParserFn {
+	return FlatGroup(
+		tomlStrictWsCommentNewline(),
+		ZeroOrMoreTimes(
+			First(
+				limitElement(tomlStrictKeyValueLine(), false),
+				recoverTomlLine(ErrorCode_ExpectTermination, "Expect termination"),
+			),
+			tomlStrictWsCommentNewline(),
+		),
+	)
+}
+
+func tomlStrictArrayOfTables() ParserFn {
+	return FlatGroup(
+		erase(strparser.Seq("[[")),
+		First(
+			FlatGroup(
+				tomlStrictWs(),
+				tomlStrictKey(),
+				tomlStrictWs(),
+				erase(strparser.Seq("]]")),
+			),
+			syntaxError(ErrorCode_ExpectArrayOfTableClose, "Expect array of table closing bracket ']]'"),
+		),
+		recoverable(tomlStrictLineEnd(), syncTo("\n"), false),
+		limitElements(Trans(
+			tomlStrictTableBody(),
+			sectionTransformer,
+			ChangeClassName(class.TomlArrayOfTable),
+		)),
+	)
+}
+
+func tomlStrictTable() ParserFn {
+	return FlatGroup(
+		erase(strparser.Seq("[")),
+		First(
+			FlatGroup(
+				tomlStrictWs(),
+				tomlStrictKey(),
+				tomlStrictWs(),
+				erase(strparser.Seq("]")),
+			),
+			syntaxError(ErrorCode_ExpectTableClose, "Expect table closing bracket ']'"),
+		),
+		recoverable(tomlStrictLineEnd(), syncTo("\n"), false),
+		limitElements(Trans(
+			tomlStrictTableBody(),
+			sectionTransformer,
+			ChangeClassName(class.TomlTable),
+		)),
+	)
+}
+
+func tomlStrictDocument() ParserFn {
+	return Trans(
+		FlatGroup(
+			Start(),
+			tomlStrictValidUTF8(),
+			tomlStrictTableBody(),
+			ZeroOrMoreTimes(
+				First(
+					recoverable(tomlStrictArrayOfTables(), syncToTableHeader, false),
+					recoverable(tomlStrictTable(), syncToTableHeader, false),
+				),
+			),
+			First(
+				strparser.End(),
+				tomlStrictControlCharError(),
+				syntaxError(ErrorCode_ExpectTermination, "Expect termination"),
+			),
+		),
+		tableTransformer,
+	)
+}
@@ -0,0 +1,309 @@
+package jsonlp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+var strictTomlOpts = &jsonlp.ParseOptions{
+	Strictness:    jsonlp.Strictness_Strict,
+	LocalDateTime: true,
+}
+
+func tomlTestValue(typ, value string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "value": value}
+}
+
+func tomlTestFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "nan"
+	case math.IsInf(v, 1):
+		return "inf"
+	case math.IsInf(v, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// Convert the parsed value to the tagged JSON of toml-test.
+func tomlTestTagged(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, w := range x {
+			m[k] = tomlTestTagged(w)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(x))
+		for i, w := range x {
+			a[i] = tomlTestTagged(w)
+		}
+		return a
+	case []map[string]interface{}:
+		a := make([]interface{}, len(x))
+		for i, w := range x {
+			a[i] = tomlTestTagged(w)
+		}
+		return a
+	case string:
+		return tomlTestValue("string", x)
+	case int64:
+		return tomlTestValue("integer", strconv.FormatInt(x, 10))
+	case float64:
+		return tomlTestValue("float", tomlTestFloat(x))
+	case bool:
+		return tomlTestValue("bool", strconv.FormatBool(x))
+	case time.Time:
+		return tomlTestValue("datetime", x.UTC().Format(time.RFC3339Nano))
+	case jsonlp.LocalDateTime:
+		return tomlTestValue("datetime-local", x.String())
+	case jsonlp.LocalDate:
+		return tomlTestValue("date-local", x.String())
+	case jsonlp.LocalTime:
+		return tomlTestValue("time-local", x.String())
+	default:
+		return tomlTestValue("unknown", reflect.TypeOf(v).String())
+	}
+}
+
+// Normalize the numbers and datetimes of the expected tagged JSON
+// so that it can be compared with tomlTestTagged().
+func tomlTestNormalize(t *testing.T, v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		typ, ok1 := x["type"].(string)
+		value, ok2 := x["value"].(string)
+		if ok1 && ok2 && len(x) == 2 {
+			var err error
+			switch typ {
+			case "integer":
+				var n int64
+				n, err = strconv.ParseInt(value, 10, 64)
+				value = strconv.FormatInt(n, 10)
+			case "float":
+				var f float64
+				switch strings.TrimPrefix(strings.TrimPrefix(value, "+"), "-") {
+				case "inf":
+					f = math.Inf(1)
+					if strings.HasPrefix(value, "-") {
+						f = math.Inf(-1)
+					}
+				case "nan":
+					f = math.NaN()
+				default:
+					f, err = strconv.ParseFloat(value, 64)
+				}
+				value = tomlTestFloat(f)
+			case "datetime":
+				var d time.Time
+				d, err = time.Parse(time.RFC3339Nano, value)
+				value = d.UTC().Format(time.RFC3339Nano)
+			}
+			if err != nil {
+				t.Fatalf("invalid expected value: %v", err)
+			}
+			return tomlTestValue(typ, value)
+		}
+		m := make(map[string]interface{}, len(x))
+		for k, w := range x {
+			m[k] = tomlTestNormalize(t, w)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(x))
+		for i, w := range x {
+			a[i] = tomlTestNormalize(t, w)
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+// Test files of toml-test (https://github.com/toml-lang/toml-test) in testdata/toml-test/dir.
+// The files that are not listed in files-toml-1.0.0 (TOML 1.1 cases) are skipped.
+func tomlTestFiles(t *testing.T, dir string) []string {
+	root := filepath.Join("testdata", "toml-test")
+	b, err := os.ReadFile(filepath.Join(root, "files-toml-1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := make(map[string]bool)
+	for _, line := range strings.Split(string(b), "\n") {
+		listed[strings.TrimSpace(line)] = true
+	}
+
+	var files []string
+	err = filepath.WalkDir(filepath.Join(root, dir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".toml" {
+			return err
+		}
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if listed[filepath.ToSlash(name)] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no test files")
+	}
+	return files
+}
+
+// Name of the subtest. (e.g. `table/array-implicit.toml`)
+func tomlTestName(dir, file string) string {
+	name, _ := filepath.Rel(filepath.Join("testdata", "toml-test", dir), file)
+	return filepath.ToSlash(name)
+}
+
+func TestStrictTomlValid(t *testing.T) {
+	for _, file := range tomlTestFiles(t, "valid") {
+		file := file
+		t.Run(tomlTestName("valid", file), func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(strings.TrimSuffix(file, ".toml") + ".json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want interface{}
+			if err := json.Unmarshal(b, &want); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := jsonlp.ParseTOMLWithOptions(string(src), strictTomlOpts)
+			if err != nil {
+				t.Errorf("ParseTOMLWithOptions: error = %v", err)
+				return
+			}
+			if g, w := tomlTestTagged(got), tomlTestNormalize(t, want); !reflect.DeepEqual(g, w) {
+				t.Errorf("ParseTOMLWithOptions: v = %v, want %v", g, w)
+			}
+		})
+	}
+}
+
+func TestStrictTomlInvalid(t *testing.T) {
+	for _, file := range tomlTestFiles(t, "invalid") {
+		file := file
+		t.Run(tomlTestName("invalid", file), func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := jsonlp.ParseTOMLWithOptions(string(src), strictTomlOpts)
+			if err == nil {
+				t.Errorf("ParseTOMLWithOptions: v = %v, want error", got)
+			}
+		})
+	}
+}
+
+func TestStrictToml1(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		opts     *jsonlp.ParseOptions
+		want     interface{}
+		wantCode jsonlp.ErrorCode
+	}{{
+		name: "st1-1a",
+		s:    "a = 1\nb = 1.5\n",
+		opts: &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_Strict},
+		want: map[string]interface{}{"a": int64(1), "b": 1.5},
+	}, {
+		name: "st1-1b",
+		s:    "a = 1979-05-27T07:32:00\nb = 07:32:00\n",
+		opts: &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_Strict},
+		want: map[string]interface{}{
+			"a": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+			"b": time.Date(1970, 1, 1, 7, 32, 0, 0, time.UTC),
+		},
+	}, {
+		name: "st1-1c",
+		s:    "a = 1\n",
+		opts: &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_Strict, NumberMode: jsonlp.Number_Lossless},
+		want: map[string]interface{}{"a": jsonlp.Number("1")},
+	}, {
+		name: "st1-1d",
+		s:    "a = inf\n",
+		opts: &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_Strict, Interop: jsonlp.Interop_JSON_AsNull},
+		want: map[string]interface{}{"a": nil},
+	}, {
+		name:     "st1-2a",
+		s:        "a = \"\\x41\"\n",
+		opts:     &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_Strict},
+		wantCode: jsonlp.ErrorCode_InvalidEscapeSequence,
+	}, {
+		name:     "st1-2b",
+		s:        "a = \"\x01\"\n",
+		opts:     &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_Strict},
+		wantCode: jsonlp.ErrorCode_InvalidCharacter,
+	}, {
+		name:     "st1-2c",
+		s:        "a = 1\n\xff\n",
+		opts:     &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_Strict},
+		wantCode: jsonlp.ErrorCode_InvalidCharacter,
+	}, {
+		name:     "st1-2d",
+		s:        "[a]\nb.c = 1\n[a.b.d]\n[a.b]\n",
+		opts:     &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_Strict},
+		wantCode: jsonlp.ErrorCode_TableRedefinition,
+	}, {
+		name:     "st1-2e",
+		s:        "a = 1979-05-27T07:32:00+09:60\n",
+		opts:     &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_Strict},
+		wantCode: jsonlp.ErrorCode_InvalidDateTime,
+	}, {
+		name:     "st1-2f",
+		s:        "a = 1979-05-27T07:32:00+24:00\n",
+		opts:     &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_Strict},
+		wantCode: jsonlp.ErrorCode_InvalidDateTime,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonlp.ParseTOMLWithOptions(tt.s, tt.opts)
+			if tt.wantCode != 0 {
+				var se *jsonlp.SyntaxError
+				if !errors.As(err, &se) {
+					t.Errorf("ParseTOMLWithOptions: error = %v, want SyntaxError", err)
+					return
+				}
+				if se.Code != tt.wantCode {
+					t.Errorf("ParseTOMLWithOptions: code = %v, want %v", se.Code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("ParseTOMLWithOptions: error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTOMLWithOptions: v = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
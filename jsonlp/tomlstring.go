@@ -3,241 +3,263 @@ package jsonlp
 import (
 	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
 	. "github.com/shellyln/takenoco/base"
-	. "github.com/shellyln/takenoco/string"
+	strparser "github.com/shellyln/takenoco/string"
 )
 
 func tomlSingleLineLiteralString() ParserFn {
 	return FlatGroup(
-		erase(Seq("'")),
+		erase(strparser.Seq("'")),
 		ZeroOrMoreTimes(
 			First(
 				OneOrMoreTimes(
 					First(
 						FlatGroup(
-							CharClass("\r", "\n"),
-							Error("An unexpected newline has appeared in the string literal."),
+							strparser.CharClass("\r", "\n"),
+							syntaxError(ErrorCode_UnexpectedNewlineInString, "An unexpected newline has appeared in the string literal."),
 						),
-						CharClassN("'"),
+						strparser.CharClassN("'"),
 					),
+					limitStringChunk(),
 				),
 			),
 		),
 		First(
-			FlatGroup(End(), Error("An unexpected termination has appeared in the string literal.")),
-			erase(Seq("'")),
+			FlatGroup(strparser.End(), syntaxError(ErrorCode_UnterminatedString, "An unexpected termination has appeared in the string literal.")),
+			erase(strparser.Seq("'")),
 		),
 	)
 }
 
 func tomlMultiLineLiteralString() ParserFn {
 	return FlatGroup(
-		erase(Seq("'''")),
-		ZeroOrOnce(erase(CharClass("\r\n", "\r", "\n"))),
+		erase(strparser.Seq("'''")),
+		ZeroOrOnce(erase(strparser.CharClass("\r\n", "\r", "\n"))),
 		ZeroOrMoreTimes(
 			First(
 				FlatGroup(
-					CharClass("'"),
-					LookAhead(CharClass("'''")),
+					strparser.CharClass("'"),
+					LookAhead(strparser.CharClass("'''")),
 				),
 				OneOrMoreTimes(
 					First(
 						Trans(
-							CharClass("\r\n", "\r", "\n"),
+							strparser.CharClass("\r\n", "\r", "\n"),
 							platformLinebreakTransformer,
 						),
-						CharClassN("'''"),
+						strparser.CharClassN("'''"),
 					),
+					limitStringChunk(),
 				),
 			),
 		),
 		First(
-			FlatGroup(End(), Error("An unexpected termination has appeared in the string literal.")),
-			erase(Seq("'''")),
+			FlatGroup(strparser.End(), syntaxError(ErrorCode_UnterminatedString, "An unexpected termination has appeared in the string literal.")),
+			erase(strparser.Seq("'''")),
 		),
 	)
 }
 
 func tomlSingleLineBasicString() ParserFn {
 	return FlatGroup(
-		erase(Seq("\"")),
+		erase(strparser.Seq("\"")),
 		ZeroOrMoreTimes(
 			First(
 				FlatGroup(
-					erase(Seq("\\")),
+					erase(strparser.Seq("\\")),
 					First(
-						CharClass("\\", "'", "\"", "`"),
-						replaceStr(CharClass("n", "N"), "\n"),
-						replaceStr(CharClass("r", "R"), "\r"),
-						replaceStr(CharClass("v", "V"), "\v"),
-						replaceStr(CharClass("t", "T"), "\t"),
-						replaceStr(CharClass("b", "B"), "\b"),
-						replaceStr(CharClass("f", "F"), "\f"),
+						strparser.CharClass("\\", "'", "\"", "`"),
+						replaceStr(strparser.CharClass("n", "N"), "\n"),
+						replaceStr(strparser.CharClass("r", "R"), "\r"),
+						replaceStr(strparser.CharClass("v", "V"), "\v"),
+						replaceStr(strparser.CharClass("t", "T"), "\t"),
+						replaceStr(strparser.CharClass("b", "B"), "\b"),
+						replaceStr(strparser.CharClass("f", "F"), "\f"),
 						Trans(
 							FlatGroup(
-								erase(CharClass("u")),
-								Repeat(Times{Min: 6, Max: 6}, HexNumber()),
+								erase(strparser.CharClass("U")),
+								Repeat(Times{Min: 8, Max: 8}, strparser.HexNumber()),
 							),
-							ParseIntRadix(16),
-							StringFromInt,
+							strparser.ParseIntRadix(16),
+							strparser.StringFromInt,
 						),
 						Trans(
 							FlatGroup(
-								erase(CharClass("u")),
-								Repeat(Times{Min: 4, Max: 4}, HexNumber()),
+								erase(strparser.CharClass("u")),
+								Repeat(Times{Min: 6, Max: 6}, strparser.HexNumber()),
 							),
-							ParseIntRadix(16),
-							StringFromInt,
+							strparser.ParseIntRadix(16),
+							strparser.StringFromInt,
 						),
 						Trans(
 							FlatGroup(
-								erase(CharClass("u{")),
-								Repeat(Times{Min: 1, Max: 6}, HexNumber()),
-								erase(CharClass("}")),
+								erase(strparser.CharClass("u")),
+								Repeat(Times{Min: 4, Max: 4}, strparser.HexNumber()),
 							),
-							ParseIntRadix(16),
-							StringFromInt,
+							strparser.ParseIntRadix(16),
+							strparser.StringFromInt,
 						),
 						Trans(
 							FlatGroup(
-								erase(CharClass("x")),
-								Repeat(Times{Min: 2, Max: 2}, HexNumber()),
+								erase(strparser.CharClass("u{")),
+								Repeat(Times{Min: 1, Max: 6}, strparser.HexNumber()),
+								erase(strparser.CharClass("}")),
 							),
-							ParseIntRadix(16),
-							StringFromInt,
+							strparser.ParseIntRadix(16),
+							strparser.StringFromInt,
 						),
 						Trans(
 							FlatGroup(
-								Repeat(Times{Min: 3, Max: 3}, OctNumber()),
+								erase(strparser.CharClass("x")),
+								Repeat(Times{Min: 2, Max: 2}, strparser.HexNumber()),
 							),
-							ParseIntRadix(8),
-							StringFromInt,
+							strparser.ParseIntRadix(16),
+							strparser.StringFromInt,
 						),
-						replaceStr(CharClass("\r\n", "\r", "\n"), ""),
-						Any(),
+						Trans(
+							FlatGroup(
+								Repeat(Times{Min: 3, Max: 3}, strparser.OctNumber()),
+							),
+							strparser.ParseIntRadix(8),
+							strparser.StringFromInt,
+						),
+						replaceStr(strparser.CharClass("\r\n", "\r", "\n"), ""),
+						strparser.Any(),
 					),
 				),
 				OneOrMoreTimes(
 					First(
 						FlatGroup(
-							CharClass("\r", "\n"),
-							Error("An unexpected newline has appeared in the string literal."),
+							strparser.CharClass("\r", "\n"),
+							syntaxError(ErrorCode_UnexpectedNewlineInString, "An unexpected newline has appeared in the string literal."),
 						),
-						CharClassN("\"", "\\"),
+						strparser.CharClassN("\"", "\\"),
 					),
+					limitStringChunk(),
 				),
 			),
+			limitStringChunk(),
 		),
 		First(
-			FlatGroup(End(), Error("An unexpected termination has appeared in the string literal.")),
-			erase(Seq("\"")),
+			FlatGroup(strparser.End(), syntaxError(ErrorCode_UnterminatedString, "An unexpected termination has appeared in the string literal.")),
+			erase(strparser.Seq("\"")),
 		),
 	)
 }
 
-func tomlMultiLineBasicString() ParserFn {
+func tomlMultiLineBasicString(f Features) ParserFn {
 	return FlatGroup(
-		erase(Seq("\"\"\"")),
-		ZeroOrOnce(erase(CharClass("\r\n", "\r", "\n"))),
+		erase(strparser.Seq("\"\"\"")),
+		ZeroOrOnce(erase(strparser.CharClass("\r\n", "\r", "\n"))),
 		ZeroOrMoreTimes(
 			First(
 				FlatGroup(
-					erase(Seq("\\")),
+					erase(strparser.Seq("\\")),
 					First(
 						erase(FlatGroup(
-							CharClass("\r\n", "\r", "\n"),
-							sp0(),
+							strparser.CharClass("\r\n", "\r", "\n"),
+							sp0(f),
 						)),
-						CharClass("\\", "'", "\"", "`"),
-						replaceStr(CharClass("n", "N"), "\n"),
-						replaceStr(CharClass("r", "R"), "\r"),
-						replaceStr(CharClass("v", "V"), "\v"),
-						replaceStr(CharClass("t", "T"), "\t"),
-						replaceStr(CharClass("b", "B"), "\b"),
-						replaceStr(CharClass("f", "F"), "\f"),
+						strparser.CharClass("\\", "'", "\"", "`"),
+						replaceStr(strparser.CharClass("n", "N"), "\n"),
+						replaceStr(strparser.CharClass("r", "R"), "\r"),
+						replaceStr(strparser.CharClass("v", "V"), "\v"),
+						replaceStr(strparser.CharClass("t", "T"), "\t"),
+						replaceStr(strparser.CharClass("b", "B"), "\b"),
+						replaceStr(strparser.CharClass("f", "F"), "\f"),
+						Trans(
+							FlatGroup(
+								erase(strparser.CharClass("U")),
+								Repeat(Times{Min: 8, Max: 8}, strparser.HexNumber()),
+							),
+							strparser.ParseIntRadix(16),
+							strparser.StringFromInt,
+						),
 						Trans(
 							FlatGroup(
-								erase(CharClass("u")),
-								Repeat(Times{Min: 6, Max: 6}, HexNumber()),
+								erase(strparser.CharClass("u")),
+								Repeat(Times{Min: 6, Max: 6}, strparser.HexNumber()),
 							),
-							ParseIntRadix(16),
-							StringFromInt,
+							strparser.ParseIntRadix(16),
+							strparser.StringFromInt,
 						),
 						Trans(
 							FlatGroup(
-								erase(CharClass("u")),
-								Repeat(Times{Min: 4, Max: 4}, HexNumber()),
+								erase(strparser.CharClass("u")),
+								Repeat(Times{Min: 4, Max: 4}, strparser.HexNumber()),
 							),
-							ParseIntRadix(16),
-							StringFromInt,
+							strparser.ParseIntRadix(16),
+							strparser.StringFromInt,
 						),
 						Trans(
 							FlatGroup(
-								erase(CharClass("u{")),
-								Repeat(Times{Min: 1, Max: 6}, HexNumber()),
-								erase(CharClass("}")),
+								erase(strparser.CharClass("u{")),
+								Repeat(Times{Min: 1, Max: 6}, strparser.HexNumber()),
+								erase(strparser.CharClass("}")),
 							),
-							ParseIntRadix(16),
-							StringFromInt,
+							strparser.ParseIntRadix(16),
+							strparser.StringFromInt,
 						),
 						Trans(
 							FlatGroup(
-								erase(CharClass("x")),
-								Repeat(Times{Min: 2, Max: 2}, HexNumber()),
+								erase(strparser.CharClass("x")),
+								Repeat(Times{Min: 2, Max: 2}, strparser.HexNumber()),
 							),
-							ParseIntRadix(16),
-							StringFromInt,
+							strparser.ParseIntRadix(16),
+							strparser.StringFromInt,
 						),
 						Trans(
 							FlatGroup(
-								Repeat(Times{Min: 3, Max: 3}, OctNumber()),
+								Repeat(Times{Min: 3, Max: 3}, strparser.OctNumber()),
 							),
-							ParseIntRadix(8),
-							StringFromInt,
+							strparser.ParseIntRadix(8),
+							strparser.StringFromInt,
 						),
 					),
 				),
 				OneOrMoreTimes(
 					First(
 						FlatGroup(
-							CharClass("\""),
-							LookAhead(CharClass("\"\"\"")),
+							strparser.CharClass("\""),
+							LookAhead(strparser.CharClass("\"\"\"")),
 						),
 						Trans(
-							CharClass("\r\n", "\r", "\n"),
+							strparser.CharClass("\r\n", "\r", "\n"),
 							platformLinebreakTransformer,
 						),
-						CharClassN("\"\"\"", "\\"),
+						strparser.CharClassN("\"\"\"", "\\"),
 					),
+					limitStringChunk(),
 				),
 			),
+			limitStringChunk(),
 		),
 		First(
-			FlatGroup(End(), Error("An unexpected termination has appeared in the string literal.")),
-			erase(Seq("\"\"\"")),
+			FlatGroup(strparser.End(), syntaxError(ErrorCode_UnterminatedString, "An unexpected termination has appeared in the string literal.")),
+			erase(strparser.Seq("\"\"\"")),
 		),
 	)
 }
 
-func tomlStringValueInner() ParserFn {
+func tomlStringValueInner(f Features) ParserFn {
 	return First(
-		tomlMultiLineBasicString(),
+		tomlMultiLineBasicString(f),
 		tomlSingleLineBasicString(),
 		tomlMultiLineLiteralString(),
 		tomlSingleLineLiteralString(),
-		stringLiteralInner("`", true),
+		extension(f, Feature_BackQuote, stringLiteralInner(f, "`", true), strparser.Seq("`")),
 	)
 }
 
-func tomlStringValue() ParserFn {
+func tomlStringValue(f Features) ParserFn {
 	return Trans(
 		FlatGroup(
-			tomlStringValueInner(),
+			tomlStringValueInner(f),
 			ZeroOrMoreTimes(
-				sp1NoLb(),
-				tomlStringValueInner(),
+				sp1NoLb(f),
+				tomlStringValueInner(f),
 			),
 		),
-		Concat,
+		strparser.Concat,
 		ChangeClassName(class.String),
 	)
 }
@@ -2,18 +2,31 @@ package jsonlp
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 
+	"github.com/shellyln/go-loose-json-parser/internal/bignum"
 	"github.com/shellyln/go-loose-json-parser/jsonlp/class"
 	. "github.com/shellyln/takenoco/base"
-	. "github.com/shellyln/takenoco/string"
+	strparser "github.com/shellyln/takenoco/string"
 )
 
-func radixNumberTransformer(prefix string, radix int) TransformerFn {
+// If signed is true, the sign of the integer without suffix is accepted. (e.g. `-0xff` of JSON5)
+func radixNumberTransformer(prefix string, radix int, signed bool) TransformerFn {
 	return func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
-		switch asts[2].Value.(string) {
+		suffix := asts[2].Value.(string)
+		isFloat := suffix == "p" || suffix == "P"
+		if kind, ok := numberLiteralKindOf(ctx, suffix, isFloat); ok {
+			if !isFloat && asts[0].Value.(string) != "" && !(signed && suffix == "f") {
+				return nil, newParseError(ErrorCode_InvalidNumber, fmt.Sprintf("Invalid number format: %v%v%v", asts[0].Value.(string), prefix, asts[1].Value.(string)))
+			}
+			return numberLiteralAsts(kind), nil
+		}
+
+		switch suffix {
 		case "p", "P":
-			concatAsts, err := Concat(ctx, asts[1:])
+			concatAsts, err := strparser.Concat(ctx, asts[1:])
 			if err != nil {
 				return nil, err
 			}
@@ -28,7 +41,7 @@ func radixNumberTransformer(prefix string, radix int) TransformerFn {
 			}}, nil
 		case "s64", "S64":
 			if asts[0].Value.(string) != "" {
-				return nil, fmt.Errorf("Invalid number format: %v%v%v", asts[0].Value.(string), prefix, asts[1].Value.(string))
+				return nil, newParseError(ErrorCode_InvalidNumber, fmt.Sprintf("Invalid number format: %v%v%v", asts[0].Value.(string), prefix, asts[1].Value.(string)))
 			}
 			v, err := strconv.ParseUint(asts[1].Value.(string), radix, 64)
 			if err != nil {
@@ -41,7 +54,7 @@ func radixNumberTransformer(prefix string, radix int) TransformerFn {
 			}}, nil
 		case "u64", "U64":
 			if asts[0].Value.(string) != "" {
-				return nil, fmt.Errorf("Invalid number format: %v%v%v", asts[0].Value.(string), prefix, asts[1].Value.(string))
+				return nil, newParseError(ErrorCode_InvalidNumber, fmt.Sprintf("Invalid number format: %v%v%v", asts[0].Value.(string), prefix, asts[1].Value.(string)))
 			}
 			v, err := strconv.ParseUint(asts[1].Value.(string), radix, 64)
 			if err != nil {
@@ -53,10 +66,13 @@ func radixNumberTransformer(prefix string, radix int) TransformerFn {
 				Value:     v,
 			}}, nil
 		default:
-			if asts[0].Value.(string) != "" {
-				return nil, fmt.Errorf("Invalid number format: %v%v%v", asts[0].Value.(string), prefix, asts[1].Value.(string))
+			if asts[0].Value.(string) != "" && !signed {
+				return nil, newParseError(ErrorCode_InvalidNumber, fmt.Sprintf("Invalid number format: %v%v%v", asts[0].Value.(string), prefix, asts[1].Value.(string)))
+			}
+			if ctx.Tag.(parseOptions).numberMode == Number_Integer {
+				return parseInteger(asts[0].Value.(string)+asts[1].Value.(string), radix)
 			}
-			v, err := strconv.ParseInt(asts[1].Value.(string), radix, 64)
+			v, err := strconv.ParseInt(asts[0].Value.(string)+asts[1].Value.(string), radix, 64)
 			if err != nil {
 				return nil, err
 			}
@@ -69,7 +85,25 @@ func radixNumberTransformer(prefix string, radix int) TransformerFn {
 	}
 }
 
+func floatNumberTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	if kind, ok := numberLiteralKindOf(ctx, "", true); ok {
+		return numberLiteralAsts(kind), nil
+	}
+	v, err := strconv.ParseFloat(asts[0].Value.(string), 64)
+	if err != nil {
+		return nil, err
+	}
+	return AstSlice{{
+		ClassName: class.Float,
+		Type:      AstType_Float,
+		Value:     v,
+	}}, nil
+}
+
 func decimalNumberTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	if kind, ok := numberLiteralKindOf(ctx, asts[1].Value.(string), false); ok {
+		return numberLiteralAsts(kind), nil
+	}
 	switch asts[1].Value.(string) {
 	case "s64", "S64":
 		v, err := strconv.ParseInt(asts[0].Value.(string), 10, 64)
@@ -92,6 +126,9 @@ func decimalNumberTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error
 			Value:     v,
 		}}, nil
 	default:
+		if ctx.Tag.(parseOptions).numberMode == Number_Integer {
+			return parseInteger(asts[0].Value.(string), 10)
+		}
 		v, err := strconv.ParseFloat(asts[0].Value.(string), 64)
 		if err != nil {
 			return nil, err
@@ -104,6 +141,31 @@ func decimalNumberTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error
 	}
 }
 
+// Parse the integer as int64.
+// If it overflows int64 and it is positive, parse it as uint64.
+func parseInteger(s string, radix int) (AstSlice, error) {
+	v, err := strconv.ParseInt(s, radix, 64)
+	if err == nil {
+		return AstSlice{{
+			ClassName: class.Int,
+			Type:      AstType_Int,
+			Value:     v,
+		}}, nil
+	}
+	if ne, ok := err.(*strconv.NumError); !ok || ne.Err != strconv.ErrRange || strings.HasPrefix(s, "-") {
+		return nil, err
+	}
+	u, err := strconv.ParseUint(strings.TrimPrefix(s, "+"), radix, 64)
+	if err != nil {
+		return nil, err
+	}
+	return AstSlice{{
+		ClassName: class.Uint,
+		Type:      AstType_Uint,
+		Value:     u,
+	}}, nil
+}
+
 func numberOrComplexTransform(ctx ParserContext, asts AstSlice) (AstSlice, error) {
 	if len(asts) == 1 {
 		return asts, nil
@@ -118,6 +180,10 @@ func numberOrComplexTransform(ctx ParserContext, asts AstSlice) (AstSlice, error
 		re = float64(x)
 	case uint64:
 		re = float64(x)
+	case Number:
+		re, _ = x.Float64()
+	case *big.Int, *big.Float, *big.Rat:
+		re = bignum.ToFloat64(x)
 	case map[string]interface{}:
 		re = x
 	}
@@ -133,6 +199,11 @@ func numberOrComplexTransform(ctx ParserContext, asts AstSlice) (AstSlice, error
 		im = sign * float64(x)
 	case uint64:
 		im = sign * float64(x)
+	case Number:
+		f, _ := x.Float64()
+		im = sign * f
+	case *big.Int, *big.Float, *big.Rat:
+		im = sign * bignum.ToFloat64(x)
 	case map[string]interface{}:
 		if _, ok := x["inf"]; ok {
 			x["inf"] = sign * x["inf"].(float64)
@@ -162,127 +233,184 @@ func numberOrComplexTransform(ctx ParserContext, asts AstSlice) (AstSlice, error
 }
 
 func tableTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	root, err := assembleTable(ctx, asts)
+	if err != nil {
+		return nil, err
+	}
+	return AstSlice{{
+		ClassName: class.Object,
+		Type:      AstType_Any,
+		Value:     root.table.value(),
+	}}, nil
+}
+
+// Assemble the table of the TOML section (`[table]`, `[[array-of-tables]]`).
+// The value is the node of the table tree, so that the document keeps
+// the sub tables and the definitions of the keys in the section.
+func sectionTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	root, err := assembleTable(ctx, asts)
+	if err != nil {
+		return nil, err
+	}
+	return AstSlice{{
+		ClassName: class.Object,
+		Type:      AstType_Any,
+		Value:     root,
+	}}, nil
+}
+
+// Assemble the table from the key-value pairs.
+// The values of the sections are merged with their nodes.
+func assembleTable(ctx ParserContext, asts AstSlice) (*tableNode, error) {
 	length := len(asts)
-	v := make(map[string]interface{})
 
-	lastRefs := make(map[string]*map[string]interface{})
-	lastRefs[""] = &v
+	opts := ctx.Tag.(parseOptions)
+	limits := &opts.limits
+	strict := opts.strictness != Strictness_None
+
+	root := newTableNode(newObject(ctx), strict)
 
 	for i := 0; i < length; i += 2 {
-		switch w := asts[i].Value.(type) {
+		var w []string
+		switch k := asts[i].Value.(type) {
 		case string:
 			// Simple identifier
-			merged := false
+			w = []string{k}
+		case []string:
+			// Dotted identifier
+			w = k
+		default:
+			continue
+		}
+		keyPos := asts[i].SourcePosition
+		valueClass := asts[i+1].ClassName
+		value := asts[i+1].Value
+
+		section, isSection := value.(*tableNode)
+		if isSection {
+			value = section.table.value()
+		}
 
-			if asts[i+1].ClassName == class.TomlArrayOfTable {
-				var a2 []map[string]interface{}
-				if tmp, ok := v[w].([]map[string]interface{}); ok {
-					a2 = tmp
+		if err := limits.countNodes(opts.state, 1, keyPos); err != nil {
+			return nil, err
+		}
+
+		node := root
+		for j, key := range w {
+			if j == len(w)-1 {
+				table := node.table
+
+				if strict {
+					if err := node.defs.define(w, key, keyPos, valueClass, table); err != nil {
+						return nil, err
+					}
+				}
+
+				if valueClass == class.TomlArrayOfTable {
+					m1, ok := asObject(value)
+					if isSection {
+						node.setChild(key, section)
+					} else if ok {
+						node.setChild(key, newTableNode(m1, strict))
+					}
+					cur, _ := table.get(key)
+					a := appendArrayOfTables(ctx, cur, m1)
+					table.set(key, a)
+					if err := limits.checkArrayLength(lengthOfArrayOfTables(a), keyPos); err != nil {
+						return nil, err
+					}
 				} else {
-					// Register (case of v[w] == nil) or overwrite
-					// NOTE: If overwrite, it is invalid TOML
-					a2 = make([]map[string]interface{}, 0, 8)
-					v[w] = a2
+					merged := false
+					if m1, ok := asObject(value); ok {
+						cur, _ := table.get(key)
+						if m2, ok := asObject(cur); ok {
+							// Merge redefined table
+							// NOTE: Possibly an invalid TOML (except in cases such as `[x.y.z] ... [x.y]`)
+							child, ok := node.child(key)
+							if !ok {
+								child = newTableNode(m2, strict)
+								node.setChild(key, child)
+							}
+							if strict {
+								var fromDefs definitions
+								if isSection {
+									fromDefs = section.defs
+								}
+								if err := child.defs.checkMerge(w, keyPos, m1, m2, fromDefs); err != nil {
+									return nil, err
+								}
+							}
+							for _, xKey := range m1.keyList() {
+								xVal, _ := m1.get(xKey)
+								m2.set(xKey, xVal)
+							}
+							if isSection {
+								child.adopt(section)
+							}
+							if err := limits.checkMembers(m2, keyPos); err != nil {
+								return nil, err
+							}
+							child.table = m2
+							merged = true
+						} else if isSection {
+							node.setChild(key, section)
+						} else {
+							node.setChild(key, newTableNode(m1, strict))
+						}
+					}
+					if !merged {
+						table.set(key, value)
+					}
 				}
-				if m1, ok := asts[i+1].Value.(map[string]interface{}); ok {
-					dottedKey := makeDottedKeyForSimpleName(w)
-					lastRefs[dottedKey] = &m1
-					a2 = append(a2, m1)
-					v[w] = a2
+				if err := limits.checkMembers(table, keyPos); err != nil {
+					return nil, err
 				}
 			} else {
-				if m1, ok := asts[i+1].Value.(map[string]interface{}); ok {
-					dottedKey := makeDottedKeyForSimpleName(w)
-					lastRefs[dottedKey] = &m1
-					if m2, ok := v[w].(map[string]interface{}); ok {
-						// Merge redefined table
-						// NOTE: Possibly an invalid TOML (except in cases such as `[x.y.z] ... [x]`)
-						for xKey, xVal := range m1 {
-							m2[xKey] = xVal
-						}
-						merged = true
+				if strict {
+					if err := node.defs.traverse(w[:j+1], key, keyPos, valueClass); err != nil {
+						return nil, err
 					}
 				}
-				if !merged {
-					v[w] = asts[i+1].Value
-				}
-			}
-
-		case []string:
-			// Dotted identifier
-			for j, key := range w {
-				if j == len(w)-1 {
-					merged := false
-					table := lastRefs[makeDottedKey(w, j)]
 
-					if asts[i+1].ClassName == class.TomlArrayOfTable {
-						var a2 []map[string]interface{}
-						if tmp, ok := (*table)[key].([]map[string]interface{}); ok {
-							a2 = tmp
+				next, ok := node.child(key)
+				if !ok {
+					// Not registered to the tree
+					if cur, ok := node.table.get(key); ok {
+						if table, ok := asObject(cur); ok {
+							// Register
+							next = newTableNode(table, strict)
 						} else {
-							// Register (case of (*table)[key] == nil) or overwrite
-							// NOTE: If overwrite, it is invalid TOML
-							a2 = make([]map[string]interface{}, 0, 8)
-							(*table)[key] = a2
-						}
-						if m1, ok := asts[i+1].Value.(map[string]interface{}); ok {
-							dottedKey := makeDottedKey(w, j+1)
-							lastRefs[dottedKey] = &m1
-							a2 = append(a2, m1)
-							(*table)[key] = a2
+							// Overwrite
+							// NOTE: it is invalid TOML
+							table := newObject(ctx)
+							node.table.set(key, table.value())
+							next = newTableNode(table, strict)
 						}
 					} else {
-						if m1, ok := asts[i+1].Value.(map[string]interface{}); ok {
-							dottedKey := makeDottedKey(w, j+1)
-							lastRefs[dottedKey] = &m1
-							if m2, ok := (*table)[key].(map[string]interface{}); ok {
-								// Merge redefined table
-								// NOTE: Possibly an invalid TOML (except in cases such as `[x.y.z] ... [x.y]`)
-								for xKey, xVal := range m1 {
-									m2[xKey] = xVal
-								}
-								merged = true
-							}
-						}
-						if !merged {
-							(*table)[key] = asts[i+1].Value
-						}
-					}
-				} else {
-					prev := lastRefs[makeDottedKey(w, j)]
-					dottedKey := makeDottedKey(w, j+1)
-					if _, ok := lastRefs[dottedKey]; ok {
-						// Already registerd to lastRefs
-					} else {
-						// Not registerd to lastRefs
-						if cur, ok := (*prev)[key]; ok {
-							switch next := cur.(type) {
-							case map[string]interface{}:
-								// Register
-								lastRefs[dottedKey] = &next
-							default:
-								// Overwrite
-								// NOTE: it is invalid TOML
-								table := make(map[string]interface{})
-								(*prev)[key] = table
-								lastRefs[dottedKey] = &table
-							}
-						} else {
-							// Append
-							table := make(map[string]interface{})
-							(*prev)[key] = table
-							lastRefs[dottedKey] = &table
+						// Append
+						table := newObject(ctx)
+						node.table.set(key, table.value())
+						next = newTableNode(table, strict)
+						if err := limits.checkMembers(node.table, keyPos); err != nil {
+							return nil, err
 						}
 					}
+					node.setChild(key, next)
 				}
+				node = next
 			}
 		}
 	}
-	return AstSlice{{
-		ClassName: class.Object,
-		Type:      AstType_Any,
-		Value:     v,
-	}}, nil
+	return root, nil
+}
+
+// Set the start position of the key.
+func keyPositionTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	for i := range asts {
+		asts[i].SourcePosition = ctx.SourcePosition
+		asts[i].Length = 0
+	}
+	return asts, nil
 }
 
 func platformLinebreakTransformer(ctx ParserContext, asts AstSlice) (AstSlice, error) {
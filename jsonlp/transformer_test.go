@@ -0,0 +1,63 @@
+package jsonlp_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+func benchmarkTOMLDocument() string {
+	var sb strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&sb, "[servers.\"srv-%d\"]\n", i)
+		fmt.Fprintf(&sb, "addr.host = \"10.0.%d.%d\"\n", i/256, i%256)
+		fmt.Fprintf(&sb, "addr.port = %d\n", 8000+i)
+		sb.WriteString("limits.cpu.max = 4\nlimits.cpu.min = 1\nlimits.mem = \"1Gi\"\n\n")
+		sb.WriteString("[[servers.\"srv-" + fmt.Sprint(i) + "\".routes]]\npath = \"/\"\nopts.a.b.c = true\n\n")
+	}
+	return sb.String()
+}
+
+func benchmarkDottedKeysDocument() string {
+	var sb strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&sb, "config.service.cluster.node.%d.limits.cpu.max = %d\n", i%50, i)
+	}
+	return sb.String()
+}
+
+func BenchmarkParseTOML_DottedKeys(b *testing.B) {
+	src := benchmarkDottedKeysDocument()
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := jsonlp.ParseTOMLWithOptions(src, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseTOML_Tables(b *testing.B) {
+	src := benchmarkTOMLDocument()
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := jsonlp.ParseTOMLWithOptions(src, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseTOML_TablesStrict(b *testing.B) {
+	src := benchmarkTOMLDocument()
+	opts := &jsonlp.ParseOptions{Strictness: jsonlp.Strictness_NoRedefinition}
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := jsonlp.ParseTOMLWithOptions(src, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package marshal
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+var (
+	typeOfLocalDate     = reflect.TypeOf(jsonlp.LocalDate{})
+	typeOfLocalTime     = reflect.TypeOf(jsonlp.LocalTime{})
+	typeOfLocalDateTime = reflect.TypeOf(jsonlp.LocalDateTime{})
+)
+
+func isLocalDateTimeType(rt reflect.Type) bool {
+	return rt == typeOfLocalDate || rt == typeOfLocalTime || rt == typeOfLocalDateTime
+}
+
+// Convert the local date/time value to time.Time in UTC.
+// The local time is on 1970-01-01, as same as jsonlp parsers without ParseOptions.LocalDateTime.
+func localDateTimeToTime(v interface{}) time.Time {
+	switch x := v.(type) {
+	case jsonlp.LocalDate:
+		return x.In(time.UTC)
+	case jsonlp.LocalTime:
+		return jsonlp.LocalDate{Year: 1970, Month: time.January, Day: 1}.At(x).In(time.UTC)
+	default:
+		return v.(jsonlp.LocalDateTime).In(time.UTC)
+	}
+}
+
+// Convert jsonlp.LocalDate, jsonlp.LocalTime, jsonlp.LocalDateTime to
+// interface{}, time.Time, string, or the local date/time types.
+// Also convert time.Time and string to the local date/time types.
+// If neither rvFrom nor rvTo is the local date/time type, it returns false.
+func unmarshalLocalDateTime(rvFrom, rvTo reflect.Value, ctx *marshalContext) (bool, error) {
+	rtTo := rvTo.Type()
+	if !rvFrom.IsValid() || rvTo.Kind() == reflect.Pointer {
+		return false, nil
+	}
+
+	if isLocalDateTimeType(rvFrom.Type()) {
+		v := rvFrom.Interface()
+		switch {
+		case rvTo.Kind() == reflect.Interface:
+			rvTo.Set(rvFrom)
+		case rtTo == typeOfTime:
+			rvTo.Set(reflect.ValueOf(localDateTimeToTime(v)))
+		case rvTo.Kind() == reflect.String:
+			rvTo.SetString(v.(fmt.Stringer).String())
+		case rtTo == rvFrom.Type():
+			rvTo.Set(rvFrom)
+		case isLocalDateTimeType(rtTo):
+			if rvFrom.Type() == typeOfLocalTime {
+				return true, fmt.Errorf("Type unmatched: %v -> %v", v, rtTo)
+			}
+			_, err := unmarshalLocalDateTime(reflect.ValueOf(localDateTimeToTime(v)), rvTo, ctx)
+			return true, err
+		default:
+			return true, fmt.Errorf("Type unmatched: %v -> %v", v, rtTo)
+		}
+		return true, nil
+	}
+
+	if !isLocalDateTimeType(rtTo) {
+		return false, nil
+	}
+
+	var t time.Time
+	switch {
+	case rvFrom.Type() == typeOfTime:
+		t = rvFrom.Interface().(time.Time)
+	case rvFrom.Kind() == reflect.String:
+		var v interface{}
+		var err error
+		switch rtTo {
+		case typeOfLocalDate:
+			v, err = jsonlp.ParseLocalDate(rvFrom.String())
+		case typeOfLocalTime:
+			v, err = jsonlp.ParseLocalTime(rvFrom.String())
+		default:
+			v, err = jsonlp.ParseLocalDateTime(rvFrom.String())
+		}
+		if err != nil {
+			return true, err
+		}
+		rvTo.Set(reflect.ValueOf(v))
+		return true, nil
+	default:
+		return true, fmt.Errorf("Type unmatched: %v -> %v", rvFrom.Interface(), rtTo)
+	}
+
+	switch rtTo {
+	case typeOfLocalDate:
+		rvTo.Set(reflect.ValueOf(jsonlp.LocalDateOf(t)))
+	case typeOfLocalTime:
+		rvTo.Set(reflect.ValueOf(jsonlp.LocalTimeOf(t)))
+	default:
+		rvTo.Set(reflect.ValueOf(jsonlp.LocalDateTimeOf(t)))
+	}
+	return true, nil
+}
@@ -0,0 +1,299 @@
+package marshal
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/shellyln/go-loose-json-parser/internal/bignum"
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+var (
+	typeOfNumber   = reflect.TypeOf(jsonlp.Number(""))
+	typeOfBigInt   = reflect.TypeOf(big.Int{})
+	typeOfBigFloat = reflect.TypeOf(big.Float{})
+	typeOfBigRat   = reflect.TypeOf(big.Rat{})
+)
+
+func isNumber(rv reflect.Value) bool {
+	return rv.Type() == typeOfNumber
+}
+
+// Convert the Number to int64.
+// It returns the error if the value is not an integer or does not fit in rvTo.
+func numberToInt64(n jsonlp.Number, rvTo reflect.Value) (int64, error) {
+	z, err := numberToBigInt(n, "Int")
+	if err != nil {
+		return 0, err
+	}
+	if !z.IsInt64() || rvTo.OverflowInt(z.Int64()) {
+		return 0, fmt.Errorf("Value out of range: %v -> Int", n)
+	}
+	return z.Int64(), nil
+}
+
+// Convert the Number to uint64.
+// It returns the error if the value is not an integer or does not fit in rvTo.
+func numberToUint64(n jsonlp.Number, rvTo reflect.Value) (uint64, error) {
+	z, err := numberToBigInt(n, "Uint")
+	if err != nil {
+		return 0, err
+	}
+	if !z.IsUint64() || rvTo.OverflowUint(z.Uint64()) {
+		return 0, fmt.Errorf("Value out of range: %v -> Uint", n)
+	}
+	return z.Uint64(), nil
+}
+
+// Convert the Number to *big.Int.
+// The literal that has the fraction part or the exponent is accepted if its value is an integer. (e.g. `1.0`, `1e3`)
+func numberToBigInt(n jsonlp.Number, typeName string) (*big.Int, error) {
+	if z, err := n.BigInt(); err == nil {
+		return z, nil
+	}
+	f, err := n.BigFloat()
+	if err != nil {
+		return nil, err
+	}
+	if !f.IsInt() {
+		return nil, fmt.Errorf("Value is not an integer: %v -> %s", n, typeName)
+	}
+	z, _ := f.Int(nil)
+	return z, nil
+}
+
+func numberToComplex(n jsonlp.Number) (complex128, error) {
+	f, err := n.Float64()
+	if err != nil {
+		return 0, err
+	}
+	return complex(f, 0), nil
+}
+
+// Convert the primitive value to *big.Int, *big.Float and *big.Rat.
+// If rvTo is not the type of math/big, it returns false.
+func unmarshalBig(rvFrom, rvTo reflect.Value, ctx *marshalContext) (bool, error) {
+	switch rvTo.Type() {
+	case typeOfBigInt:
+		z, err := toBigInt(rvFrom)
+		if err != nil {
+			return true, err
+		}
+		rvTo.Addr().Interface().(*big.Int).Set(z)
+	case typeOfBigFloat:
+		z, err := toBigFloat(rvFrom)
+		if err != nil {
+			return true, err
+		}
+		rvTo.Addr().Interface().(*big.Float).Set(z)
+	case typeOfBigRat:
+		z, err := toBigRat(rvFrom)
+		if err != nil {
+			return true, err
+		}
+		rvTo.Addr().Interface().(*big.Rat).Set(z)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func toBigInt(rvFrom reflect.Value) (*big.Int, error) {
+	switch v := bigValueOf(rvFrom).(type) {
+	case *big.Int:
+		return v, nil
+	case *big.Float:
+		if v.IsInf() {
+			return nil, fmt.Errorf("Type unmatched: %v -> big.Int", v)
+		}
+		z, _ := v.Int(nil)
+		return z, nil
+	case *big.Rat:
+		return new(big.Int).Quo(v.Num(), v.Denom()), nil
+	}
+
+	switch rvFrom.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rvFrom.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(rvFrom.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rvFrom.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("Type unmatched: %v -> big.Int", f)
+		}
+		z, _ := big.NewFloat(f).Int(nil)
+		return z, nil
+	case reflect.String:
+		n := jsonlp.Number(rvFrom.String())
+		if z, err := n.BigInt(); err == nil {
+			return z, nil
+		}
+		f, err := n.BigFloat()
+		if err != nil {
+			return nil, err
+		}
+		z, _ := f.Int(nil)
+		return z, nil
+	default:
+		return nil, fmt.Errorf("Type unmatched: %v -> big.Int", rvFrom.Interface())
+	}
+}
+
+func toBigFloat(rvFrom reflect.Value) (*big.Float, error) {
+	switch v := bigValueOf(rvFrom).(type) {
+	case *big.Int:
+		return new(big.Float).SetInt(v), nil
+	case *big.Float:
+		return v, nil
+	case *big.Rat:
+		return new(big.Float).SetRat(v), nil
+	}
+
+	switch rvFrom.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Float).SetInt64(rvFrom.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Float).SetUint64(rvFrom.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rvFrom.Float()
+		if math.IsNaN(f) {
+			return nil, fmt.Errorf("Type unmatched: %v -> big.Float", f)
+		}
+		return big.NewFloat(f), nil
+	case reflect.String:
+		return jsonlp.Number(rvFrom.String()).BigFloat()
+	default:
+		return nil, fmt.Errorf("Type unmatched: %v -> big.Float", rvFrom.Interface())
+	}
+}
+
+func toBigRat(rvFrom reflect.Value) (*big.Rat, error) {
+	switch v := bigValueOf(rvFrom).(type) {
+	case *big.Int:
+		return new(big.Rat).SetInt(v), nil
+	case *big.Float:
+		if v.IsInf() {
+			return nil, fmt.Errorf("Type unmatched: %v -> big.Rat", v)
+		}
+		z, _ := v.Rat(nil)
+		return z, nil
+	case *big.Rat:
+		return v, nil
+	}
+
+	switch rvFrom.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(rvFrom.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(rvFrom.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		z := new(big.Rat).SetFloat64(rvFrom.Float())
+		if z == nil {
+			return nil, fmt.Errorf("Type unmatched: %v -> big.Rat", rvFrom.Float())
+		}
+		return z, nil
+	case reflect.String:
+		z, ok := new(big.Rat).SetString(rvFrom.String())
+		if !ok {
+			return nil, &strconv.NumError{Func: "BigRat", Num: rvFrom.String(), Err: strconv.ErrSyntax}
+		}
+		return z, nil
+	default:
+		return nil, fmt.Errorf("Type unmatched: %v -> big.Rat", rvFrom.Interface())
+	}
+}
+
+// Returns *big.Int, *big.Float or *big.Rat if rv is the value of math/big types.
+// Otherwise, returns nil.
+func bigValueOf(rv reflect.Value) interface{} {
+	if !rv.IsValid() {
+		return nil
+	}
+	switch rv.Type() {
+	case typeOfBigInt, typeOfBigFloat, typeOfBigRat:
+		if !rv.CanAddr() {
+			ptr := reflect.New(rv.Type())
+			ptr.Elem().Set(rv)
+			return ptr.Interface()
+		}
+		return rv.Addr().Interface()
+	}
+	return nil
+}
+
+// Convert the value of math/big types to the primitive types.
+// If rvFrom is not the type of math/big, it returns false.
+func unmarshalFromBig(rvFrom, rvTo reflect.Value, ctx *marshalContext) (bool, error) {
+	v := bigValueOf(rvFrom)
+	if v == nil {
+		return false, nil
+	}
+
+	switch rvTo.Kind() {
+	case reflect.Interface:
+		// NOTE: Copy it to avoid sharing the internal buffer.
+		switch z := v.(type) {
+		case *big.Int:
+			rvTo.Set(reflect.ValueOf(new(big.Int).Set(z)))
+		case *big.Float:
+			rvTo.Set(reflect.ValueOf(new(big.Float).Copy(z)))
+		case *big.Rat:
+			rvTo.Set(reflect.ValueOf(new(big.Rat).Set(z)))
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		z, err := toBigInt(rvFrom)
+		if err != nil {
+			return true, err
+		}
+		if !z.IsInt64() || rvTo.OverflowInt(z.Int64()) {
+			return true, fmt.Errorf("Value out of range: %v -> Int", z)
+		}
+		rvTo.SetInt(z.Int64())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		z, err := toBigInt(rvFrom)
+		if err != nil {
+			return true, err
+		}
+		if !z.IsUint64() || rvTo.OverflowUint(z.Uint64()) {
+			return true, fmt.Errorf("Value out of range: %v -> Uint", z)
+		}
+		rvTo.SetUint(z.Uint64())
+
+	case reflect.Float32, reflect.Float64:
+		rvTo.SetFloat(bignum.ToFloat64(v))
+
+	case reflect.Complex64, reflect.Complex128:
+		rvTo.SetComplex(complex(bignum.ToFloat64(v), 0))
+
+	case reflect.Bool:
+		switch z := v.(type) {
+		case *big.Int:
+			rvTo.SetBool(z.Sign() != 0)
+		case *big.Float:
+			rvTo.SetBool(z.Sign() != 0)
+		case *big.Rat:
+			rvTo.SetBool(z.Sign() != 0)
+		}
+
+	case reflect.String:
+		switch z := v.(type) {
+		case *big.Int:
+			rvTo.SetString(z.String())
+		case *big.Float:
+			rvTo.SetString(z.Text('g', -1))
+		case *big.Rat:
+			rvTo.SetString(z.RatString())
+		}
+
+	default:
+		// big.Int -> big.Float, etc. are converted by unmarshalBig()
+		return false, nil
+	}
+	return true, nil
+}
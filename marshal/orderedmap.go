@@ -0,0 +1,62 @@
+package marshal
+
+import (
+	"reflect"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+)
+
+var (
+	typeOfAny        = reflect.TypeOf((*interface{})(nil)).Elem()
+	typeOfOrderedMap = reflect.TypeOf(jsonlp.OrderedMap{})
+)
+
+func orderedMapOf(rv reflect.Value) *jsonlp.OrderedMap {
+	if !rv.IsValid() || rv.Type() != typeOfOrderedMap {
+		return nil
+	}
+	if !rv.CanAddr() {
+		ptr := reflect.New(typeOfOrderedMap)
+		ptr.Elem().Set(rv)
+		return ptr.Interface().(*jsonlp.OrderedMap)
+	}
+	return rv.Addr().Interface().(*jsonlp.OrderedMap)
+}
+
+// Convert *jsonlp.OrderedMap as same as map[string]interface{}.
+// If the destination is interface{} or jsonlp.OrderedMap, the order of the keys is kept.
+// If rvFrom is not *jsonlp.OrderedMap, it returns false.
+func unmarshalFromOrderedMap(rvFrom, rvTo reflect.Value, ctx *marshalContext) (bool, error) {
+	m := orderedMapOf(rvFrom)
+	if m == nil {
+		return false, nil
+	}
+
+	switch {
+	case rvTo.Kind() == reflect.Interface || rvTo.Type() == typeOfOrderedMap:
+		dst := jsonlp.NewOrderedMap()
+		var err error
+		m.Range(func(key string, value interface{}) bool {
+			rvDest := reflect.New(typeOfAny).Elem()
+			// NOTE: Pass the addressable interface value. (value may be nil)
+			if err = unmarshalCore(reflect.ValueOf(&value).Elem(), rvDest, ctx, false); err != nil {
+				return false
+			}
+			dst.Set(key, rvDest.Interface())
+			return true
+		})
+		if err != nil {
+			return true, err
+		}
+		if rvTo.Kind() == reflect.Interface {
+			rvTo.Set(reflect.ValueOf(dst))
+		} else {
+			rvTo.Set(reflect.ValueOf(dst).Elem())
+		}
+		return true, nil
+
+	case rvTo.Kind() == reflect.Map || rvTo.Kind() == reflect.Struct:
+		return true, unmarshalCore(reflect.ValueOf(m.ToMap()), rvTo, ctx, true)
+	}
+	return false, nil
+}
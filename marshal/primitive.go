@@ -9,6 +9,8 @@ import (
 	"reflect"
 	"strconv"
 	"time"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
 )
 
 func unmarshalInt(rvFrom, rvTo reflect.Value, ctx *marshalContext) error {
@@ -33,6 +35,14 @@ func unmarshalInt(rvFrom, rvTo reflect.Value, ctx *marshalContext) error {
 		if v == "" {
 			return nil
 		}
+		if isNumber(rvFrom) {
+			if z, err := numberToInt64(jsonlp.Number(v), rvTo); err != nil {
+				return err
+			} else {
+				rvTo.SetInt(z)
+			}
+			return nil
+		}
 		if z, err := strconv.ParseInt(v, 10, 64); err != nil {
 			return err
 		} else {
@@ -67,6 +77,14 @@ func unmarshalUint(rvFrom, rvTo reflect.Value, ctx *marshalContext) error {
 		if v == "" {
 			return nil
 		}
+		if isNumber(rvFrom) {
+			if z, err := numberToUint64(jsonlp.Number(v), rvTo); err != nil {
+				return err
+			} else {
+				rvTo.SetUint(z)
+			}
+			return nil
+		}
 		if z, err := strconv.ParseUint(v, 10, 64); err != nil {
 			return err
 		} else {
@@ -150,6 +168,14 @@ func unmarshalFloat(rvFrom, rvTo reflect.Value, ctx *marshalContext) error {
 		if v == "" {
 			return nil
 		}
+		if isNumber(rvFrom) {
+			if z, err := jsonlp.Number(v).Float64(); err != nil {
+				return err
+			} else {
+				rvTo.SetFloat(z)
+			}
+			return nil
+		}
 		if z, err := strconv.ParseFloat(v, 64); err != nil {
 			return err
 		} else {
@@ -243,6 +269,14 @@ func unmarshalComplex(rvFrom, rvTo reflect.Value, ctx *marshalContext) error {
 		if v == "" {
 			return nil
 		}
+		if isNumber(rvFrom) {
+			if z, err := numberToComplex(jsonlp.Number(v)); err != nil {
+				return err
+			} else {
+				rvTo.SetComplex(z)
+			}
+			return nil
+		}
 		if z, err := strconv.ParseComplex(v, 64); err != nil {
 			return err
 		} else {
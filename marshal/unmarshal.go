@@ -113,6 +113,16 @@ func unmarshalCore(rvFrom, rvTo reflect.Value, ctx *marshalContext, rvFromReused
 		}
 	}
 
+	if matched, err := unmarshalFromBig(rvFrom, rvTo, ctx); matched {
+		return err
+	}
+	if matched, err := unmarshalFromOrderedMap(rvFrom, rvTo, ctx); matched {
+		return err
+	}
+	if matched, err := unmarshalLocalDateTime(rvFrom, rvTo, ctx); matched {
+		return err
+	}
+
 	switch rvTo.Kind() {
 	case reflect.Pointer:
 		reflect.New(rtTo.Elem())
@@ -215,6 +225,10 @@ func unmarshalCore(rvFrom, rvTo reflect.Value, ctx *marshalContext, rvFromReused
 		}
 
 	case reflect.Struct:
+		if matched, err := unmarshalBig(rvFrom, rvTo, ctx); matched {
+			return err
+		}
+
 		switch rvFrom.Kind() {
 		case reflect.Struct:
 			length := rvTo.NumField()
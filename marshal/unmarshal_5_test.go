@@ -0,0 +1,342 @@
+package marshal_test
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shellyln/go-loose-json-parser/jsonlp"
+	"github.com/shellyln/go-loose-json-parser/marshal"
+)
+
+func TestNumber1(t *testing.T) {
+	type config struct {
+		I   int64       `json:"i"`
+		I8  int8        `json:"i8"`
+		U   uint64      `json:"u"`
+		F   float64     `json:"f"`
+		C   complex128  `json:"c"`
+		S   string      `json:"s"`
+		X   int         `json:"x"`
+		Bi  *big.Int    `json:"bi"`
+		Bf  *big.Float  `json:"bf"`
+		Br  *big.Rat    `json:"br"`
+		Any interface{} `json:"any"`
+	}
+
+	parsed, err := jsonlp.ParseJSONWithOptions(`{
+        i:   9007199254740993,
+        i8:  -12,
+        u:   18446744073709551615,
+        f:   1.5e3,
+        c:   2.5,
+        s:   1_000,
+        x:   0xff,
+        bi:  123456789012345678901234567890,
+        bf:  123456789012345678901234567890,
+        br:  0.1,
+        any: 3.14,
+    }`, &jsonlp.ParseOptions{
+		NumberMode: jsonlp.Number_Lossless,
+	})
+
+	if err != nil {
+		t.Errorf("Parse: error = %v\n", err)
+		return
+	}
+
+	var dst config
+	if err := marshal.Unmarshal(parsed, &dst, nil); err != nil {
+		t.Errorf("Unmarshal: error = %v\n", err)
+		return
+	}
+
+	bi, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	br, _ := new(big.Rat).SetString("1/10")
+	want := config{
+		I:   9007199254740993,
+		I8:  -12,
+		U:   18446744073709551615,
+		F:   1500,
+		C:   complex(2.5, 0),
+		S:   "1000",
+		X:   255,
+		Bi:  bi,
+		Br:  br,
+		Any: jsonlp.Number("3.14"),
+	}
+	bf := dst.Bf
+	dst.Bf = nil
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("dst: %v, want: %v\n", dst, want)
+	}
+	if bf == nil || bf.Text('f', 0) != "123456789012345678901234567890" {
+		t.Errorf("dst.Bf: %v, want: %v\n", bf, "123456789012345678901234567890")
+	}
+}
+
+func TestNumber2(t *testing.T) {
+	var dst struct {
+		Bi big.Int
+		Bf big.Float
+		Br big.Rat
+	}
+	src := map[string]interface{}{
+		"Bi": int64(-3),
+		"Bf": float64(0.5),
+		"Br": uint64(7),
+	}
+	if err := marshal.Unmarshal(src, &dst, nil); err != nil {
+		t.Errorf("Unmarshal: error = %v\n", err)
+		return
+	}
+	if dst.Bi.Int64() != -3 {
+		t.Errorf("dst.Bi: %v, want: %v\n", &dst.Bi, -3)
+	}
+	if f, _ := dst.Bf.Float64(); f != 0.5 {
+		t.Errorf("dst.Bf: %v, want: %v\n", &dst.Bf, 0.5)
+	}
+	if dst.Br.RatString() != "7" {
+		t.Errorf("dst.Br: %v, want: %v\n", &dst.Br, 7)
+	}
+}
+
+func TestNumber3(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		dst     interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"int8 max", `{"V": 127}`, &struct{ V int8 }{}, int64(127), false},
+		{"int8 overflow", `{"V": 128}`, &struct{ V int8 }{}, nil, true},
+		{"int64 overflow", `{"V": 9223372036854775808}`, &struct{ V int64 }{}, nil, true},
+		{"uint8 overflow", `{"V": 300}`, &struct{ V uint8 }{}, nil, true},
+		{"uint negative", `{"V": -1}`, &struct{ V uint }{}, nil, true},
+		{"int exponent", `{"V": 1.5e3}`, &struct{ V int16 }{}, int64(1500), false},
+		{"int fraction", `{"V": 1.5}`, &struct{ V int }{}, nil, true},
+		{"uint fraction", `{"V": 2.25}`, &struct{ V uint }{}, nil, true},
+		{"uint zero fraction", `{"V": 2.0}`, &struct{ V uint }{}, uint64(2), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := jsonlp.ParseJSONWithOptions(tt.src, &jsonlp.ParseOptions{
+				NumberMode: jsonlp.Number_Lossless,
+			})
+			if err != nil {
+				t.Errorf("Parse: error = %v\n", err)
+				return
+			}
+			err = marshal.Unmarshal(parsed, tt.dst, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Unmarshal: error = %v, wantErr %v\n", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			rv := reflect.ValueOf(tt.dst).Elem().Field(0)
+			var got interface{}
+			if rv.CanInt() {
+				got = rv.Int()
+			} else {
+				got = rv.Uint()
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal: got = %v, want %v\n", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBig1(t *testing.T) {
+	type config struct {
+		I   int64       `json:"i"`
+		U   uint8       `json:"u"`
+		F   float64     `json:"f"`
+		S   string      `json:"s"`
+		B   bool        `json:"b"`
+		Bi  *big.Int    `json:"bi"`
+		Bf  *big.Float  `json:"bf"`
+		Br  big.Rat     `json:"br"`
+		Any interface{} `json:"any"`
+	}
+
+	parsed, err := jsonlp.ParseJSONWithOptions(`{
+        i:   -9007199254740993,
+        u:   255,
+        f:   0.5,
+        s:   123456789012345678901234567890,
+        b:   1,
+        bi:  12.75,
+        bf:  7,
+        br:  0.25,
+        any: 10n,
+    }`, &jsonlp.ParseOptions{
+		NumberMode: jsonlp.Number_BigRat,
+	})
+
+	if err != nil {
+		t.Errorf("Parse: error = %v\n", err)
+		return
+	}
+
+	var dst config
+	if err := marshal.Unmarshal(parsed, &dst, nil); err != nil {
+		t.Errorf("Unmarshal: error = %v\n", err)
+		return
+	}
+
+	if dst.I != -9007199254740993 || dst.U != 255 || dst.F != 0.5 || !dst.B {
+		t.Errorf("dst: %v\n", dst)
+	}
+	if dst.S != "123456789012345678901234567890" {
+		t.Errorf("dst.S: %v\n", dst.S)
+	}
+	if dst.Bi == nil || dst.Bi.Int64() != 12 {
+		t.Errorf("dst.Bi: %v\n", dst.Bi)
+	}
+	if f, _ := dst.Bf.Float64(); dst.Bf == nil || f != 7 {
+		t.Errorf("dst.Bf: %v\n", dst.Bf)
+	}
+	if dst.Br.RatString() != "1/4" {
+		t.Errorf("dst.Br: %v\n", &dst.Br)
+	}
+	if !reflect.DeepEqual(dst.Any, big.NewInt(10)) {
+		t.Errorf("dst.Any: %v\n", dst.Any)
+	}
+}
+
+func TestBig2(t *testing.T) {
+	var dst struct {
+		U uint8
+	}
+	parsed, err := jsonlp.ParseJSON(`{U: 256n}`, jsonlp.Linebreak_Lf, jsonlp.Interop_None)
+	if err != nil {
+		t.Errorf("Parse: error = %v\n", err)
+		return
+	}
+	if err := marshal.Unmarshal(parsed, &dst, nil); err == nil {
+		t.Errorf("Unmarshal: error = nil, want error\n")
+	}
+}
+
+func TestOrderedMap1(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	type config struct {
+		Addr  string                 `json:"addr"`
+		Items []item                 `json:"items"`
+		Map   map[string]interface{} `json:"map"`
+		Any   interface{}            `json:"any"`
+	}
+
+	parsed, err := jsonlp.ParseTOMLWithOptions(`
+addr = '127.0.0.1'
+any = { z = 1, y = 2, x = 3 }
+map = { b = { c = 1 } }
+[[items]]
+name = 'foo'
+[[items]]
+name = 'bar'
+`, &jsonlp.ParseOptions{
+		OrderedMap: true,
+	})
+
+	if err != nil {
+		t.Errorf("Parse: error = %v\n", err)
+		return
+	}
+
+	var dst config
+	if err := marshal.Unmarshal(parsed, &dst, nil); err != nil {
+		t.Errorf("Unmarshal: error = %v\n", err)
+		return
+	}
+
+	if dst.Addr != "127.0.0.1" {
+		t.Errorf("dst.Addr: %v\n", dst.Addr)
+	}
+	if !reflect.DeepEqual(dst.Items, []item{{Name: "foo"}, {Name: "bar"}}) {
+		t.Errorf("dst.Items: %v\n", dst.Items)
+	}
+	if m, ok := dst.Map["b"].(*jsonlp.OrderedMap); !ok || len(dst.Map) != 1 || !reflect.DeepEqual(m.Keys(), []string{"c"}) {
+		t.Errorf("dst.Map: %v\n", dst.Map)
+	}
+	if m, ok := dst.Any.(*jsonlp.OrderedMap); !ok || !reflect.DeepEqual(m.Keys(), []string{"z", "y", "x"}) {
+		t.Errorf("dst.Any: %v\n", dst.Any)
+	}
+}
+
+func TestLocalDateTime1(t *testing.T) {
+	type config struct {
+		T   time.Time            `json:"t"`
+		D   jsonlp.LocalDate     `json:"d"`
+		Tm  jsonlp.LocalTime     `json:"tm"`
+		Dt  jsonlp.LocalDateTime `json:"dt"`
+		Dp  *jsonlp.LocalDate    `json:"dp"`
+		S   string               `json:"s"`
+		Any interface{}          `json:"any"`
+		Off jsonlp.LocalDateTime `json:"off"`
+		Str jsonlp.LocalDate     `json:"str"`
+	}
+
+	parsed, err := jsonlp.ParseTOMLWithOptions(`
+t   = 2020-12-31T18:20:30
+d   = 2020-12-31T18:20:30
+tm  = 18:20:30.001
+dt  = 2020-12-31T18:20:30
+dp  = 2020-12-31
+s   = 2020-12-31T18:20:30
+any = 2020-12-31
+off = 2020-12-31T18:20:30Z
+str = '2020-12-31'
+`, &jsonlp.ParseOptions{
+		LocalDateTime: true,
+	})
+
+	if err != nil {
+		t.Errorf("Parse: error = %v\n", err)
+		return
+	}
+
+	var dst config
+	if err := marshal.Unmarshal(parsed, &dst, nil); err != nil {
+		t.Errorf("Unmarshal: error = %v\n", err)
+		return
+	}
+
+	date := jsonlp.LocalDate{Year: 2020, Month: time.December, Day: 31}
+	dt := date.At(jsonlp.LocalTime{Hour: 18, Minute: 20, Second: 30})
+
+	if !dst.T.Equal(time.Date(2020, 12, 31, 18, 20, 30, 0, time.UTC)) {
+		t.Errorf("dst.T: %v\n", dst.T)
+	}
+	if dst.D != date {
+		t.Errorf("dst.D: %v\n", dst.D)
+	}
+	if dst.Tm != (jsonlp.LocalTime{Hour: 18, Minute: 20, Second: 30, Nanosecond: 1000000}) {
+		t.Errorf("dst.Tm: %v\n", dst.Tm)
+	}
+	if dst.Dt != dt {
+		t.Errorf("dst.Dt: %v\n", dst.Dt)
+	}
+	if dst.Dp == nil || *dst.Dp != date {
+		t.Errorf("dst.Dp: %v\n", dst.Dp)
+	}
+	if dst.S != "2020-12-31T18:20:30" {
+		t.Errorf("dst.S: %v\n", dst.S)
+	}
+	if dst.Any != date {
+		t.Errorf("dst.Any: %v\n", dst.Any)
+	}
+	if dst.Off != dt {
+		t.Errorf("dst.Off: %v\n", dst.Off)
+	}
+	if dst.Str != date {
+		t.Errorf("dst.Str: %v\n", dst.Str)
+	}
+}
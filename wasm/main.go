@@ -4,7 +4,6 @@
 package main
 
 import (
-	"encoding/json"
 	"strings"
 	"syscall/js"
 
@@ -14,7 +13,6 @@ import (
 func normalizeJSON(this js.Value, args []js.Value) interface{} {
 	src := ""
 	indent := 0
-	var b []byte
 
 	if 0 < len(args) {
 		src = args[0].String()
@@ -23,30 +21,27 @@ func normalizeJSON(this js.Value, args []js.Value) interface{} {
 		indent = args[1].Int()
 	}
 
-	parsed, err := jsonlp.ParseJSON(src, jsonlp.Linebreak_Lf, jsonlp.Interop_JSON)
+	parsed, err := jsonlp.ParseJSON(src, jsonlp.Linebreak_Lf, jsonlp.Interop_None)
 	if err != nil {
 		return js.ValueOf(err.Error())
 	}
 
+	opts := &jsonlp.FormatOptions{Interop: jsonlp.Interop_JSON}
 	if 0 < indent {
-		b, err = json.MarshalIndent(parsed, "", strings.Repeat(" ", indent))
-		if err != nil {
-			return js.ValueOf(err.Error())
-		}
-	} else {
-		b, err = json.Marshal(parsed)
-		if err != nil {
-			return js.ValueOf(err.Error())
-		}
+		opts.Indent = strings.Repeat(" ", indent)
 	}
 
-	return js.ValueOf(string(b))
+	s, err := jsonlp.FormatJSON(parsed, opts)
+	if err != nil {
+		return js.ValueOf(err.Error())
+	}
+
+	return js.ValueOf(s)
 }
 
 func normalizeTOML(this js.Value, args []js.Value) interface{} {
 	src := ""
 	indent := 0
-	var b []byte
 
 	if 0 < len(args) {
 		src = args[0].String()
@@ -55,24 +50,22 @@ func normalizeTOML(this js.Value, args []js.Value) interface{} {
 		indent = args[1].Int()
 	}
 
-	parsed, err := jsonlp.ParseTOML(src, jsonlp.Linebreak_Lf, jsonlp.Interop_JSON)
+	parsed, err := jsonlp.ParseTOML(src, jsonlp.Linebreak_Lf, jsonlp.Interop_None)
 	if err != nil {
 		return js.ValueOf(err.Error())
 	}
 
+	opts := &jsonlp.FormatOptions{Interop: jsonlp.Interop_JSON}
 	if 0 < indent {
-		b, err = json.MarshalIndent(parsed, "", strings.Repeat(" ", indent))
-		if err != nil {
-			return js.ValueOf(err.Error())
-		}
-	} else {
-		b, err = json.Marshal(parsed)
-		if err != nil {
-			return js.ValueOf(err.Error())
-		}
+		opts.Indent = strings.Repeat(" ", indent)
+	}
+
+	s, err := jsonlp.FormatJSON(parsed, opts)
+	if err != nil {
+		return js.ValueOf(err.Error())
 	}
 
-	return js.ValueOf(string(b))
+	return js.ValueOf(s)
 }
 
 func getVersion(this js.Value, args []js.Value) interface{} {